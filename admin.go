@@ -2,8 +2,10 @@
 package admin
 
 import (
+	"context"
 	"expvar"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
@@ -13,10 +15,9 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/trace"
 	"gopkg.in/op/go-logging.v1"
 )
@@ -49,6 +50,7 @@ type Route struct {
 	group          string
 	includeInIndex bool
 	method         string
+	role           Role
 }
 
 // Opts is all flags associated with the admin HTTP server.
@@ -58,8 +60,82 @@ type Opts struct {
 	Port     int        `long:"port" default:"9990" description:"The port to listen on."`
 	Logger   Logger     `no-flag:"true"`
 	LogInfo  LoggerInfo `no-flag:"true"`
+
+	ShutdownTimeout time.Duration `long:"shutdown_timeout" default:"30s" description:"Time to wait for in-flight requests to complete when the server is shut down via its context."`
+	QuitTimeout     time.Duration `long:"quit_timeout" default:"30s" description:"Deadline for shutdown hooks to run when quitting via /quitquitquit."`
+
+	Socket      string       `long:"socket" description:"Path of a Unix domain socket to listen on instead of the host & port."`
+	TLSCert     string       `long:"tls_cert" description:"PEM-encoded certificate to serve TLS with. Reloaded when the file changes."`
+	TLSKey      string       `long:"tls_key" description:"PEM-encoded private key for the TLS certificate."`
+	TLSClientCA string       `long:"tls_client_ca" description:"PEM-encoded CA bundle. If set, clients must present a certificate signed by it."`
+	Listener    net.Listener `no-flag:"true"`
+
+	AssetDir string `long:"asset_dir" description:"Directory of UI assets served in preference to the embedded ones, e.g. for UI development or custom branding."`
+
+	// Authenticator identifies callers so that routes can be restricted by role. If nil, all routes are accessible to anyone.
+	Authenticator Authenticator `no-flag:"true"`
+
+	AuditLogSize int         `long:"audit_log_size" default:"1000" description:"Number of audited admin actions to retain in memory."`
+	AuditSinks   []AuditSink `no-flag:"true"`
+
+	// Registry holds the servers & clients displayed by the admin server. Defaults to DefaultRegistry.
+	Registry *Registry `no-flag:"true"`
+
+	HistoryDisabled  bool          `long:"history_disabled" description:"If true, no history of metrics is recorded."`
+	HistoryInterval  time.Duration `long:"history_interval" default:"5s" description:"Interval at which metrics are sampled into the in-memory history."`
+	HistoryRetention time.Duration `long:"history_retention" default:"30m" description:"How long the in-memory history of metrics is retained for."`
+	HistoryMetrics   []string      `long:"history_metric" description:"Metric families to record history of. Defaults to all of them."`
+
+	// Gatherers are the sources of metrics served by the admin server, merged in order. Defaults to Gatherer.
+	Gatherers []NamedGatherer `no-flag:"true"`
+
+	MetricsErrorHandling        string `long:"metrics_error_handling" default:"http_error" choice:"http_error" choice:"continue" description:"How /metrics handles errors gathering metrics: http_error fails the scrape, continue serves whatever could be gathered."`
+	MetricsMaxConcurrentScrapes int    `long:"metrics_max_concurrent_scrapes" description:"Maximum number of concurrent scrapes of /metrics, beyond which they're rejected with a 503. Unlimited if 0."`
+	MetricsDisableCompression   bool   `long:"metrics_disable_compression" description:"If true, /metrics responses aren't gzipped even if the client accepts it."`
+	MetricsDisableOpenMetrics   bool   `long:"metrics_disable_openmetrics" description:"If true, /metrics never negotiates the OpenMetrics format, which is needed to expose exemplars."`
+
+	ProfileDir                string        `long:"profile_dir" description:"Directory to store captured profiles in, so they survive restarts. If unset, they're held in memory."`
+	ProfileStoreSize          string        `long:"profile_store_size" default:"64MiB" description:"Maximum total size of stored profiles, beyond which the oldest are discarded."`
+	ProfileSchedule           time.Duration `long:"profile_schedule" description:"Interval at which to capture profiles automatically. Disabled if 0."`
+	ProfileScheduleKinds      []string      `long:"profile_schedule_kind" default:"heap" default:"goroutine" description:"Kinds of profile to capture on the schedule, e.g. cpu, heap, goroutine, mutex or block."`
+	ProfileCPUDuration        time.Duration `long:"profile_cpu_duration" default:"10s" description:"How long scheduled CPU profiles are captured for."`
+	ProfileHeapThreshold      string        `long:"profile_heap_threshold" description:"Capture a heap profile when the heap grows beyond this size, e.g. 1GiB."`
+	ProfileGoroutineThreshold int           `long:"profile_goroutine_threshold" description:"Capture a goroutine profile when there are more than this many goroutines."`
+
+	ContinuousProfiling            bool              `long:"continuous_profiling" description:"If true, profiles are captured continuously in the background and passed to the profile exporters."`
+	ContinuousProfilingInterval    time.Duration     `long:"continuous_profiling_interval" default:"1m" description:"Interval at which profiles are captured continuously."`
+	ContinuousProfilingCPUDuration time.Duration     `long:"continuous_profiling_cpu_duration" default:"10s" description:"How long each continuous CPU profile is captured over. Must be shorter than the interval."`
+	ContinuousProfilingKinds       []string          `long:"continuous_profiling_kind" description:"Kinds of profile to capture continuously. Defaults to cpu, heap, goroutine, mutex and block."`
+	ContinuousProfilingRetain      int               `long:"continuous_profiling_retain" default:"20" description:"Number of continuously captured profiles to retain in memory."`
+	ContinuousProfilingLabels      map[string]string `long:"continuous_profiling_label" description:"Labels attached to continuously captured profiles when they're exported, e.g. service:foo."`
+	ContinuousProfilingDir         string            `long:"continuous_profiling_dir" description:"Directory to write continuously captured profiles to."`
+	ContinuousProfilingURL         string            `long:"continuous_profiling_url" description:"URL to POST continuously captured profiles to."`
+	// ProfileExporters receive continuously captured profiles, in addition to the directory & URL above.
+	ProfileExporters []ProfileExporter `no-flag:"true"`
+
+	TraceRetain int `long:"trace_retain" default:"5" description:"Number of execution traces captured via /admin/traces or the flight recorder to retain in memory."`
+
+	FlightRecorder         bool          `long:"flight_recorder" description:"If true, the last few seconds of execution trace are kept in memory, to be snapshotted along with the goroutines when something goes wrong."`
+	FlightRecorderPeriod   time.Duration `long:"flight_recorder_period" default:"10s" description:"Approximate period of execution the flight recorder keeps."`
+	FlightRecorderSize     string        `long:"flight_recorder_size" default:"10MiB" description:"Approximate maximum size of the execution trace the flight recorder keeps, which takes precedence over its period."`
+	FlightRecorderTriggers []string      `long:"flight_recorder_trigger" description:"Snapshot the flight recorder when the sum of the series matched by a selector crosses a threshold, e.g. 'http_requests_in_flight > 100' or 'up{job=\"db\"} < 1'."`
+	FlightRecorderInterval time.Duration `long:"flight_recorder_interval" default:"5s" description:"Interval at which the flight recorder's triggers are checked."`
+	FlightRecorderCooldown time.Duration `long:"flight_recorder_cooldown" default:"1m" description:"Minimum time between snapshots of the flight recorder taken by its triggers or the application."`
+
+	DebugMaxConcurrent int           `long:"debug_max_concurrent" default:"1" description:"Maximum number of CPU profiles or traces that can be captured at once, via /debug/pprof, the admin pages or in the background."`
+	DebugMaxDuration   time.Duration `long:"debug_max_duration" default:"60s" description:"Longest CPU profile or trace that can be captured."`
+	DebugCooldown      time.Duration `long:"debug_cooldown" description:"Time after a CPU profile or trace finishes before another can be captured."`
+	DebugQueueTimeout  time.Duration `long:"debug_queue_timeout" description:"How long CPU profiles or traces wait for their turn before they're rejected. Rejected immediately if 0."`
+	// DebugLimits limits requests to other routes by path, or overrides the limits above for the CPU profiler & tracer,
+	// keyed by cpu & trace or by /debug/pprof/profile & /debug/pprof/trace.
+	DebugLimits map[string]DebugLimit `no-flag:"true"`
+
+	SummaryMetrics []string `long:"summary_metric" description:"runtime/metrics metrics to show in the summary header, e.g. /sched/goroutines:goroutines. See /admin/runtime/metrics for those available."`
 }
 
+// defaultShutdownTimeout is used when Opts.ShutdownTimeout isn't set.
+const defaultShutdownTimeout = 30 * time.Second
+
 // DefaultAdminHTTPServer is the global admin http server.
 var DefaultAdminHTTPServer = &HTTPServer{}
 
@@ -69,18 +145,21 @@ var routes = []Route{
 		handler:        RedirectHandler(AdminPath, http.StatusTemporaryRedirect),
 		alias:          "Admin Redirect",
 		includeInIndex: false,
+		role:           RoleAnonymous,
 	},
 	{
 		path:           AdminPath,
 		handler:        http.HandlerFunc(SummaryHandler),
 		alias:          "Summary",
 		includeInIndex: true,
+		role:           RoleViewer,
 	},
 	{
 		path:           AdminPath + "/",
 		handler:        RedirectHandler(AdminPath, http.StatusTemporaryRedirect),
 		alias:          "Admin Redirect",
 		includeInIndex: false,
+		role:           RoleAnonymous,
 	},
 	{
 		path:           "/admin/ping",
@@ -88,13 +167,134 @@ var routes = []Route{
 		alias:          "Ping",
 		includeInIndex: true,
 		group:          UtilitiesGroup,
+		role:           RoleAnonymous,
+	},
+	{
+		path:           "/admin/health",
+		handler:        http.HandlerFunc(HealthHandler),
+		alias:          "Health",
+		includeInIndex: true,
+		group:          UtilitiesGroup,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/health/live",
+		handler:        probeHandler(Liveness),
+		includeInIndex: false,
+		role:           RoleAnonymous,
+	},
+	{
+		path:           "/admin/health/ready",
+		handler:        probeHandler(Readiness),
+		includeInIndex: false,
+		role:           RoleAnonymous,
+	},
+	{
+		path:           "/admin/health/startup",
+		handler:        probeHandler(Startup),
+		includeInIndex: false,
+		role:           RoleAnonymous,
+	},
+	{
+		path:           "/admin/gc",
+		handler:        http.HandlerFunc(gcPageHandler),
+		alias:          "Garbage Collect",
+		includeInIndex: true,
+		group:          UtilitiesGroup,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/admin/gc",
 		handler:        http.HandlerFunc(gcHandler),
-		alias:          "Garbage Collect",
+		method:         http.MethodPost,
+		includeInIndex: false,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/runtime",
+		handler:        http.HandlerFunc(runtimeHandler),
+		alias:          "Runtime",
+		includeInIndex: true,
+		group:          ProcessInfoGroup,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/runtime.json",
+		handler:        http.HandlerFunc(runtimeJSONHandler),
+		includeInIndex: false,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/runtime",
+		handler:        http.HandlerFunc(updateRuntimeHandler),
+		method:         http.MethodPost,
+		includeInIndex: false,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/runtime/metrics",
+		handler:        http.HandlerFunc(runtimeMetricsHandler),
+		alias:          "Runtime Metrics",
+		includeInIndex: true,
+		group:          ProcessInfoGroup,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/runtime/metrics.json",
+		handler:        http.HandlerFunc(runtimeMetricsJSONHandler),
+		includeInIndex: false,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/goroutines",
+		handler:        http.HandlerFunc(goroutinesHandler),
+		alias:          "Goroutines",
+		includeInIndex: true,
+		group:          ProcessInfoGroup,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/goroutines.json",
+		handler:        http.HandlerFunc(goroutinesJSONHandler),
+		includeInIndex: false,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/goroutines/snapshots",
+		handler:        http.HandlerFunc(goroutineSnapshotHandler),
+		method:         http.MethodPost,
+		includeInIndex: false,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/drain",
+		handler:        drainPageHandler("Drain", "Fail the readiness probe so this instance is taken out of rotation, and notify shutdown hooks."),
+		alias:          "Drain",
 		includeInIndex: true,
 		group:          UtilitiesGroup,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/admin/drain",
+		handler:        http.HandlerFunc(drainHandler),
+		method:         http.MethodPost,
+		includeInIndex: false,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/abortabortabort",
+		handler:        drainPageHandler("Abort", "Exit immediately, without draining or running shutdown hooks."),
+		alias:          "Abort",
+		includeInIndex: true,
+		group:          UtilitiesGroup,
+		role:           RoleOperator,
+	},
+	{
+		path:           "/abortabortabort",
+		handler:        http.HandlerFunc(abortHandler),
+		method:         http.MethodPost,
+		includeInIndex: false,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/admin/logging",
@@ -102,12 +302,14 @@ var routes = []Route{
 		alias:          "Logging",
 		group:          UtilitiesGroup,
 		includeInIndex: true,
+		role:           RoleViewer,
 	},
 	{
 		path:           "/admin/logging",
 		handler:        http.HandlerFunc(UpdateLoggingHandler),
 		method:         http.MethodPost,
 		includeInIndex: false,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/admin/metrics",
@@ -115,6 +317,13 @@ var routes = []Route{
 		alias:          "Metrics",
 		includeInIndex: true,
 		group:          MetricsGroup,
+		role:           RoleViewer,
+	},
+	{
+		path:           "/admin/metrics/labels",
+		handler:        http.HandlerFunc(MetricLabelsHandler),
+		includeInIndex: false,
+		role:           RoleViewer,
 	},
 	{
 		path:           "/debug/pprof/",
@@ -122,6 +331,7 @@ var routes = []Route{
 		alias:          "PProf",
 		includeInIndex: true,
 		group:          PerfProfileGroup,
+		role:           RoleViewer,
 	},
 	{
 		path:           "/debug/pprof/cmdline",
@@ -129,6 +339,7 @@ var routes = []Route{
 		alias:          "CmdLine",
 		includeInIndex: true,
 		group:          PerfProfileGroup,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/debug/pprof/profile",
@@ -136,6 +347,7 @@ var routes = []Route{
 		alias:          "Profile",
 		includeInIndex: true,
 		group:          PerfProfileGroup,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/debug/pprof/symbol",
@@ -143,6 +355,7 @@ var routes = []Route{
 		alias:          "Symbol",
 		includeInIndex: true,
 		group:          PerfProfileGroup,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/debug/pprof/trace",
@@ -150,12 +363,14 @@ var routes = []Route{
 		alias:          "Trace",
 		includeInIndex: true,
 		group:          PerfProfileGroup,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/debug/pprof/",
 		prefix:         true,
 		handler:        http.HandlerFunc(pprof.Index),
 		includeInIndex: false,
+		role:           RoleOperator,
 	},
 	{
 		path:           "/debug/events",
@@ -163,6 +378,7 @@ var routes = []Route{
 		alias:          "Event Traces",
 		includeInIndex: true,
 		group:          UtilitiesGroup,
+		role:           RoleViewer,
 	},
 	{
 		path:           "/debug/requests",
@@ -170,6 +386,7 @@ var routes = []Route{
 		alias:          "Request Traces",
 		includeInIndex: true,
 		group:          UtilitiesGroup,
+		role:           RoleViewer,
 	},
 	{
 		path:           "/debug/vars",
@@ -177,20 +394,21 @@ var routes = []Route{
 		alias:          "Vars",
 		includeInIndex: true,
 		group:          ProcessInfoGroup,
+		role:           RoleOperator,
 	},
 	{
-		path: "/metrics",
-		handler: promhttp.HandlerFor(
-			prometheus.Gatherers{Gatherer},
-			promhttp.HandlerOpts{}),
+		path:           "/metrics",
+		handler:        scrapeHandler,
 		includeInIndex: false,
 		alias:          "Metrics",
+		role:           RoleViewer,
 	},
 	{
 		path:           "/favicon.ico",
 		handler:        ResourceHandler("/", "img"),
 		alias:          "Favicon",
 		includeInIndex: false,
+		role:           RoleAnonymous,
 	},
 	{
 		path:           FilesPath,
@@ -198,6 +416,7 @@ var routes = []Route{
 		handler:        ResourceHandler(FilesPath, ""),
 		includeInIndex: false,
 		alias:          "Files",
+		role:           RoleAnonymous,
 	},
 }
 
@@ -215,20 +434,24 @@ func RedirectHandler(url string, code int) http.Handler {
 }
 
 // ResourceHandler returns the asset, relative to the given paths.
+// Assets are served from Opts.AssetDir if set and it contains them, otherwise from those embedded in the binary.
 func ResourceHandler(baseRequestPath, baseResourcePath string) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		urlPath := strings.TrimPrefix(request.URL.Path, baseRequestPath)
 		writeContentType(writer, detectTypeFromExtension(urlPath))
 		assetPath := path.Join(baseResourcePath, urlPath)
-		if contents, err := Asset(assetPath); err != nil {
-			log.Warningf("Resource %s not found (for %s)", assetPath, request.URL.Path)
+		if err := serveAsset(writer, request, assetPath); err != nil {
+			log.Warningf("Resource %s not found (for %s): %s", assetPath, request.URL.Path, err)
 			writer.WriteHeader(http.StatusNotFound)
-		} else {
-			writer.Write(contents)
 		}
 	})
 }
 
+func gcPageHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	fmt.Fprintf(w, `<form action="/admin/gc" method="POST">%s<input type="submit" class="btn btn-primary" value="Force garbage collection"/></form>`, CSRFField(r))
+}
+
 func gcHandler(w http.ResponseWriter, r *http.Request) {
 	log.Infof("Forcing GC...")
 	runtime.GC()
@@ -237,33 +460,370 @@ func gcHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // HTTPServer is a holder for the router and server involved in serving the admin UI.
+// Its zero value is ready to use; the built-in admin routes are registered on first use.
 type HTTPServer struct {
+	mutex          sync.RWMutex
+	defaults       sync.Once
 	adminHTTPMuxer *mux.Router
 	allRoutes      []Route
+	authenticator  Authenticator
+	audits         *auditLog
+	registry       *Registry
+	quitTimeout    time.Duration
+	history        *metricHistory
+	profiles       *profileStore
+	profiler       *continuousProfiler
+	debugLimits    *debugLimiters
+	traces         *traceStore
+	recorder       *flightRecorder
+
+	lifecycle       sync.Mutex
+	server          *http.Server
+	listener        net.Listener
+	cancel          context.CancelFunc
+	done            chan struct{}
+	shutdownTimeout time.Duration
+}
+
+// RouteOptions describes how a route registered via Handle is matched and displayed.
+type RouteOptions struct {
+	// Alias is the name displayed for the route in the sidebar nav.
+	Alias string
+	// Group is the sidebar group the route is displayed under. Routes without a group are top level.
+	Group string
+	// Method is the HTTP method the route responds to. Defaults to GET.
+	Method string
+	// Prefix matches all paths beginning with the route's path, rather than only the exact path.
+	Prefix bool
+	// InIndex includes the route in the sidebar nav. It may only be set on GET routes.
+	InIndex bool
+	// Role is required to access the route when the server has an Authenticator. Defaults to RoleViewer.
+	Role Role
+}
+
+// Handle registers a handler for the given path on the admin server.
+// It can be called before or after the server has started serving.
+// Exact routes always take precedence over prefix routes, and longer prefixes over shorter ones,
+// so a route can be registered beneath an existing prefix such as FilesPath.
+// An error is returned if the path or handler is invalid, or if a route with the same path,
+// method & prefix is already registered.
+func (a *HTTPServer) Handle(path string, handler http.Handler, opts RouteOptions) error {
+	if handler == nil {
+		return fmt.Errorf("admin route %s has a nil handler", path)
+	} else if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("admin route path %q must begin with /", path)
+	} else if opts.InIndex && opts.Method != "" && opts.Method != http.MethodGet {
+		return fmt.Errorf("admin route %s %s cannot be included in the index; only GET routes are", opts.Method, path)
+	}
+	a.init()
+	return a.addAdminRoutes(Route{
+		path:           path,
+		prefix:         opts.Prefix,
+		handler:        handler,
+		alias:          opts.Alias,
+		group:          opts.Group,
+		includeInIndex: opts.InIndex,
+		method:         opts.Method,
+		role:           opts.Role,
+	})
+}
+
+// HandleFunc registers a handler function for the given path on the admin server.
+func (a *HTTPServer) HandleFunc(path string, handler func(http.ResponseWriter, *http.Request), opts RouteOptions) error {
+	return a.Handle(path, http.HandlerFunc(handler), opts)
+}
+
+// Handle registers a handler for the given path on the default admin server.
+func Handle(path string, handler http.Handler, opts RouteOptions) error {
+	return DefaultAdminHTTPServer.Handle(path, handler, opts)
+}
+
+// HandleFunc registers a handler function for the given path on the default admin server.
+func HandleFunc(path string, handler func(http.ResponseWriter, *http.Request), opts RouteOptions) error {
+	return DefaultAdminHTTPServer.HandleFunc(path, handler, opts)
+}
+
+// ServeHTTP dispatches the request to the current set of admin routes.
+func (a *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.init()
+	a.mutex.RLock()
+	muxer := a.adminHTTPMuxer
+	a.mutex.RUnlock()
+	muxer.ServeHTTP(w, r)
+}
+
+// serverRoutes returns the built-in routes whose handlers are bound to this server.
+func (a *HTTPServer) serverRoutes() []Route {
+	return []Route{
+		{
+			path:           "/admin/audit",
+			handler:        http.HandlerFunc(a.auditHandler),
+			alias:          "Audit Log",
+			includeInIndex: true,
+			group:          UtilitiesGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/audit.json",
+			handler:        http.HandlerFunc(a.auditJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/metrics/history",
+			handler:        http.HandlerFunc(a.historyHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/profiles",
+			handler:        http.HandlerFunc(a.profilesHandler),
+			alias:          "Profiles",
+			includeInIndex: true,
+			group:          ProcessInfoGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/profiles.json",
+			handler:        http.HandlerFunc(a.profilesJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/profiles",
+			handler:        http.HandlerFunc(a.captureProfileHandler),
+			method:         http.MethodPost,
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/profiles/diff",
+			handler:        http.HandlerFunc(a.profileDiffHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/profiles/view",
+			handler:        http.HandlerFunc(a.profileViewHandler),
+			alias:          "Profile Viewer",
+			includeInIndex: true,
+			group:          PerfProfileGroup,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/profiles/view.json",
+			handler:        http.HandlerFunc(a.profileViewJSONHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/profiles/",
+			prefix:         true,
+			handler:        http.HandlerFunc(a.profileHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/profiling",
+			handler:        http.HandlerFunc(a.profilingHandler),
+			alias:          "Continuous Profiling",
+			includeInIndex: true,
+			group:          ProcessInfoGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/profiling.json",
+			handler:        http.HandlerFunc(a.profilingJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/profiling/",
+			prefix:         true,
+			handler:        http.HandlerFunc(a.continuousProfileHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/traces",
+			handler:        http.HandlerFunc(a.tracesHandler),
+			alias:          "Execution Traces",
+			includeInIndex: true,
+			group:          ProcessInfoGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/traces.json",
+			handler:        http.HandlerFunc(a.tracesJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/traces",
+			handler:        http.HandlerFunc(a.captureTraceHandler),
+			method:         http.MethodPost,
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/traces/",
+			prefix:         true,
+			handler:        http.HandlerFunc(a.traceHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/flightrecorder",
+			handler:        http.HandlerFunc(a.flightRecorderHandler),
+			alias:          "Flight Recorder",
+			includeInIndex: true,
+			group:          ProcessInfoGroup,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/flightrecorder.json",
+			handler:        http.HandlerFunc(a.flightRecorderJSONHandler),
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/flightrecorder/snapshot",
+			handler:        http.HandlerFunc(a.snapshotFlightRecorderHandler),
+			method:         http.MethodPost,
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/debug/limits",
+			handler:        http.HandlerFunc(a.debugLimitsHandler),
+			alias:          "Debug Limits",
+			includeInIndex: true,
+			group:          PerfProfileGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/debug/limits.json",
+			handler:        http.HandlerFunc(a.debugLimitsJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/debug/limits/cancel",
+			handler:        http.HandlerFunc(a.cancelDebugRunHandler),
+			method:         http.MethodPost,
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/quitquitquit",
+			handler:        drainPageHandler("Quit", "Drain, run shutdown hooks in order, then exit."),
+			alias:          "Quit",
+			includeInIndex: true,
+			group:          UtilitiesGroup,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/quitquitquit",
+			handler:        http.HandlerFunc(a.quitHandler),
+			method:         http.MethodPost,
+			includeInIndex: false,
+			role:           RoleOperator,
+		},
+		{
+			path:           "/admin/lint",
+			handler:        http.HandlerFunc(a.lintHandler),
+			alias:          "Lint",
+			includeInIndex: true,
+			group:          UtilitiesGroup,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/lint.json",
+			handler:        http.HandlerFunc(a.lintJSONHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           "/admin/failedlint",
+			handler:        http.HandlerFunc(a.failedLintHandler),
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           ServersPath,
+			prefix:         true,
+			handler:        a.registryHandler(ServersPath, "server", func() []RegistryEntry { return a.getRegistry().Servers() }),
+			alias:          "Servers",
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+		{
+			path:           ClientsPath,
+			prefix:         true,
+			handler:        a.registryHandler(ClientsPath, "client", func() []RegistryEntry { return a.getRegistry().Clients() }),
+			alias:          "Clients",
+			includeInIndex: false,
+			role:           RoleViewer,
+		},
+	}
 }
 
-func (a *HTTPServer) addAdminRoutes(newRoutes ...Route) {
+// init registers the built-in admin routes, exactly once.
+func (a *HTTPServer) init() {
+	a.defaults.Do(func() {
+		if err := a.addAdminRoutes(append(routes, a.serverRoutes()...)...); err != nil {
+			panic(fmt.Sprintf("failed to register built-in admin routes: %s", err))
+		}
+	})
+}
+
+func (a *HTTPServer) addAdminRoutes(newRoutes ...Route) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	allRoutes := append([]Route{}, a.allRoutes...)
 	for _, r := range newRoutes {
-		method := r.method
-		if method == "" {
+		if r.method == "" {
 			r.method = http.MethodGet
 		}
-		a.allRoutes = append(a.allRoutes, r)
+		if r.role == "" {
+			r.role = RoleViewer
+		}
+		for _, existing := range allRoutes {
+			if existing.path == r.path && existing.method == r.method && existing.prefix == r.prefix {
+				return fmt.Errorf("admin route %s %s is already registered", r.method, r.path)
+			}
+		}
+		allRoutes = append(allRoutes, r)
+		log.Debugf("Registering admin route %s %s => %s", r.method, r.path, getFunctionName(r.handler))
 	}
+	a.allRoutes = allRoutes
 	a.updateMuxer()
+	return nil
 }
 
+// updateMuxer rebuilds the muxer from allRoutes. The caller must hold the write lock.
+// gorilla/mux matches routes in the order they're added, so exact routes are added first,
+// followed by prefix routes from longest to shortest.
 func (a *HTTPServer) updateMuxer() {
-	r := mux.NewRouter()
+	ordered := make([]Route, len(a.allRoutes))
+	copy(ordered, a.allRoutes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].prefix != ordered[j].prefix {
+			return !ordered[i].prefix
+		}
+		return ordered[i].prefix && len(ordered[i].path) > len(ordered[j].path)
+	})
 
-	for _, route := range a.allRoutes {
-		handler := &indexView{
+	r := mux.NewRouter()
+	for _, route := range ordered {
+		handler := a.authorize(route.role, a.audit(route.path, csrfProtect(&indexView{
 			title: route.alias,
-			next:  route.handler,
-			entries: func() entrySlice {
-				return a.indexEntries()
+			next:  a.limitDebug(route.path, route.handler),
+			entries: func(r *http.Request) entrySlice {
+				return a.indexEntries(PrincipalFromContext(r.Context()))
 			},
-		}
+		})))
 
 		if route.prefix {
 			r.PathPrefix(route.path).Handler(handler).Methods(route.method).Name(route.alias)
@@ -271,30 +831,27 @@ func (a *HTTPServer) updateMuxer() {
 			r.Path(route.path).Handler(handler).Methods(route.method).Name(route.alias)
 		}
 	}
-
-	endpoints := make(sort.StringSlice, 0, len(a.allRoutes))
-	for _, route := range a.allRoutes {
-		endpoints = append(endpoints, fmt.Sprintf("\t%s => %s", route.path, getFunctionName(route.handler)))
-	}
-	sort.Sort(endpoints)
-
-	log.Debugf("AdminHttpServer Muxer endpoints:\n%s", strings.Join(endpoints, "\n"))
-
 	a.adminHTTPMuxer = r
 }
 
-func (a *HTTPServer) indexEntries() []entry {
+// indexEntries returns the nav entries visible to the given principal.
+func (a *HTTPServer) indexEntries(p *Principal) []entry {
 	entries := make([]entry, 0)
-	// TODO(mike): Add clients, servers here
-	entries = append(entries, a.localRoutes()...)
+	if a.getAuthenticator() == nil || p.HasRole(RoleViewer) {
+		entries = append(entries, a.registryEntries()...)
+	}
+	entries = append(entries, a.localRoutes(p)...)
 
 	return entries
 }
 
-func (a *HTTPServer) localRoutes() []entry {
+func (a *HTTPServer) localRoutes(p *Principal) []entry {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
 	routes := make([]Route, 0)
 	for _, r := range a.allRoutes {
-		if r.includeInIndex {
+		if r.includeInIndex && (a.authenticator == nil || p.HasRole(r.role)) {
 			routes = append(routes, r)
 		}
 	}
@@ -336,7 +893,15 @@ func groupRoutesByGroup(routes []Route) map[string][]Route {
 	return results
 }
 
-func (a *HTTPServer) startServer(opts Opts) {
+// Start binds the admin server's listener and begins serving in the background.
+// Errors binding the listener are returned to the caller. The server is shut down gracefully
+// when the given context is cancelled, or when Shutdown is called.
+func (a *HTTPServer) Start(ctx context.Context, opts Opts) error {
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+	if a.server != nil {
+		return fmt.Errorf("admin http server is already running")
+	}
 	if opts.Logger != nil {
 		log = opts.Logger
 	}
@@ -345,11 +910,171 @@ func (a *HTTPServer) startServer(opts Opts) {
 	}
 	if opts.Disabled {
 		log.Infof("Not starting admin http")
-		return
+		return nil
+	}
+	if opts.AssetDir != "" {
+		log.Infof("Serving admin assets from %s", opts.AssetDir)
 	}
+	setAssetDir(opts.AssetDir)
+	// Gatherers are always set, so that a server restarted without them goes back to using Gatherer.
+	if err := setGatherers(opts.Gatherers); err != nil {
+		return err
+	}
+	if err := setScrapeOpts(opts); err != nil {
+		return err
+	}
+	if err := setSummaryMetrics(opts.SummaryMetrics); err != nil {
+		return err
+	}
+	profiles, profileTriggers, err := newProfileStoreFromOpts(opts)
+	if err != nil {
+		return err
+	}
+	profiler, err := newContinuousProfiler(opts)
+	if err != nil {
+		return err
+	}
+	debugLimits, err := newDebugLimiters(opts)
+	if err != nil {
+		return err
+	}
+	profiles.limits = debugLimits
+	if profiler != nil {
+		profiler.limits = debugLimits
+	}
+	traces := newTraceStore(opts.TraceRetain)
+	recorder, err := newFlightRecorder(opts, traces)
+	if err != nil {
+		return err
+	}
+	a.init()
+	a.mutex.Lock()
+	a.authenticator = opts.Authenticator
+	a.audits = newAuditLog(opts.AuditLogSize, opts.AuditSinks)
+	a.registry = opts.Registry
+	a.quitTimeout = opts.QuitTimeout
+	a.mutex.Unlock()
 
-	log.Infof("Serving admin http on %s:%d", opts.Host, opts.Port)
-	log.Errorf("Failed to serve admin HTTP: %s", http.ListenAndServe(fmt.Sprintf("%s:%d", opts.Host, opts.Port), a.adminHTTPMuxer))
+	listener, err := listen(ctx, opts)
+	if err != nil {
+		return err
+	}
+	log.Infof("Serving admin http on %s", listener.Addr())
+
+	// Requests are served with a context that's cancelled on shutdown, which signals long-running
+	// handlers such as /debug/pprof/profile to finish early and write out what they have.
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Handler:     a,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	done := make(chan struct{})
+	a.server = server
+	a.listener = listener
+	a.cancel = cancel
+	a.done = done
+	a.shutdownTimeout = opts.ShutdownTimeout
+	if a.shutdownTimeout == 0 {
+		a.shutdownTimeout = defaultShutdownTimeout
+	}
+
+	if !opts.HistoryDisabled {
+		interval, retention := opts.HistoryInterval, opts.HistoryRetention
+		if interval <= 0 {
+			interval = defaultHistoryInterval
+		}
+		if retention <= 0 {
+			retention = defaultHistoryRetention
+		}
+		history := newMetricHistory(int(retention/interval), retention, opts.HistoryMetrics)
+		a.mutex.Lock()
+		a.history = history
+		a.mutex.Unlock()
+		go history.run(baseCtx, interval)
+	}
+	a.mutex.Lock()
+	a.debugLimits = debugLimits
+	a.traces = traces
+	a.profiles = profiles
+	a.mutex.Unlock()
+	go profiles.run(baseCtx, profileTriggers)
+	if profiler != nil {
+		a.mutex.Lock()
+		a.profiler = profiler
+		a.mutex.Unlock()
+		go profiler.run(baseCtx)
+	}
+	if recorder != nil {
+		a.mutex.Lock()
+		a.recorder = recorder
+		a.mutex.Unlock()
+		go recorder.run(baseCtx)
+	}
+
+	go func() {
+		defer close(done)
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.Errorf("Failed to serve admin HTTP: %s", err)
+		}
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+			defer cancel()
+			if err := a.shutdown(shutdownCtx, server); err != nil {
+				log.Warningf("Failed to shut down admin HTTP cleanly: %s", err)
+			}
+		case <-done:
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the admin server, waiting for in-flight requests to complete.
+// If the context expires first, remaining connections are closed forcibly and the context's error is returned.
+// It is a no-op if the server isn't running.
+func (a *HTTPServer) Shutdown(ctx context.Context) error {
+	return a.shutdown(ctx, nil)
+}
+
+// shutdown stops the server if it's running. If server is non-nil, it's only stopped if it is still the running server.
+func (a *HTTPServer) shutdown(ctx context.Context, server *http.Server) error {
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+	if a.server == nil || (server != nil && a.server != server) {
+		return nil
+	}
+	log.Infof("Shutting down admin http")
+	a.cancel()
+	err := a.server.Shutdown(ctx)
+	if err != nil {
+		a.server.Close()
+	}
+	<-a.done
+	a.server = nil
+	a.listener = nil
+	return err
+}
+
+// Addr returns the address the server is listening on, or nil if it isn't running.
+func (a *HTTPServer) Addr() net.Addr {
+	a.lifecycle.Lock()
+	defer a.lifecycle.Unlock()
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Addr()
+}
+
+// wait blocks until the server stops serving.
+func (a *HTTPServer) wait() {
+	a.lifecycle.Lock()
+	done := a.done
+	a.lifecycle.Unlock()
+	if done != nil {
+		<-done
+	}
 }
 
 func getFunctionName(i interface{}) string {
@@ -364,13 +1089,31 @@ func getFunctionName(i interface{}) string {
 
 var once sync.Once
 
-// Serve starts the HTTPServer.
+// Serve starts the default HTTPServer and blocks until it is shut down.
 func Serve(opts Opts) {
-	DefaultAdminHTTPServer.addAdminRoutes(routes...)
-	DefaultAdminHTTPServer.startServer(opts)
+	if err := DefaultAdminHTTPServer.Start(context.Background(), opts); err != nil {
+		log.Errorf("Failed to serve admin HTTP: %s", err)
+		return
+	}
+	DefaultAdminHTTPServer.wait()
+}
+
+// Start starts the default HTTPServer in the background. See HTTPServer.Start.
+func Start(ctx context.Context, opts Opts) error {
+	return DefaultAdminHTTPServer.Start(ctx, opts)
+}
+
+// Shutdown gracefully stops the default HTTPServer. See HTTPServer.Shutdown.
+func Shutdown(ctx context.Context) error {
+	return DefaultAdminHTTPServer.Shutdown(ctx)
+}
+
+// Addr returns the address the default HTTPServer is listening on, or nil if it isn't running.
+func Addr() net.Addr {
+	return DefaultAdminHTTPServer.Addr()
 }
 
-// ServeOnce starts the HTTPServer, but only once.
+// ServeOnce starts the default HTTPServer, but only once. Like Serve, it blocks until the server is shut down.
 func ServeOnce(opts Opts) {
 	once.Do(func() {
 		Serve(opts)
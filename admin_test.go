@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func get(t *testing.T, handler http.Handler, path string, html bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if html {
+		req.Header.Set("Accept", "text/html")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func writeString(s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(s))
+	}
+}
+
+func TestPProfRoutes(t *testing.T) {
+	s := &HTTPServer{}
+	for _, path := range []string{
+		"/debug/pprof/",
+		"/debug/pprof/cmdline",
+		"/debug/pprof/profile?seconds=1",
+		"/debug/pprof/symbol",
+		"/debug/pprof/trace?seconds=0.1",
+		"/debug/pprof/heap",
+		"/debug/pprof/goroutine?debug=1",
+	} {
+		if w := get(t, s, path, false); w.Code != http.StatusOK {
+			t.Errorf("GET %s returned %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandleRejectsDuplicates(t *testing.T) {
+	s := &HTTPServer{}
+	err := s.Handle("/admin/ping", writeString("pong"), RouteOptions{})
+	if err == nil || err.Error() != "admin route GET /admin/ping is already registered" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Same path with a different method is fine.
+	if err := s.Handle("/admin/ping", writeString("pong"), RouteOptions{Method: http.MethodPost}); err != nil {
+		t.Fatal(err)
+	}
+	// A failed batch must not leave anything registered.
+	if err := s.addAdminRoutes(Route{path: "/admin/new", handler: writeString("new")}, Route{path: "/admin/gc", handler: writeString("gc")}); err == nil {
+		t.Fatal("expected duplicate error")
+	}
+	if w := get(t, s, "/admin/new", false); w.Code != http.StatusNotFound {
+		t.Errorf("partially applied batch: /admin/new returned %d", w.Code)
+	}
+}
+
+func TestHandleValidation(t *testing.T) {
+	s := &HTTPServer{}
+	for name, f := range map[string]func() error{
+		"nil handler": func() error { return s.Handle("/admin/x", nil, RouteOptions{}) },
+		"empty path":  func() error { return s.Handle("", writeString("x"), RouteOptions{}) },
+		"relative":    func() error { return s.Handle("admin/x", writeString("x"), RouteOptions{}) },
+		"indexed POST": func() error {
+			return s.Handle("/admin/x", writeString("x"), RouteOptions{Method: http.MethodPost, InIndex: true})
+		},
+	} {
+		if err := f(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHandleAfterServing(t *testing.T) {
+	s := &HTTPServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	if err := s.Handle("/admin/late", writeString("late"), RouteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(server.URL + "/admin/late")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "late" {
+		t.Fatalf("got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestHandleBeneathPrefix(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Handle(FilesPath+"custom.txt", writeString("custom"), RouteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Handle("/debug/pprof/custom/", writeString("prefix"), RouteOptions{Prefix: true}); err != nil {
+		t.Fatal(err)
+	}
+	if w := get(t, s, FilesPath+"custom.txt", false); w.Body.String() != "custom" {
+		t.Errorf("exact route shadowed by prefix: %d %q", w.Code, w.Body.String())
+	}
+	if w := get(t, s, "/debug/pprof/custom/thing", false); w.Body.String() != "prefix" {
+		t.Errorf("longer prefix shadowed by shorter one: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleShowsInNav(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Handle("/admin/custom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>custom page</p>"))
+	}), RouteOptions{Alias: "Custom Page", Group: "Team Tools", InIndex: true}); err != nil {
+		t.Fatal(err)
+	}
+	body := get(t, s, "/admin/custom", true).Body.String()
+	for _, want := range []string{"<p>custom page</p>", "Team Tools", `href="/admin/custom"`, "Custom Page"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+}
+
+func TestConcurrentHandle(t *testing.T) {
+	s := &HTTPServer{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.Handle(fmt.Sprintf("/admin/concurrent/%d", i), writeString("ok"), RouteOptions{Alias: fmt.Sprint(i), InIndex: true}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			get(t, s, "/admin", true)
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 20; i++ {
+		if w := get(t, s, fmt.Sprintf("/admin/concurrent/%d", i), false); w.Body.String() != "ok" {
+			t.Errorf("route %d: %d %q", i, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRenderedPagesAreSelfContained(t *testing.T) {
+	s := &HTTPServer{}
+	for _, path := range []string{"/admin", "/admin/logging", "/admin/metrics", "/admin/servers/"} {
+		w := get(t, s, path, true)
+		if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+			t.Errorf("%s: unexpected Content-Security-Policy %q", path, csp)
+		}
+		body := w.Body.String()
+		for _, external := range []string{`src="http`, `href="http`, `src="//`, `href="//`} {
+			if strings.Contains(body, external) {
+				t.Errorf("%s: page references an external asset", path)
+			}
+		}
+		for _, asset := range []string{"/admin/files/css/bootstrap.min.css", "/admin/files/js/jquery.min.js", "/admin/files/js/line-chart.js"} {
+			if !strings.Contains(body, asset) {
+				t.Errorf("%s: page doesn't include %s", path, asset)
+			}
+		}
+	}
+	for _, asset := range []string{
+		"/admin/files/css/bootstrap.min.css",
+		"/admin/files/css/font-awesome.min.css",
+		"/admin/files/fonts/fontawesome-webfont.woff2",
+		"/admin/files/js/jquery.min.js",
+		"/admin/files/js/bootstrap.bundle.min.js",
+		"/admin/files/js/line-chart.js",
+	} {
+		if w := get(t, s, asset, false); w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("%s: unexpected %d", asset, w.Code)
+		}
+	}
+}
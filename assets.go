@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minified third-party assets are served precompressed when the client accepts it.
+//go:generate sh -c "for f in css/*.min.css js/*.min.js; do gzip -9 -n -k -f $f && brotli -q 11 -k -f $f; done"
+
+//go:embed css/*.css css/*.gz css/*.br fonts/*.woff fonts/*.woff2 img/*.ico js/*.js js/*.gz js/*.br
+var embeddedAssets embed.FS
+
+// embeddedModTime is reported as the Last-Modified time of embedded assets, which don't have their own.
+var embeddedModTime = func() time.Time {
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Now()
+}()
+
+var assetDir = struct {
+	sync.RWMutex
+	dir string
+}{}
+
+// setAssetDir sets a directory whose files are served in preference to the embedded assets, or clears it if dir
+// is empty.
+func setAssetDir(dir string) {
+	assetDir.Lock()
+	defer assetDir.Unlock()
+	assetDir.dir = dir
+}
+
+// assetLayers returns the filesystems to look for assets in, in order of precedence.
+func assetLayers() []http.FileSystem {
+	assetDir.RLock()
+	defer assetDir.RUnlock()
+	if assetDir.dir != "" {
+		return []http.FileSystem{http.Dir(assetDir.dir), http.FS(embeddedAssets)}
+	}
+	return []http.FileSystem{http.FS(embeddedAssets)}
+}
+
+// encodings are the precompressed variants we look for, in order of preference, with their file extensions.
+var encodings = []struct{ name, extension string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type etagKey struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// etags caches the ETag of each asset, since they're hashed from the contents.
+var etags sync.Map
+
+// etag returns a strong ETag for the given file, which is left positioned at its start.
+func etag(name string, f http.File, info os.FileInfo) (string, error) {
+	key := etagKey{name: name, modTime: info.ModTime(), size: info.Size()}
+	if tag, present := etags.Load(key); present {
+		return tag.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	tag := `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]) + `"`
+	etags.Store(key, tag)
+	return tag, nil
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, h := range r.Header["Accept-Encoding"] {
+		for _, e := range strings.Split(h, ",") {
+			if e = strings.TrimSpace(strings.SplitN(e, ";", 2)[0]); e == encoding || e == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serveAsset serves the named asset from the first layer containing it, preferring a precompressed
+// variant from the same layer if the client accepts one. It returns fs.ErrNotExist if there's no such asset.
+func serveAsset(w http.ResponseWriter, r *http.Request, name string) error {
+	for _, layer := range assetLayers() {
+		f, err := layer.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		} else if info.IsDir() {
+			return fs.ErrNotExist
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		for _, encoding := range encodings {
+			if !acceptsEncoding(r, encoding.name) {
+				continue
+			}
+			if cf, err := layer.Open(name + encoding.extension); err == nil {
+				defer cf.Close()
+				if cinfo, err := cf.Stat(); err == nil && !cinfo.IsDir() {
+					f, info = cf, cinfo
+					w.Header().Set("Content-Encoding", encoding.name)
+					break
+				}
+			}
+		}
+
+		tag, err := etag(name+w.Header().Get("Content-Encoding"), f, info)
+		if err != nil {
+			return err
+		}
+		modTime := info.ModTime()
+		if modTime.IsZero() {
+			modTime = embeddedModTime
+		}
+		w.Header().Set("ETag", tag)
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeContent(w, r, name, modTime, f)
+		return nil
+	}
+	return fs.ErrNotExist
+}
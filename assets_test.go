@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func getAsset(s http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestAssetCaching(t *testing.T) {
+	s := &HTTPServer{}
+	w := getAsset(s, "/admin/files/js/index.js", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/javascript" {
+		t.Errorf("unexpected Content-Type %s", ct)
+	}
+	if w := getAsset(s, "/admin/files/js/index.js", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: expected 304, got %d", w.Code)
+	}
+	if w := getAsset(s, "/favicon.ico", nil); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/x-icon" {
+		t.Errorf("favicon: unexpected %d %v", w.Code, w.Header())
+	}
+	for _, path := range []string{"/admin/files/js/missing.js", "/admin/files/js"} {
+		if w := getAsset(s, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if err := serveAsset(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "../admin.go"); err == nil {
+		t.Error("assets outside the asset directories should not be served")
+	}
+}
+
+func TestPrecompressedAssets(t *testing.T) {
+	s := &HTTPServer{}
+	plain := getAsset(s, "/admin/files/js/jquery.min.js", nil)
+	for encoding, accept := range map[string]string{"br": "gzip, deflate, br", "gzip": "gzip;q=1.0"} {
+		w := getAsset(s, "/admin/files/js/jquery.min.js", map[string]string{"Accept-Encoding": accept})
+		if w.Header().Get("Content-Encoding") != encoding || w.Body.Len() >= plain.Body.Len() {
+			t.Errorf("%s: unexpected response %v, %d bytes", encoding, w.Header(), w.Body.Len())
+		} else if w.Header().Get("ETag") == plain.Header().Get("ETag") {
+			t.Errorf("%s: variants should have different ETags", encoding)
+		}
+	}
+	if w := getAsset(s, "/admin/files/js/index.js", map[string]string{"Accept-Encoding": "gzip"}); w.Header().Get("Content-Encoding") != "" {
+		t.Error("index.js has no precompressed variant")
+	}
+}
+
+// TestPrecompressedAssetsUpToDate checks the precompressed variants were regenerated (via go generate) when their sources changed.
+func TestPrecompressedAssetsUpToDate(t *testing.T) {
+	files, _ := filepath.Glob("*/*.gz")
+	if len(files) == 0 {
+		t.Fatal("no precompressed assets found")
+	}
+	for _, gz := range files {
+		f, _ := os.Open(gz)
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%s: %s", gz, err)
+		}
+		decompressed, _ := ioutil.ReadAll(r)
+		original, _ := ioutil.ReadFile(gz[:len(gz)-len(".gz")])
+		if !bytes.Equal(decompressed, original) {
+			t.Errorf("%s is out of date; run go generate", gz)
+		}
+		if _, err := os.Stat(gz[:len(gz)-len(".gz")] + ".br"); err != nil {
+			t.Errorf("%s has no brotli variant", gz)
+		}
+	}
+}
+
+func TestAssetDir(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "assets")
+	defer os.RemoveAll(dir)
+	defer setAssetDir("")
+	os.Mkdir(filepath.Join(dir, "js"), 0755)
+	writeFile(t, dir, "js/jquery.min.js", []byte("overridden"))
+	writeFile(t, dir, "js/branding.js", []byte("branding"))
+	setAssetDir(dir)
+
+	s := &HTTPServer{}
+	w := getAsset(s, "/admin/files/js/jquery.min.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	if w.Body.String() != "overridden" || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("overlay should take precedence over the embedded asset & its variants: %v %q", w.Header(), w.Body.String())
+	}
+	if w := getAsset(s, "/admin/files/js/branding.js", nil); w.Body.String() != "branding" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+	if w := getAsset(s, "/admin/files/js/index.js", nil); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("embedded assets not in the overlay should still be served: %d", w.Code)
+	}
+	etag := getAsset(s, "/admin/files/js/branding.js", nil).Header().Get("ETag")
+	writeFile(t, dir, "js/branding.js", []byte("rebranded"))
+	if w := getAsset(s, "/admin/files/js/branding.js", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK || w.Body.String() != "rebranded" {
+		t.Errorf("changed overlay file should be served afresh: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStartResetsAssetDir(t *testing.T) {
+	defer setAssetDir("")
+	s := &HTTPServer{}
+	opts := localOpts
+	opts.AssetDir = t.TempDir()
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown(context.Background())
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if layers := assetLayers(); len(layers) != 1 {
+		t.Errorf("asset dir not reset on restart: %v", layers)
+	}
+}
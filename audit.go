@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultAuditLogSize is the number of audit events retained in memory when Opts.AuditLogSize isn't set.
+const defaultAuditLogSize = 1000
+
+// An AuditEvent records a single mutating action taken through the admin server.
+type AuditEvent struct {
+	Time       time.Time           `json:"time"`
+	Actor      string              `json:"actor"`
+	RemoteAddr string              `json:"remote_addr"`
+	Method     string              `json:"method"`
+	Route      string              `json:"route"`
+	Params     map[string][]string `json:"params,omitempty"`
+	Status     int                 `json:"status"`
+	Outcome    string              `json:"outcome"`
+	Detail     string              `json:"detail,omitempty"`
+}
+
+// An AuditSink receives audit events as they're recorded, for example to export them to a log pipeline.
+// Record is called synchronously while serving the request, so it should not block for long.
+type AuditSink interface {
+	Record(event AuditEvent)
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface.
+type AuditSinkFunc func(event AuditEvent)
+
+// Record implements the AuditSink interface.
+func (f AuditSinkFunc) Record(event AuditEvent) {
+	f(event)
+}
+
+// An auditLog is a fixed size ring buffer of audit events, which also forwards them to its sinks.
+type auditLog struct {
+	mutex  sync.Mutex
+	events []AuditEvent
+	next   int
+	full   bool
+	sinks  []AuditSink
+}
+
+func newAuditLog(size int, sinks []AuditSink) *auditLog {
+	if size <= 0 {
+		size = defaultAuditLogSize
+	}
+	return &auditLog{events: make([]AuditEvent, size), sinks: sinks}
+}
+
+func (l *auditLog) record(event AuditEvent) {
+	l.mutex.Lock()
+	l.events[l.next] = event
+	l.next = (l.next + 1) % len(l.events)
+	l.full = l.full || l.next == 0
+	sinks := l.sinks
+	l.mutex.Unlock()
+
+	log.Infof("Audit: %s %s %s by %s from %s: %s %s", event.Method, event.Route, event.Outcome, event.Actor, event.RemoteAddr, event.Detail, event.Params)
+	for _, sink := range sinks {
+		sink.Record(event)
+	}
+}
+
+// Events returns the retained events, most recent first.
+func (l *auditLog) Events() []AuditEvent {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.events)
+	}
+	ret := make([]AuditEvent, 0, n)
+	for i := 1; i <= n; i++ {
+		ret = append(ret, l.events[(l.next-i+len(l.events))%len(l.events)])
+	}
+	return ret
+}
+
+type auditKey struct{}
+
+// AnnotateAudit attaches a description of what a mutating request did to its audit event,
+// e.g. "GOGC changed from 100 to 200". It is a no-op for requests that aren't audited.
+func AnnotateAudit(r *http.Request, detail string) {
+	if event, ok := r.Context().Value(auditKey{}).(*AuditEvent); ok {
+		event.Detail = detail
+	}
+}
+
+type afterAuditKey struct{}
+
+// afterAudit arranges for fn to be called once the request's audit event has been recorded and its response
+// flushed, e.g. so that a handler can exit the process without losing either. fn is called immediately for
+// requests that aren't audited.
+func afterAudit(r *http.Request, fn func()) {
+	if after, ok := r.Context().Value(afterAuditKey{}).(*[]func()); ok {
+		*after = append(*after, fn)
+	} else {
+		fn()
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, if the underlying ResponseWriter does.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// audit wraps a handler so that every non-GET request to it is recorded in the server's audit log.
+func (a *HTTPServer) audit(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		event := &AuditEvent{
+			Time:       time.Now(),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Route:      route,
+		}
+		if p := PrincipalFromContext(r.Context()); p != nil {
+			event.Actor = p.Name
+		}
+		if err := r.ParseForm(); err == nil && len(r.Form) > 0 {
+			event.Params = make(map[string][]string, len(r.Form))
+			for k, v := range r.Form {
+				if k != csrfField {
+					event.Params[k] = v
+				}
+			}
+		}
+		recorder := &statusRecorder{ResponseWriter: w}
+		var after []func()
+		ctx := context.WithValue(context.WithValue(r.Context(), auditKey{}, event), afterAuditKey{}, &after)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		event.Status = recorder.status
+		if event.Status == 0 {
+			event.Status = http.StatusOK
+		}
+		if event.Status < http.StatusBadRequest {
+			event.Outcome = "success"
+		} else {
+			event.Outcome = "failure"
+		}
+		a.auditLog().record(*event)
+		if len(after) > 0 {
+			recorder.Flush()
+			for _, fn := range after {
+				fn()
+			}
+		}
+	})
+}
+
+func (a *HTTPServer) auditLog() *auditLog {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.audits == nil {
+		a.audits = newAuditLog(defaultAuditLogSize, nil)
+	}
+	return a.audits
+}
+
+var auditTemplate = template.Must(template.New("audit").Parse(`
+<table class="table table-sm">
+	<thead>
+		<tr><th>time</th><th>actor</th><th>remote address</th><th>action</th><th>parameters</th><th>outcome</th></tr>
+	</thead>
+	<tbody>
+{{range .}}
+		<tr class="{{if (eq .Outcome "success")}}{{else}}table-danger{{end}}">
+			<td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td>
+			<td>{{if .Actor}}{{.Actor}}{{else}}<em>anonymous</em>{{end}}</td>
+			<td>{{.RemoteAddr}}</td>
+			<td>{{.Method}} {{.Route}}</td>
+			<td>{{range $k, $v := .Params}}{{$k}}={{range $v}}{{.}} {{end}}<br/>{{end}}</td>
+			<td>{{.Outcome}} ({{.Status}}){{if .Detail}}: {{.Detail}}{{end}}</td>
+		</tr>
+{{else}}
+		<tr><td colspan="6">No actions have been recorded.</td></tr>
+{{end}}
+	</tbody>
+</table>
+`))
+
+// auditHandler renders the audit log.
+func (a *HTTPServer) auditHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := auditTemplate.Execute(w, a.auditLog().Events()); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// auditJSONHandler returns the audit log as JSON, most recent first.
+func (a *HTTPServer) auditJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.auditLog().Events())
+	w.Write(b)
+}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gopkg.in/op/go-logging.v1"
+)
+
+func post(s http.Handler, path string, form url.Values, cookie, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: cookie})
+	}
+	if header != "" {
+		req.Header.Set(csrfHeader, header)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestCSRF(t *testing.T) {
+	s := &HTTPServer{}
+	w := get(t, s, "/admin/gc", true)
+	var token string
+	for _, c := range w.Result().Cookies() {
+		if c.Name == csrfCookie {
+			token = c.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("no CSRF cookie set")
+	} else if !strings.Contains(w.Body.String(), `value="`+token+`"`) {
+		t.Fatal("GC form doesn't contain the CSRF token")
+	}
+
+	for name, tc := range map[string]struct {
+		form           url.Values
+		cookie, header string
+		code           int
+	}{
+		"no token":        {nil, "", "", http.StatusForbidden},
+		"no cookie":       {url.Values{csrfField: {token}}, "", "", http.StatusForbidden},
+		"mismatched":      {url.Values{csrfField: {"forged"}}, token, "", http.StatusForbidden},
+		"form field":      {url.Values{csrfField: {token}}, token, "", http.StatusOK},
+		"header":          {nil, token, token, http.StatusOK},
+		"empty on both":   {url.Values{csrfField: {""}}, "", "", http.StatusForbidden},
+		"header mismatch": {nil, token, "forged", http.StatusForbidden},
+	} {
+		if w := post(s, "/admin/gc", tc.form, tc.cookie, tc.header); w.Code != tc.code {
+			t.Errorf("%s: got %d, want %d", name, w.Code, tc.code)
+		}
+	}
+
+	// Bearer token clients are exempt.
+	req := httptest.NewRequest(http.MethodPost, "/admin/gc", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("bearer request: got %d", w.Code)
+	}
+}
+
+func TestLoggingFormsIncludeCSRFToken(t *testing.T) {
+	loggerInfo = fakeLoggerInfo{}
+	defer func() { loggerInfo = nil }()
+	s := &HTTPServer{}
+	req := httptest.NewRequest(http.MethodGet, "/admin/logging", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookie, Value: "the-token"})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if n := strings.Count(w.Body.String(), `name="csrf_token" value="the-token"`); n != 8 {
+		t.Errorf("expected a token in each of 8 forms, found %d", n)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	var sunk []AuditEvent
+	s := authedServer(StaticTokenAuthenticator{"op": {Name: "bob", Roles: []Role{RoleOperator}}})
+	s.audits = newAuditLog(2, []AuditSink{AuditSinkFunc(func(e AuditEvent) { sunk = append(sunk, e) })})
+
+	for _, tok := range []string{"op", "op", ""} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/gc", strings.NewReader("reason=testing&csrf_token=x"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: csrfCookie, Value: "x"})
+		req.SetBasicAuth("ignored", "ignored")
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// The unauthenticated request never reaches the audit log.
+	if len(sunk) != 2 {
+		t.Fatalf("sink received %d events", len(sunk))
+	}
+	e := sunk[0]
+	if e.Actor != "bob" || e.Route != "/admin/gc" || e.Outcome != "success" || e.Status != http.StatusOK ||
+		len(e.Params["reason"]) != 1 || e.Params[csrfField] != nil {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	// A request rejected for a bad CSRF token is recorded as a failure.
+	req := httptest.NewRequest(http.MethodPost, "/admin/gc", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookie, Value: "x"})
+	req.SetBasicAuth("ignored", "ignored")
+	s.authenticator = nil
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit.json", nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	var events []AuditEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("ring buffer of size 2 returned %d events", len(events))
+	}
+	if events[0].Outcome != "failure" || events[0].Status != http.StatusForbidden || !strings.Contains(events[0].Detail, "CSRF") {
+		t.Errorf("most recent event should be the CSRF failure: %+v", events[0])
+	}
+	if !strings.Contains(get(t, s, "/admin/audit", true).Body.String(), "bob") {
+		t.Error("audit page doesn't show the actor")
+	}
+}
+
+type fakeLoggerInfo struct{}
+
+func (fakeLoggerInfo) ModuleLevels() map[string]logging.Level {
+	return map[string]logging.Level{"": logging.INFO}
+}
+
+func (fakeLoggerInfo) SetLevel(level logging.Level, module string) {}
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A Role is required by a route in order to access it.
+type Role string
+
+// Built-in roles.
+const (
+	// RoleAnonymous routes can be accessed without authenticating.
+	RoleAnonymous Role = "anonymous"
+	// RoleViewer routes display information about the process.
+	RoleViewer Role = "viewer"
+	// RoleOperator routes change the state of the process or expose sensitive data, such as profiles.
+	// Operators can also access all viewer routes.
+	RoleOperator Role = "operator"
+)
+
+// impliedRoles is the set of roles that are implicitly granted by another.
+var impliedRoles = map[Role][]Role{
+	RoleOperator: {RoleViewer},
+}
+
+// A Principal is an authenticated caller of the admin server.
+type Principal struct {
+	Name  string
+	Roles []Role
+}
+
+// HasRole returns true if the principal has been granted the given role, either directly or implicitly.
+func (p *Principal) HasRole(role Role) bool {
+	if role == RoleAnonymous {
+		return true
+	} else if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+		for _, implied := range impliedRoles[r] {
+			if implied == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// An Authenticator identifies the caller of an admin request.
+type Authenticator interface {
+	// Authenticate returns the principal making the request.
+	// It returns nil if the request carries no credentials that this authenticator understands,
+	// or an error if it does but they are invalid.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// A challenger is an Authenticator that can tell clients how to authenticate, via a WWW-Authenticate header.
+type challenger interface {
+	challenge() string
+}
+
+// Authenticators tries each of its authenticators in turn, returning the first principal found.
+type Authenticators []Authenticator
+
+// Authenticate implements the Authenticator interface.
+func (as Authenticators) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range as {
+		if p, err := a.Authenticate(r); err != nil || p != nil {
+			return p, err
+		}
+	}
+	return nil, nil
+}
+
+func (as Authenticators) challenges() []string {
+	var ret []string
+	for _, a := range as {
+		if c, ok := a.(challenger); ok {
+			ret = append(ret, c.challenge())
+		} else if nested, ok := a.(Authenticators); ok {
+			ret = append(ret, nested.challenges()...)
+		}
+	}
+	return ret
+}
+
+// StaticTokenAuthenticator authenticates requests bearing an "Authorization: Bearer <token>" header.
+// It maps each token to the principal it identifies.
+type StaticTokenAuthenticator map[string]Principal
+
+// Authenticate implements the Authenticator interface.
+func (s StaticTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+	token := []byte(strings.TrimPrefix(header, "Bearer "))
+	var found *Principal
+	// Compare against every token so the time taken doesn't reveal which one matched.
+	for t, p := range s {
+		if subtle.ConstantTimeCompare([]byte(t), token) == 1 {
+			p := p
+			found = &p
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown bearer token")
+	}
+	return found, nil
+}
+
+func (s StaticTokenAuthenticator) challenge() string {
+	return `Bearer realm="admin"`
+}
+
+// BasicCredentials are the credentials for a single user of a BasicAuthenticator.
+type BasicCredentials struct {
+	// PasswordHash is the bcrypt hash of the user's password.
+	PasswordHash string
+	Roles        []Role
+}
+
+// BasicAuthenticator authenticates requests using HTTP basic authentication.
+// It maps each username to their credentials.
+type BasicAuthenticator map[string]BasicCredentials
+
+// Authenticate implements the Authenticator interface.
+func (b BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	creds, present := b[user]
+	if !present {
+		return nil, fmt.Errorf("unknown user %s", user)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(creds.PasswordHash), []byte(password)); err != nil {
+		return nil, fmt.Errorf("incorrect password for %s", user)
+	}
+	return &Principal{Name: user, Roles: creds.Roles}, nil
+}
+
+func (b BasicAuthenticator) challenge() string {
+	return `Basic realm="admin"`
+}
+
+// ClientCertAuthenticator authenticates requests by the common name of their verified TLS client certificate.
+// It maps each common name to the roles it's granted. It is only useful with Opts.TLSClientCA set.
+type ClientCertAuthenticator map[string][]Role
+
+// Authenticate implements the Authenticator interface.
+func (c ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	roles, present := c[cn]
+	if !present {
+		return nil, fmt.Errorf("unknown client certificate %s", cn)
+	}
+	return &Principal{Name: cn, Roles: roles}, nil
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal that made the request with the given context.
+// It returns nil if the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// authorize wraps a handler so that only callers with the given role can access it.
+// If the server has no authenticator, everything is accessible.
+func (a *HTTPServer) authorize(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticator := a.getAuthenticator()
+		if authenticator == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := authenticator.Authenticate(r)
+		if err != nil {
+			log.Warningf("Failed to authenticate admin request from %s: %s", r.RemoteAddr, err)
+			if role == RoleAnonymous {
+				p, err = nil, nil
+			}
+		}
+		if err != nil || (p == nil && role != RoleAnonymous) {
+			for _, c := range (Authenticators{authenticator}).challenges() {
+				w.Header().Add("WWW-Authenticate", c)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		} else if !p.HasRole(role) {
+			log.Warningf("Denied %s access to %s, which requires role %s", p.Name, r.URL.Path, role)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+func (a *HTTPServer) getAuthenticator() Authenticator {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.authenticator
+}
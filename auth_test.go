@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func authedServer(authenticator Authenticator) *HTTPServer {
+	s := &HTTPServer{}
+	s.init()
+	s.authenticator = authenticator
+	return s
+}
+
+func getWithToken(s http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept", "text/html")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoles(t *testing.T) {
+	s := authedServer(StaticTokenAuthenticator{
+		"viewer-token":   {Name: "alice", Roles: []Role{RoleViewer}},
+		"operator-token": {Name: "bob", Roles: []Role{RoleOperator}},
+	})
+	for _, tc := range []struct {
+		path, token string
+		code        int
+	}{
+		{"/admin/ping", "", http.StatusOK},
+		{"/admin/ping", "wrong-token", http.StatusOK},
+		{"/admin", "", http.StatusUnauthorized},
+		{"/admin", "wrong-token", http.StatusUnauthorized},
+		{"/admin", "viewer-token", http.StatusOK},
+		{"/admin", "operator-token", http.StatusOK},
+		{"/admin/gc", "viewer-token", http.StatusForbidden},
+		{"/admin/gc", "operator-token", http.StatusOK},
+		{"/debug/pprof/cmdline", "viewer-token", http.StatusForbidden},
+		{"/debug/vars", "viewer-token", http.StatusForbidden},
+		{"/debug/vars", "operator-token", http.StatusOK},
+		{"/admin/goroutines", "viewer-token", http.StatusForbidden},
+		{"/admin/goroutines.json", "viewer-token", http.StatusForbidden},
+		{"/admin/goroutines", "operator-token", http.StatusOK},
+		{"/admin/profiles", "viewer-token", http.StatusOK},
+		{"/admin/profiles/1", "viewer-token", http.StatusForbidden},
+		{"/admin/profiles/diff?base=1&target=2", "viewer-token", http.StatusForbidden},
+		{"/admin/profiling/1", "viewer-token", http.StatusForbidden},
+		{"/admin/profiles/view", "viewer-token", http.StatusForbidden},
+		{"/admin/profiles/view.json", "viewer-token", http.StatusForbidden},
+		{"/admin/traces", "viewer-token", http.StatusOK},
+		{"/admin/traces/1/download", "viewer-token", http.StatusForbidden},
+		{"/admin/flightrecorder", "viewer-token", http.StatusForbidden},
+		{"/admin/flightrecorder.json", "viewer-token", http.StatusForbidden},
+		{"/debug/pprof/heap", "viewer-token", http.StatusForbidden},
+		{"/debug/pprof/heap", "operator-token", http.StatusOK},
+	} {
+		if w := getWithToken(s, tc.path, tc.token); w.Code != tc.code {
+			t.Errorf("GET %s with %q: got %d, want %d", tc.path, tc.token, w.Code, tc.code)
+		}
+	}
+	if w := getWithToken(s, "/admin", ""); w.Header().Get("WWW-Authenticate") != `Bearer realm="admin"` {
+		t.Errorf("unexpected challenge %q", w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestNavHidesInaccessibleRoutes(t *testing.T) {
+	s := authedServer(StaticTokenAuthenticator{
+		"viewer-token":   {Name: "alice", Roles: []Role{RoleViewer}},
+		"operator-token": {Name: "bob", Roles: []Role{RoleOperator}},
+	})
+	viewer := getWithToken(s, "/admin", "viewer-token").Body.String()
+	operator := getWithToken(s, "/admin", "operator-token").Body.String()
+	for _, path := range []string{`href="/admin/gc"`, `href="/debug/pprof/profile"`} {
+		if strings.Contains(viewer, path) {
+			t.Errorf("viewer can see %s", path)
+		}
+		if !strings.Contains(operator, path) {
+			t.Errorf("operator can't see %s", path)
+		}
+	}
+	if !strings.Contains(viewer, `href="/admin/metrics"`) {
+		t.Error("viewer can't see metrics")
+	}
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	s := authedServer(Authenticators{
+		StaticTokenAuthenticator{},
+		BasicAuthenticator{"carol": {PasswordHash: string(hash), Roles: []Role{RoleOperator}}},
+	})
+	for password, code := range map[string]int{"hunter2": http.StatusOK, "hunter3": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/gc", nil)
+		req.SetBasicAuth("carol", password)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("password %s: got %d, want %d", password, w.Code, code)
+		}
+	}
+	w := getWithToken(s, "/admin", "")
+	if challenges := w.Header()["Www-Authenticate"]; len(challenges) != 2 {
+		t.Errorf("unexpected challenges %q", challenges)
+	}
+}
+
+func TestClientCertAuthenticator(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "admin-tls")
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", ca)
+	viewer := newTestCert(t, "dashboard", ca)
+	unknown := newTestCert(t, "someone-else", ca)
+
+	opts := localOpts
+	opts.TLSCert = writeFile(t, dir, "server.crt", server.certPEM)
+	opts.TLSKey = writeFile(t, dir, "server.key", server.keyPEM)
+	opts.TLSClientCA = writeFile(t, dir, "ca.crt", ca.certPEM)
+	opts.Authenticator = ClientCertAuthenticator{"dashboard": {RoleViewer}}
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	url := "https://" + s.Addr().String()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	for _, tc := range []struct {
+		cert *testCert
+		path string
+		code int
+	}{
+		{viewer, "/admin/metrics", http.StatusOK},
+		{viewer, "/admin/gc", http.StatusForbidden},
+		{unknown, "/admin/metrics", http.StatusUnauthorized},
+	} {
+		resp, err := tlsClient(roots, tc.cert.tlsCertificate(t)).Get(url + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.code {
+			t.Errorf("%s GET %s: got %d, want %d", tc.cert.cert.Subject.CommonName, tc.path, resp.StatusCode, tc.code)
+		}
+	}
+}
@@ -1,531 +0,0 @@
-// Code generated by go-bindata. DO NOT EDIT.
-// sources:
-// css/client-registry.css
-// css/index.css
-// css/metric-query.css
-// css/server-registry.css
-// css/summary.css
-// img/favicon.ico
-// js/chart-renderer.js
-// js/index.js
-// js/metric-query.js
-// js/server-registry.js
-// js/summary.js
-// js/utils.js
-
-package admin
-
-import (
-	"crypto/sha256"
-	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"reflect"
-	"strings"
-	"time"
-	"unsafe"
-)
-
-func bindataRead(data, name string) ([]byte, error) {
-	var empty [0]byte
-	sx := (*reflect.StringHeader)(unsafe.Pointer(&data))
-	b := empty[:]
-	bx := (*reflect.SliceHeader)(unsafe.Pointer(&b))
-	bx.Data = sx.Data
-	bx.Len = len(data)
-	bx.Cap = bx.Len
-	return b, nil
-}
-
-type asset struct {
-	bytes  []byte
-	info   os.FileInfo
-	digest [sha256.Size]byte
-}
-
-type bindataFileInfo struct {
-	name    string
-	size    int64
-	mode    os.FileMode
-	modTime time.Time
-}
-
-func (fi bindataFileInfo) Name() string {
-	return fi.name
-}
-func (fi bindataFileInfo) Size() int64 {
-	return fi.size
-}
-func (fi bindataFileInfo) Mode() os.FileMode {
-	return fi.mode
-}
-func (fi bindataFileInfo) ModTime() time.Time {
-	return fi.modTime
-}
-func (fi bindataFileInfo) IsDir() bool {
-	return false
-}
-func (fi bindataFileInfo) Sys() interface{} {
-	return nil
-}
-
-var _cssClientRegistryCss = "\x2e\x63\x6c\x69\x65\x6e\x74\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x35\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x72\x61\x64\x69\x75\x73\x3a\x20\x34\x70\x78\x3b\x0a\x20\x20\x74\x65\x78\x74\x2d\x61\x6c\x69\x67\x6e\x3a\x20\x63\x65\x6e\x74\x65\x72\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x6c\x69\x67\x68\x74\x67\x72\x65\x79\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x3b\x0a\x7d\x0a\x0a\x2e\x63\x6c\x69\x65\x6e\x74\x20\x2e\x6e\x61\x6d\x65\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x36\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x72\x69\x67\x68\x74\x3a\x20\x36\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x63\x6c\x69\x65\x6e\x74\x20\x2e\x64\x65\x73\x74\x20\x7b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x73\x69\x7a\x65\x3a\x20\x39\x70\x74\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x30\x20\x36\x70\x78\x20\x36\x70\x78\x20\x36\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x3a\x20\x30\x3b\x0a\x20\x20\x6d\x69\x6e\x2d\x68\x65\x69\x67\x68\x74\x3a\x20\x34\x35\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x63\x6c\x69\x65\x6e\x74\x20\x68\x72\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x3a\x20\x30\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x30\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x74\x6f\x70\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x68\x65\x61\x64\x65\x72\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x39\x39\x39\x3b\x0a\x20\x20\x74\x65\x78\x74\x2d\x74\x72\x61\x6e\x73\x66\x6f\x72\x6d\x3a\x20\x75\x70\x70\x65\x72\x63\x61\x73\x65\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x74\x6f\x70\x3a\x20\x31\x30\x70\x78\x3b\x0a\x20\x20\x74\x65\x78\x74\x2d\x61\x6c\x69\x67\x6e\x3a\x20\x6c\x65\x66\x74\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x31\x35\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x74\x65\x78\x74\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x67\x72\x61\x79\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x77\x65\x69\x67\x68\x74\x3a\x20\x62\x6f\x6c\x64\x3b\x0a\x20\x20\x74\x65\x78\x74\x2d\x61\x6c\x69\x67\x6e\x3a\x20\x72\x69\x67\x68\x74\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x3a\x20\x30\x20\x30\x20\x30\x20\x2d\x32\x35\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x74\x6f\x70\x3a\x20\x31\x32\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x31\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x67\x6f\x6f\x64\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x36\x62\x62\x66\x35\x30\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x70\x6f\x6f\x72\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x66\x32\x38\x65\x31\x64\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x62\x61\x64\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x63\x61\x33\x35\x32\x38\x3b\x0a\x7d\x0a\x0a\x2e\x73\x72\x2d\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x37\x37\x37\x37\x37\x37\x3b\x0a\x7d\x0a\x0a\x2e\x63\x6f\x6e\x6e\x2d\x74\x72\x6f\x75\x62\x6c\x65\x20\x7b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x62\x73\x6f\x6c\x75\x74\x65\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x2d\x39\x30\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x74\x6f\x70\x3a\x20\x2d\x31\x31\x70\x78\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x38\x35\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x23\x63\x63\x63\x3b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x37\x37\x37\x37\x37\x37\x3b\x0a\x7d\x0a\x0a\x2e\x63\x6f\x6e\x6e\x2d\x74\x72\x6f\x75\x62\x6c\x65\x20\x73\x70\x61\x6e\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x66\x30\x61\x64\x34\x65\x3b\x0a\x7d\x0a\x0a\x2e\x64\x74\x61\x62\x20\x7b\x0a\x20\x20\x6c\x69\x73\x74\x2d\x73\x74\x79\x6c\x65\x2d\x74\x79\x70\x65\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a"
-
-func cssClientRegistryCssBytes() ([]byte, error) {
-	return bindataRead(
-		_cssClientRegistryCss,
-		"css/client-registry.css",
-	)
-}
-
-func cssClientRegistryCss() (*asset, error) {
-	bytes, err := cssClientRegistryCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "css/client-registry.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc8, 0x29, 0xad, 0xd8, 0x78, 0xb6, 0xc, 0x15, 0x1d, 0x63, 0xdd, 0x4f, 0xef, 0x1, 0x6e, 0x74, 0x44, 0x42, 0x2d, 0xc9, 0x78, 0x9d, 0x6b, 0x54, 0xc6, 0x37, 0x2c, 0xe9, 0xc3, 0xc0, 0xa2, 0xc7}}
-	return a, nil
-}
-
-var _cssIndexCss = "\x68\x74\x6d\x6c\x2c\x0a\x62\x6f\x64\x79\x20\x7b\x0a\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x31\x30\x30\x25\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x30\x30\x25\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x30\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x68\x31\x2c\x0a\x68\x32\x2c\x0a\x68\x33\x2c\x0a\x68\x34\x2c\x0a\x68\x35\x2c\x0a\x68\x36\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x36\x36\x36\x3b\x0a\x7d\x0a\x0a\x2f\x2a\x2a\x20\x4e\x61\x76\x69\x67\x61\x74\x69\x6f\x6e\x20\x2a\x2f\x0a\x23\x77\x72\x61\x70\x70\x65\x72\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6d\x6f\x7a\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6f\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x7d\x0a\x0a\x23\x77\x72\x61\x70\x70\x65\x72\x2e\x74\x6f\x67\x67\x6c\x65\x64\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x2d\x32\x35\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x23\x73\x69\x64\x65\x62\x61\x72\x20\x7b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x66\x69\x78\x65\x64\x3b\x0a\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x31\x30\x30\x25\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x32\x35\x30\x70\x78\x3b\x0a\x20\x20\x6f\x76\x65\x72\x66\x6c\x6f\x77\x2d\x79\x3a\x20\x61\x75\x74\x6f\x3b\x0a\x20\x20\x6f\x76\x65\x72\x66\x6c\x6f\x77\x2d\x78\x3a\x20\x61\x75\x74\x6f\x3b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x34\x32\x38\x62\x63\x61\x3b\x0a\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6d\x6f\x7a\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6f\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x7a\x2d\x69\x6e\x64\x65\x78\x3a\x20\x31\x30\x3b\x0a\x7d\x0a\x0a\x23\x63\x6f\x6e\x74\x65\x6e\x74\x73\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x32\x37\x30\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x74\x6f\x70\x3a\x20\x31\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x23\x74\x6f\x67\x67\x6c\x65\x20\x7b\x0a\x20\x20\x7a\x2d\x69\x6e\x64\x65\x78\x3a\x20\x31\x30\x30\x30\x3b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x66\x69\x78\x65\x64\x3b\x0a\x20\x20\x6c\x65\x66\x74\x3a\x20\x32\x35\x30\x70\x78\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x32\x70\x78\x3b\x0a\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x31\x30\x30\x25\x3b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x34\x32\x38\x62\x63\x61\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x6c\x65\x66\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x72\x67\x62\x61\x28\x30\x2c\x20\x30\x2c\x20\x30\x2c\x20\x30\x2e\x31\x29\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x72\x69\x67\x68\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x72\x67\x62\x61\x28\x30\x2c\x20\x30\x2c\x20\x30\x2c\x20\x30\x2e\x31\x29\x3b\x0a\x20\x20\x62\x6f\x78\x2d\x73\x68\x61\x64\x6f\x77\x3a\x20\x30\x20\x31\x30\x70\x78\x20\x30\x20\x72\x65\x64\x3b\x0a\x20\x20\x63\x75\x72\x73\x6f\x72\x3a\x20\x70\x6f\x69\x6e\x74\x65\x72\x3b\x0a\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6d\x6f\x7a\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x2d\x6f\x2d\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x20\x20\x74\x72\x61\x6e\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x6c\x6c\x20\x30\x2e\x35\x73\x20\x65\x61\x73\x65\x3b\x0a\x7d\x0a\x0a\x23\x74\x6f\x67\x67\x6c\x65\x3a\x68\x6f\x76\x65\x72\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x32\x39\x37\x32\x62\x31\x3b\x0a\x7d\x0a\x0a\x23\x77\x72\x61\x70\x70\x65\x72\x2e\x74\x6f\x67\x67\x6c\x65\x64\x20\x23\x74\x6f\x67\x67\x6c\x65\x20\x7b\x0a\x20\x20\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x23\x74\x6f\x67\x67\x6c\x65\x20\x73\x70\x61\x6e\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x77\x68\x69\x74\x65\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x73\x69\x7a\x65\x3a\x20\x38\x70\x74\x3b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x72\x65\x6c\x61\x74\x69\x76\x65\x3b\x0a\x20\x20\x74\x6f\x70\x3a\x20\x35\x30\x25\x3b\x0a\x7d\x0a\x0a\x2f\x2a\x2a\x20\x4e\x61\x76\x20\x73\x74\x79\x6c\x65\x20\x2a\x2a\x2f\x0a\x6e\x61\x76\x20\x61\x3a\x68\x6f\x76\x65\x72\x2c\x0a\x6e\x61\x76\x20\x61\x3a\x76\x69\x73\x69\x74\x65\x64\x2c\x0a\x6e\x61\x76\x20\x61\x3a\x6c\x69\x6e\x6b\x2c\x0a\x6e\x61\x76\x20\x61\x3a\x61\x63\x74\x69\x76\x65\x20\x7b\x0a\x20\x20\x74\x65\x78\x74\x2d\x64\x65\x63\x6f\x72\x61\x74\x69\x6f\x6e\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x75\x6c\x20\x7b\x0a\x20\x20\x6c\x69\x73\x74\x2d\x73\x74\x79\x6c\x65\x2d\x74\x79\x70\x65\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x30\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x30\x30\x25\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x74\x6f\x70\x3a\x20\x32\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x32\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x32\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x65\x6c\x65\x63\x74\x61\x62\x6c\x65\x3a\x68\x6f\x76\x65\x72\x3a\x6e\x6f\x74\x28\x2e\x73\x65\x6c\x65\x63\x74\x65\x64\x29\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x32\x39\x37\x32\x62\x31\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x65\x6c\x65\x63\x74\x65\x64\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x30\x66\x35\x38\x39\x37\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x32\x35\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x31\x35\x70\x78\x3b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x77\x68\x69\x74\x65\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x77\x65\x69\x67\x68\x74\x3a\x20\x34\x30\x30\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x61\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x65\x31\x65\x38\x65\x64\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x77\x65\x69\x67\x68\x74\x3a\x20\x33\x30\x30\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x73\x69\x7a\x65\x3a\x20\x31\x30\x70\x74\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x61\x3a\x68\x6f\x76\x65\x72\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x77\x68\x69\x74\x65\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x30\x3b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x30\x30\x25\x3b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x69\x6e\x6c\x69\x6e\x65\x2d\x62\x6c\x6f\x63\x6b\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x2e\x61\x63\x74\x69\x76\x65\x20\x75\x6c\x20\x7b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x62\x6c\x6f\x63\x6b\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x20\x75\x6c\x20\x7b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x73\x70\x61\x6e\x20\x7b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x77\x68\x69\x74\x65\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x77\x65\x69\x67\x68\x74\x3a\x20\x62\x6f\x6c\x64\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x73\x69\x7a\x65\x3a\x20\x31\x30\x2e\x35\x70\x74\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x6c\x69\x20\x73\x70\x61\x6e\x2e\x66\x61\x73\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x32\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x20\x6c\x69\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x34\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x2d\x74\x69\x74\x6c\x65\x20\x7b\x0a\x20\x20\x63\x75\x72\x73\x6f\x72\x3a\x20\x70\x6f\x69\x6e\x74\x65\x72\x3b\x0a\x7d\x0a\x0a\x2f\x2a\x2a\x20\x53\x68\x61\x72\x65\x64\x20\x2a\x2f\x0a\x0a\x70\x72\x65\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x74\x72\x61\x6e\x73\x70\x61\x72\x65\x6e\x74\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x62\x65\x6c\x6f\x77\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x72\x69\x67\x68\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x7b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x2d\x63\x6f\x6e\x74\x65\x6e\x74\x20\x3e\x20\x2e\x74\x61\x62\x2d\x70\x61\x6e\x65\x2c\x0a\x2e\x70\x69\x6c\x6c\x2d\x63\x6f\x6e\x74\x65\x6e\x74\x20\x3e\x20\x2e\x70\x69\x6c\x6c\x2d\x70\x61\x6e\x65\x20\x7b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x2d\x63\x6f\x6e\x74\x65\x6e\x74\x20\x3e\x20\x2e\x61\x63\x74\x69\x76\x65\x2c\x0a\x2e\x70\x69\x6c\x6c\x2d\x63\x6f\x6e\x74\x65\x6e\x74\x20\x3e\x20\x2e\x61\x63\x74\x69\x76\x65\x20\x7b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x62\x6c\x6f\x63\x6b\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x3e\x20\x6c\x69\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x72\x69\x67\x68\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x3e\x20\x6c\x69\x20\x7b\x0a\x20\x20\x66\x6c\x6f\x61\x74\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x3e\x20\x6c\x69\x20\x3e\x20\x61\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x72\x69\x67\x68\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x3e\x20\x6c\x69\x20\x3e\x20\x61\x20\x7b\x0a\x20\x20\x6d\x69\x6e\x2d\x77\x69\x64\x74\x68\x3a\x20\x37\x34\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x72\x69\x67\x68\x74\x3a\x20\x30\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x33\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x7b\x0a\x20\x20\x66\x6c\x6f\x61\x74\x3a\x20\x6c\x65\x66\x74\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x72\x69\x67\x68\x74\x3a\x20\x31\x39\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x72\x69\x67\x68\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x23\x64\x64\x64\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x3e\x20\x6c\x69\x20\x3e\x20\x61\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x72\x69\x67\x68\x74\x3a\x20\x2d\x31\x70\x78\x3b\x0a\x20\x20\x6c\x69\x6e\x65\x2d\x68\x65\x69\x67\x68\x74\x3a\x20\x30\x2e\x34\x35\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x72\x61\x64\x69\x75\x73\x3a\x20\x30\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x2e\x61\x63\x74\x69\x76\x65\x20\x3e\x20\x61\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x2e\x61\x63\x74\x69\x76\x65\x20\x3e\x20\x61\x3a\x68\x6f\x76\x65\x72\x2c\x0a\x2e\x74\x61\x62\x73\x2d\x6c\x65\x66\x74\x20\x3e\x20\x2e\x6e\x61\x76\x2d\x74\x61\x62\x73\x20\x2e\x61\x63\x74\x69\x76\x65\x20\x3e\x20\x61\x3a\x66\x6f\x63\x75\x73\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x64\x64\x64\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x63\x61\x70\x74\x69\x6f\x6e\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x74\x6f\x70\x3a\x20\x38\x70\x78\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x38\x70\x78\x3b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x37\x37\x37\x3b\x0a\x20\x20\x74\x65\x78\x74\x2d\x61\x6c\x69\x67\x6e\x3a\x20\x6c\x65\x66\x74\x3b\x0a\x7d\x0a\x0a\x2e\x66\x69\x6c\x74\x65\x72\x2d\x69\x6e\x70\x75\x74\x2d\x67\x72\x6f\x75\x70\x20\x7b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x72\x65\x6c\x61\x74\x69\x76\x65\x3b\x0a\x20\x20\x64\x69\x73\x70\x6c\x61\x79\x3a\x20\x69\x6e\x6c\x69\x6e\x65\x2d\x62\x6c\x6f\x63\x6b\x3b\x0a\x20\x20\x76\x65\x72\x74\x69\x63\x61\x6c\x2d\x61\x6c\x69\x67\x6e\x3a\x20\x6d\x69\x64\x64\x6c\x65\x3b\x0a\x7d\x0a\x0a\x23\x66\x69\x6c\x74\x65\x72\x20\x2e\x62\x74\x6e\x3a\x66\x6f\x63\x75\x73\x20\x7b\x0a\x20\x20\x6f\x75\x74\x6c\x69\x6e\x65\x3a\x20\x6e\x6f\x6e\x65\x3b\x0a\x7d\x0a\x0a\x2e\x66\x69\x6c\x74\x65\x72\x2d\x69\x6e\x70\x75\x74\x2e\x66\x6f\x72\x6d\x2d\x63\x6f\x6e\x74\x72\x6f\x6c\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x32\x33\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x66\x69\x6c\x74\x65\x72\x2d\x69\x6e\x70\x75\x74\x2d\x63\x6c\x65\x61\x72\x20\x7b\x0a\x20\x20\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x61\x62\x73\x6f\x6c\x75\x74\x65\x3b\x0a\x20\x20\x72\x69\x67\x68\x74\x3a\x20\x35\x70\x78\x3b\x0a\x20\x20\x74\x6f\x70\x3a\x20\x30\x3b\x0a\x20\x20\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x3b\x0a\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x31\x34\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x3a\x20\x61\x75\x74\x6f\x3b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x73\x69\x7a\x65\x3a\x20\x31\x34\x70\x78\x3b\x0a\x20\x20\x63\x75\x72\x73\x6f\x72\x3a\x20\x70\x6f\x69\x6e\x74\x65\x72\x3b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x63\x63\x63\x3b\x0a\x7d\x0a\x0a\x23\x66\x69\x6c\x74\x65\x72\x2d\x73\x75\x62\x6d\x69\x74\x2c\x0a\x23\x66\x69\x6c\x74\x65\x72\x2d\x6c\x6f\x61\x64\x69\x6e\x67\x20\x7b\x0a\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x31\x30\x30\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x66\x61\x73\x2d\x72\x65\x66\x72\x65\x73\x68\x2d\x61\x6e\x69\x6d\x61\x74\x65\x20\x7b\x0a\x20\x20\x2d\x61\x6e\x69\x6d\x61\x74\x69\x6f\x6e\x3a\x20\x73\x70\x69\x6e\x20\x30\x2e\x37\x73\x20\x69\x6e\x66\x69\x6e\x69\x74\x65\x20\x6c\x69\x6e\x65\x61\x72\x3b\x0a\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x61\x6e\x69\x6d\x61\x74\x69\x6f\x6e\x3a\x20\x73\x70\x69\x6e\x32\x20\x30\x2e\x37\x73\x20\x69\x6e\x66\x69\x6e\x69\x74\x65\x20\x6c\x69\x6e\x65\x61\x72\x3b\x0a\x7d\x0a\x0a\x40\x2d\x77\x65\x62\x6b\x69\x74\x2d\x6b\x65\x79\x66\x72\x61\x6d\x65\x73\x20\x73\x70\x69\x6e\x32\x20\x7b\x0a\x20\x20\x66\x72\x6f\x6d\x20\x7b\x0a\x20\x20\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x74\x72\x61\x6e\x73\x66\x6f\x72\x6d\x3a\x20\x72\x6f\x74\x61\x74\x65\x28\x30\x64\x65\x67\x29\x3b\x0a\x20\x20\x7d\x0a\x20\x20\x74\x6f\x20\x7b\x0a\x20\x20\x20\x20\x2d\x77\x65\x62\x6b\x69\x74\x2d\x74\x72\x61\x6e\x73\x66\x6f\x72\x6d\x3a\x20\x72\x6f\x74\x61\x74\x65\x28\x33\x36\x30\x64\x65\x67\x29\x3b\x0a\x20\x20\x7d\x0a\x7d\x0a\x0a\x40\x6b\x65\x79\x66\x72\x61\x6d\x65\x73\x20\x73\x70\x69\x6e\x20\x7b\x0a\x20\x20\x66\x72\x6f\x6d\x20\x7b\x0a\x20\x20\x20\x20\x74\x72\x61\x6e\x73\x66\x6f\x72\x6d\x3a\x20\x73\x63\x61\x6c\x65\x28\x31\x29\x20\x72\x6f\x74\x61\x74\x65\x28\x30\x64\x65\x67\x29\x3b\x0a\x20\x20\x7d\x0a\x20\x20\x74\x6f\x20\x7b\x0a\x20\x20\x20\x20\x74\x72\x61\x6e\x73\x66\x6f\x72\x6d\x3a\x20\x73\x63\x61\x6c\x65\x28\x31\x29\x20\x72\x6f\x74\x61\x74\x65\x28\x33\x36\x30\x64\x65\x67\x29\x3b\x0a\x20\x20\x7d\x0a\x7d\x0a\x0a\x2f\x2a\x2a\x20\x50\x6f\x70\x6f\x76\x65\x72\x20\x2a\x2f\x0a\x2e\x70\x6f\x70\x6f\x76\x65\x72\x2d\x63\x6f\x6e\x74\x65\x6e\x74\x20\x7b\x0a\x20\x20\x77\x6f\x72\x64\x2d\x77\x72\x61\x70\x3a\x20\x62\x72\x65\x61\x6b\x2d\x77\x6f\x72\x64\x3b\x0a\x7d\x0a"
-
-func cssIndexCssBytes() ([]byte, error) {
-	return bindataRead(
-		_cssIndexCss,
-		"css/index.css",
-	)
-}
-
-func cssIndexCss() (*asset, error) {
-	bytes, err := cssIndexCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "css/index.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x45, 0x95, 0x5c, 0xcf, 0xe5, 0x97, 0x5e, 0x30, 0xa6, 0x30, 0x2d, 0x8f, 0x1f, 0x97, 0x46, 0xd3, 0xaf, 0x96, 0x1, 0x4, 0x5c, 0xa2, 0x1c, 0x1f, 0x62, 0xc, 0xac, 0x1d, 0xd4, 0x8e, 0xe9, 0x4c}}
-	return a, nil
-}
-
-var _cssMetricQueryCss = "\x23\x63\x68\x61\x72\x74\x2d\x64\x69\x76\x20\x7b\x0a\x20\x20\x6d\x69\x6e\x2d\x68\x65\x69\x67\x68\x74\x3a\x20\x33\x35\x30\x70\x78\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x35\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x73\x6e\x75\x67\x67\x6c\x65\x2d\x6c\x65\x66\x74\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x2e\x73\x6e\x75\x67\x67\x6c\x65\x2d\x72\x69\x67\x68\x74\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x7b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x6c\x65\x66\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x74\x6f\x70\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x3b\x0a\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x33\x39\x37\x70\x78\x3b\x0a\x20\x20\x6f\x76\x65\x72\x66\x6c\x6f\x77\x2d\x79\x3a\x20\x61\x75\x74\x6f\x3b\x0a\x20\x20\x6f\x76\x65\x72\x66\x6c\x6f\x77\x2d\x78\x3a\x20\x68\x69\x64\x64\x65\x6e\x3b\x0a\x7d\x0a\x0a\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x6c\x69\x20\x7b\x0a\x20\x20\x63\x75\x72\x73\x6f\x72\x3a\x20\x70\x6f\x69\x6e\x74\x65\x72\x3b\x0a\x20\x20\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x34\x32\x38\x62\x63\x61\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x33\x70\x78\x20\x31\x32\x70\x78\x20\x33\x70\x78\x20\x31\x32\x70\x78\x3b\x0a\x7d\x0a\x0a\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x6c\x69\x3a\x68\x6f\x76\x65\x72\x3a\x6e\x6f\x74\x28\x2e\x73\x65\x6c\x65\x63\x74\x65\x64\x29\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x65\x66\x65\x66\x65\x66\x3b\x0a\x7d\x0a\x0a\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x6c\x69\x2e\x73\x65\x6c\x65\x63\x74\x65\x64\x20\x7b\x0a\x20\x20\x62\x61\x63\x6b\x67\x72\x6f\x75\x6e\x64\x2d\x63\x6f\x6c\x6f\x72\x3a\x20\x23\x64\x66\x64\x66\x64\x66\x3b\x0a\x7d\x0a"
-
-func cssMetricQueryCssBytes() ([]byte, error) {
-	return bindataRead(
-		_cssMetricQueryCss,
-		"css/metric-query.css",
-	)
-}
-
-func cssMetricQueryCss() (*asset, error) {
-	bytes, err := cssMetricQueryCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "css/metric-query.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x76, 0x75, 0x21, 0x58, 0x9a, 0x26, 0x82, 0x73, 0x87, 0xfc, 0xb1, 0xdf, 0x82, 0xd4, 0x81, 0x4b, 0x8f, 0x7a, 0x89, 0x26, 0xb7, 0x86, 0x4, 0xe5, 0xd3, 0x7, 0x26, 0x9c, 0x6a, 0x89, 0x3d, 0x7d}}
-	return a, nil
-}
-
-var _cssServerRegistryCss = "\x2e\x74\x61\x62\x2d\x70\x61\x6e\x65\x2e\x62\x6f\x72\x64\x65\x72\x73\x20\x7b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x6c\x65\x66\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x72\x69\x67\x68\x74\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x62\x6f\x72\x64\x65\x72\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x31\x70\x78\x20\x73\x6f\x6c\x69\x64\x20\x6c\x69\x67\x68\x74\x67\x72\x61\x79\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x3a\x20\x31\x30\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x32\x35\x70\x78\x3b\x0a\x7d\x0a\x0a\x2e\x73\x65\x72\x76\x65\x72\x2d\x73\x74\x61\x74\x73\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x74\x6f\x70\x3a\x20\x31\x36\x35\x70\x78\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x31\x30\x70\x78\x3b\x0a\x7d\x0a"
-
-func cssServerRegistryCssBytes() ([]byte, error) {
-	return bindataRead(
-		_cssServerRegistryCss,
-		"css/server-registry.css",
-	)
-}
-
-func cssServerRegistryCss() (*asset, error) {
-	bytes, err := cssServerRegistryCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "css/server-registry.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6e, 0x4a, 0xec, 0x85, 0x15, 0xc9, 0x90, 0x84, 0x66, 0xa0, 0xd2, 0x15, 0x70, 0x8a, 0x72, 0xe2, 0x46, 0x1b, 0x6a, 0x4f, 0xe, 0x36, 0x74, 0x4e, 0xab, 0xf4, 0xd1, 0xa6, 0x69, 0x6c, 0xa5, 0xc7}}
-	return a, nil
-}
-
-var _cssSummaryCss = "\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x75\x6c\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x62\x6f\x74\x74\x6f\x6d\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x75\x6c\x20\x6c\x69\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x6c\x65\x66\x74\x3a\x20\x30\x3b\x0a\x7d\x0a\x0a\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x75\x6c\x20\x6c\x69\x20\x64\x69\x76\x20\x73\x70\x61\x6e\x20\x7b\x0a\x20\x20\x66\x6f\x6e\x74\x2d\x77\x65\x69\x67\x68\x74\x3a\x20\x6e\x6f\x72\x6d\x61\x6c\x3b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x35\x70\x78\x3b\x0a\x7d\x0a\x0a\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x75\x6c\x20\x6c\x69\x20\x64\x69\x76\x20\x61\x20\x7b\x0a\x20\x20\x70\x61\x64\x64\x69\x6e\x67\x2d\x72\x69\x67\x68\x74\x3a\x20\x34\x70\x78\x3b\x0a\x7d\x0a\x0a\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x68\x72\x20\x7b\x0a\x20\x20\x6d\x61\x72\x67\x69\x6e\x2d\x74\x6f\x70\x3a\x20\x35\x70\x78\x3b\x0a\x7d\x0a"
-
-func cssSummaryCssBytes() ([]byte, error) {
-	return bindataRead(
-		_cssSummaryCss,
-		"css/summary.css",
-	)
-}
-
-func cssSummaryCss() (*asset, error) {
-	bytes, err := cssSummaryCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "css/summary.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3e, 0x5d, 0x11, 0x85, 0xe4, 0xb3, 0x15, 0xf1, 0xc2, 0xbd, 0xeb, 0xa7, 0xfd, 0x81, 0xa2, 0xc0, 0x0, 0xdc, 0x6e, 0x5f, 0x82, 0x70, 0x3c, 0xfe, 0x99, 0x1b, 0xe6, 0x2a, 0x4a, 0xdf, 0x9e, 0x3a}}
-	return a, nil
-}
-
-var _imgFaviconIco = "\x00\x00\x01\x00\x01\x00\x10\x10\x00\x00\x01\x00\x20\x00\x68\x04\x00\x00\x16\x00\x00\x00\x28\x00\x00\x00\x10\x00\x00\x00\x20\x00\x00\x00\x01\x00\x20\x00\x00\x00\x00\x00\x00\x04\x00\x00\x12\x0b\x00\x00\x12\x0b\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x52\x69\xbf\x66\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\x66\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x90\x8e\xac\xa4\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x52\x69\xbf\xff\x95\x91\xaa\xac\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x34\xf7\xca\x8c\xd5\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xec\xf7\xca\x8c\x34\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x80\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xec\xb6\x6e\xff\xe1\xa2\x50\xff\xec\xb6\x6e\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xa9\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\xc0\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xe1\xa2\x50\xff\xde\x9c\x48\xff\xe1\xa2\x50\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xe7\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\xed\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xec\xb6\x6e\xff\xe1\xa2\x50\xff\xec\xb6\x6e\xff\xf7\xca\x8c\xff\xef\xbb\x75\xff\xe4\xa7\x59\xff\xe4\xa7\x59\xff\xef\xbb\x75\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xfc\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\xed\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xe4\xa7\x59\xff\xde\x9c\x48\xff\xde\x9c\x48\xff\xe4\xa7\x59\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\xc0\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xe4\xa7\x59\xff\xde\x9c\x48\xff\xde\x9c\x48\xff\xe4\xa7\x59\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xf5\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x80\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xef\xbb\x75\xff\xe4\xa7\x59\xff\xe4\xa7\x59\xff\xef\xbb\x75\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xce\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x34\xf7\xca\x8c\xe3\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xea\xb3\x6a\xff\xea\xb3\x6a\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\x75\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x67\xf7\xca\x8c\xf9\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xea\xb3\x6a\xff\xea\xb3\x6a\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xba\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x75\xf7\xca\x8c\xec\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xff\xf7\xca\x8c\xba\xf7\xca\x8c\x17\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xf7\xca\x8c\x34\xf7\xca\x8c\xa9\xf7\xca\x8c\xe7\xf7\xca\x8c\xfc\xf7\xca\x8c\xff\xf7\xca\x8c\xf5\xf7\xca\x8c\xce\xf7\xca\x8c\x75\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xc0\x03\x00\x00\xc0\x03\x00\x00\xc0\x03\x00\x00\xc0\x03\x00\x00\xc0\x03\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\x80\x01\x00\x00\xc0\x03\x00\x00\xe0\x03\x00\x00\xf0\x0f\x00\x00"
-
-func imgFaviconIcoBytes() ([]byte, error) {
-	return bindataRead(
-		_imgFaviconIco,
-		"img/favicon.ico",
-	)
-}
-
-func imgFaviconIco() (*asset, error) {
-	bytes, err := imgFaviconIcoBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "img/favicon.ico", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3f, 0x3c, 0xef, 0x5c, 0x96, 0xb0, 0xc0, 0x22, 0x90, 0x38, 0x59, 0x56, 0x7c, 0xa5, 0x56, 0xe9, 0x9f, 0xe7, 0x5c, 0x77, 0x9, 0x7e, 0x28, 0x70, 0xd1, 0xdc, 0x0, 0x82, 0x83, 0xf7, 0x9b, 0x9e}}
-	return a, nil
-}
-
-var _jsChartRendererJs = "\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x24\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x67\x6f\x6f\x67\x6c\x65\x20\x2a\x2f\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x28\x65\x6c\x65\x6d\x65\x6e\x74\x2c\x20\x74\x69\x74\x6c\x65\x29\x20\x7b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x68\x65\x61\x64\x65\x72\x20\x3d\x20\x24\x28\x27\x3c\x68\x35\x3e\x3c\x2f\x68\x35\x3e\x27\x29\x0a\x20\x20\x20\x20\x2e\x61\x64\x64\x43\x6c\x61\x73\x73\x28\x27\x74\x65\x78\x74\x2d\x63\x65\x6e\x74\x65\x72\x27\x29\x0a\x20\x20\x20\x20\x2e\x74\x65\x78\x74\x28\x74\x69\x74\x6c\x65\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x63\x68\x61\x72\x74\x44\x69\x76\x20\x3d\x20\x24\x28\x27\x3c\x64\x69\x76\x3e\x3c\x2f\x64\x69\x76\x3e\x27\x29\x5b\x30\x5d\x3b\x0a\x0a\x20\x20\x24\x28\x65\x6c\x65\x6d\x65\x6e\x74\x29\x0a\x20\x20\x20\x20\x2e\x65\x6d\x70\x74\x79\x28\x29\x0a\x20\x20\x20\x20\x2e\x61\x70\x70\x65\x6e\x64\x28\x68\x65\x61\x64\x65\x72\x29\x3b\x0a\x20\x20\x24\x28\x65\x6c\x65\x6d\x65\x6e\x74\x29\x2e\x61\x70\x70\x65\x6e\x64\x28\x63\x68\x61\x72\x74\x44\x69\x76\x29\x3b\x0a\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x6f\x6c\x75\x6d\x6e\x73\x20\x3d\x20\x7b\x7d\x3b\x0a\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x20\x3d\x20\x6e\x65\x77\x20\x67\x6f\x6f\x67\x6c\x65\x2e\x76\x69\x73\x75\x61\x6c\x69\x7a\x61\x74\x69\x6f\x6e\x2e\x4c\x69\x6e\x65\x43\x68\x61\x72\x74\x28\x63\x68\x61\x72\x74\x44\x69\x76\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x20\x3d\x20\x6e\x65\x77\x20\x67\x6f\x6f\x67\x6c\x65\x2e\x76\x69\x73\x75\x61\x6c\x69\x7a\x61\x74\x69\x6f\x6e\x2e\x44\x61\x74\x61\x54\x61\x62\x6c\x65\x28\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2e\x61\x64\x64\x43\x6f\x6c\x75\x6d\x6e\x28\x27\x64\x61\x74\x65\x74\x69\x6d\x65\x27\x2c\x20\x27\x54\x69\x6d\x65\x27\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2e\x61\x64\x64\x52\x6f\x77\x73\x28\x36\x30\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x4f\x70\x74\x69\x6f\x6e\x73\x20\x3d\x20\x7b\x0a\x20\x20\x20\x20\x6c\x65\x67\x65\x6e\x64\x3a\x20\x7b\x70\x6f\x73\x69\x74\x69\x6f\x6e\x3a\x20\x27\x6e\x6f\x6e\x65\x27\x7d\x2c\x0a\x20\x20\x20\x20\x77\x69\x64\x74\x68\x3a\x20\x27\x31\x30\x30\x25\x27\x2c\x0a\x20\x20\x20\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x33\x35\x30\x2c\x0a\x20\x20\x20\x20\x63\x68\x61\x72\x74\x41\x72\x65\x61\x3a\x20\x7b\x77\x69\x64\x74\x68\x3a\x20\x27\x39\x30\x25\x27\x2c\x20\x68\x65\x69\x67\x68\x74\x3a\x20\x27\x38\x35\x25\x27\x7d\x2c\x0a\x20\x20\x20\x20\x76\x41\x78\x69\x73\x3a\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x76\x69\x65\x77\x57\x69\x6e\x64\x6f\x77\x3a\x20\x7b\x6d\x69\x6e\x3a\x20\x30\x7d\x2c\x0a\x20\x20\x20\x20\x20\x20\x62\x61\x73\x65\x6c\x69\x6e\x65\x43\x6f\x6c\x6f\x72\x3a\x20\x27\x23\x64\x64\x64\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x67\x72\x69\x64\x6c\x69\x6e\x65\x43\x6f\x6c\x6f\x72\x3a\x20\x27\x23\x64\x64\x64\x27\x2c\x0a\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x20\x20\x68\x41\x78\x69\x73\x3a\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x62\x61\x73\x65\x6c\x69\x6e\x65\x43\x6f\x6c\x6f\x72\x3a\x20\x27\x23\x64\x64\x64\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x67\x72\x69\x64\x6c\x69\x6e\x65\x43\x6f\x6c\x6f\x72\x3a\x20\x27\x23\x64\x64\x64\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x74\x65\x78\x74\x53\x74\x79\x6c\x65\x3a\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x66\x6f\x6e\x74\x53\x69\x7a\x65\x3a\x20\x31\x32\x2c\x0a\x20\x20\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x7d\x3b\x0a\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x2e\x64\x72\x61\x77\x28\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2c\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x4f\x70\x74\x69\x6f\x6e\x73\x29\x3b\x0a\x7d\x0a\x0a\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x61\x70\x70\x65\x6e\x64\x4d\x65\x74\x72\x69\x63\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x65\x74\x72\x69\x63\x73\x29\x20\x7b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x72\x6f\x77\x20\x3d\x20\x5b\x6e\x65\x77\x20\x44\x61\x74\x65\x28\x29\x5d\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x63\x20\x3d\x20\x74\x68\x69\x73\x3b\x0a\x20\x20\x6d\x65\x74\x72\x69\x63\x73\x2e\x66\x6f\x72\x45\x61\x63\x68\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x65\x74\x72\x69\x63\x29\x20\x7b\x0a\x20\x20\x20\x20\x69\x66\x20\x28\x21\x28\x6d\x65\x74\x72\x69\x63\x2e\x6e\x61\x6d\x65\x20\x69\x6e\x20\x63\x2e\x63\x6f\x6c\x75\x6d\x6e\x73\x29\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x2e\x63\x6f\x6c\x75\x6d\x6e\x73\x5b\x6d\x65\x74\x72\x69\x63\x2e\x6e\x61\x6d\x65\x5d\x20\x3d\x20\x74\x72\x75\x65\x3b\x0a\x20\x20\x20\x20\x20\x20\x63\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2e\x61\x64\x64\x43\x6f\x6c\x75\x6d\x6e\x28\x27\x6e\x75\x6d\x62\x65\x72\x27\x2c\x20\x6d\x65\x74\x72\x69\x63\x2e\x6e\x61\x6d\x65\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x20\x20\x20\x20\x72\x6f\x77\x2e\x70\x75\x73\x68\x28\x6d\x65\x74\x72\x69\x63\x2e\x76\x61\x6c\x75\x65\x29\x3b\x0a\x20\x20\x7d\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x61\x64\x64\x52\x6f\x77\x28\x72\x6f\x77\x29\x3b\x0a\x7d\x3b\x0a\x0a\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x61\x64\x64\x52\x6f\x77\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x72\x6f\x77\x29\x20\x7b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2e\x61\x64\x64\x52\x6f\x77\x28\x72\x6f\x77\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2e\x72\x65\x6d\x6f\x76\x65\x52\x6f\x77\x28\x30\x29\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x2e\x64\x72\x61\x77\x28\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x44\x61\x74\x61\x2c\x20\x74\x68\x69\x73\x2e\x63\x68\x61\x72\x74\x4f\x70\x74\x69\x6f\x6e\x73\x29\x3b\x0a\x7d\x3b\x0a"
-
-func jsChartRendererJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsChartRendererJs,
-		"js/chart-renderer.js",
-	)
-}
-
-func jsChartRendererJs() (*asset, error) {
-	bytes, err := jsChartRendererJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/chart-renderer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x9a, 0xff, 0xc7, 0x5b, 0x38, 0x5f, 0xf7, 0x54, 0x76, 0x3a, 0x7e, 0xbd, 0xb0, 0xa2, 0x6d, 0x4c, 0xd1, 0x53, 0x44, 0x4, 0x54, 0x35, 0x62, 0xab, 0x64, 0xc7, 0xb1, 0x1d, 0xf7, 0xb0, 0xc0, 0x98}}
-	return a, nil
-}
-
-var _jsIndexJs = "\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x24\x20\x2a\x2f\x0a\x0a\x24\x28\x64\x6f\x63\x75\x6d\x65\x6e\x74\x29\x2e\x72\x65\x61\x64\x79\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x24\x28\x27\x23\x74\x6f\x67\x67\x6c\x65\x27\x29\x2e\x63\x6c\x69\x63\x6b\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x65\x29\x20\x7b\x0a\x20\x20\x20\x20\x65\x2e\x70\x72\x65\x76\x65\x6e\x74\x44\x65\x66\x61\x75\x6c\x74\x28\x29\x3b\x0a\x20\x20\x20\x20\x24\x28\x27\x23\x77\x72\x61\x70\x70\x65\x72\x27\x29\x2e\x74\x6f\x67\x67\x6c\x65\x43\x6c\x61\x73\x73\x28\x27\x74\x6f\x67\x67\x6c\x65\x64\x27\x29\x3b\x0a\x20\x20\x20\x20\x24\x28\x27\x23\x74\x6f\x67\x67\x6c\x65\x20\x73\x70\x61\x6e\x27\x29\x2e\x74\x6f\x67\x67\x6c\x65\x43\x6c\x61\x73\x73\x28\x27\x66\x61\x2d\x61\x6e\x67\x6c\x65\x2d\x72\x69\x67\x68\x74\x27\x29\x3b\x0a\x20\x20\x7d\x29\x3b\x0a\x0a\x20\x20\x24\x28\x27\x6e\x61\x76\x20\x2e\x73\x75\x62\x6e\x61\x76\x2d\x74\x69\x74\x6c\x65\x27\x29\x2e\x63\x6c\x69\x63\x6b\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x24\x28\x74\x68\x69\x73\x29\x0a\x20\x20\x20\x20\x20\x20\x2e\x70\x61\x72\x65\x6e\x74\x28\x29\x0a\x20\x20\x20\x20\x20\x20\x2e\x66\x69\x6e\x64\x28\x27\x75\x6c\x27\x29\x0a\x20\x20\x20\x20\x20\x20\x2e\x73\x6c\x69\x64\x65\x54\x6f\x67\x67\x6c\x65\x28\x29\x3b\x0a\x20\x20\x20\x20\x24\x28\x74\x68\x69\x73\x29\x0a\x20\x20\x20\x20\x20\x20\x2e\x66\x69\x6e\x64\x28\x27\x2e\x66\x61\x73\x27\x29\x0a\x20\x20\x20\x20\x20\x20\x2e\x74\x6f\x67\x67\x6c\x65\x43\x6c\x61\x73\x73\x28\x27\x66\x61\x2d\x63\x61\x72\x65\x74\x2d\x73\x71\x75\x61\x72\x65\x2d\x75\x70\x27\x29\x3b\x0a\x20\x20\x7d\x29\x3b\x0a\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x63\x6f\x6e\x66\x69\x72\x6d\x61\x74\x69\x6f\x6e\x4c\x69\x6e\x6b\x73\x20\x3d\x20\x7b\x0a\x20\x20\x20\x20\x27\x41\x62\x6f\x72\x74\x2d\x53\x65\x72\x76\x65\x72\x27\x3a\x20\x27\x61\x62\x6f\x72\x74\x27\x2c\x0a\x20\x20\x20\x20\x27\x51\x75\x69\x74\x2d\x53\x65\x72\x76\x65\x72\x27\x3a\x20\x27\x71\x75\x69\x74\x27\x2c\x0a\x20\x20\x20\x20\x53\x68\x75\x74\x64\x6f\x77\x6e\x3a\x20\x27\x73\x68\x75\x74\x64\x6f\x77\x6e\x27\x2c\x0a\x20\x20\x7d\x3b\x0a\x0a\x20\x20\x66\x6f\x72\x20\x28\x63\x6f\x6e\x73\x74\x20\x6b\x65\x79\x20\x69\x6e\x20\x63\x6f\x6e\x66\x69\x72\x6d\x61\x74\x69\x6f\x6e\x4c\x69\x6e\x6b\x73\x29\x20\x7b\x0a\x20\x20\x20\x20\x69\x66\x20\x28\x63\x6f\x6e\x66\x69\x72\x6d\x61\x74\x69\x6f\x6e\x4c\x69\x6e\x6b\x73\x2e\x68\x61\x73\x4f\x77\x6e\x50\x72\x6f\x70\x65\x72\x74\x79\x28\x6b\x65\x79\x29\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x65\x6c\x65\x6d\x20\x3d\x20\x64\x6f\x63\x75\x6d\x65\x6e\x74\x2e\x67\x65\x74\x45\x6c\x65\x6d\x65\x6e\x74\x42\x79\x49\x64\x28\x6b\x65\x79\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x24\x28\x65\x6c\x65\x6d\x29\x2e\x63\x6c\x69\x63\x6b\x28\x7b\x6e\x61\x6d\x65\x3a\x20\x63\x6f\x6e\x66\x69\x72\x6d\x61\x74\x69\x6f\x6e\x4c\x69\x6e\x6b\x73\x5b\x6b\x65\x79\x5d\x7d\x2c\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x65\x76\x65\x6e\x74\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x63\x6f\x6e\x66\x69\x72\x6d\x28\x27\x41\x72\x65\x20\x79\x6f\x75\x20\x73\x75\x72\x65\x20\x79\x6f\x75\x20\x77\x61\x6e\x74\x20\x74\x6f\x20\x27\x20\x2b\x20\x65\x76\x65\x6e\x74\x2e\x64\x61\x74\x61\x2e\x6e\x61\x6d\x65\x20\x2b\x20\x27\x3f\x27\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x7d\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x20\x20\x7d\x0a\x7d\x29\x3b\x0a"
-
-func jsIndexJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsIndexJs,
-		"js/index.js",
-	)
-}
-
-func jsIndexJs() (*asset, error) {
-	bytes, err := jsIndexJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/index.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xff, 0xf2, 0x10, 0x1, 0x37, 0x9e, 0x2f, 0xfc, 0xfb, 0xc4, 0xd3, 0x5f, 0x5, 0x37, 0xea, 0x71, 0xc6, 0x13, 0xf7, 0x17, 0x65, 0xe5, 0x6, 0x2, 0x3e, 0x3e, 0x69, 0x3f, 0xf1, 0xfc, 0x9d, 0x9c}}
-	return a, nil
-}
-
-var _jsMetricQueryJs = "\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x24\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x67\x6f\x6f\x67\x6c\x65\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x20\x2a\x2f\x0a\x0a\x67\x6f\x6f\x67\x6c\x65\x2e\x6c\x6f\x61\x64\x28\x27\x76\x69\x73\x75\x61\x6c\x69\x7a\x61\x74\x69\x6f\x6e\x27\x2c\x20\x27\x31\x27\x2c\x20\x7b\x70\x61\x63\x6b\x61\x67\x65\x73\x3a\x20\x5b\x27\x63\x6f\x72\x65\x63\x68\x61\x72\x74\x27\x5d\x2c\x20\x63\x61\x6c\x6c\x62\x61\x63\x6b\x3a\x20\x67\x72\x61\x70\x68\x4c\x69\x62\x4c\x6f\x61\x64\x65\x64\x7d\x29\x3b\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x67\x72\x61\x70\x68\x4c\x69\x62\x4c\x6f\x61\x64\x65\x64\x28\x29\x20\x7b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x63\x68\x61\x72\x44\x69\x76\x20\x3d\x20\x24\x28\x27\x23\x63\x68\x61\x72\x74\x2d\x64\x69\x76\x27\x29\x5b\x30\x5d\x3b\x0a\x20\x20\x6c\x65\x74\x20\x73\x65\x6c\x65\x63\x74\x65\x64\x20\x3d\x20\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x3b\x0a\x20\x20\x6c\x65\x74\x20\x69\x6e\x74\x65\x72\x76\x61\x6c\x20\x3d\x20\x7b\x7d\x3b\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x66\x72\x65\x73\x68\x53\x74\x61\x74\x73\x28\x73\x74\x61\x74\x2c\x20\x63\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6c\x65\x61\x72\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x69\x6e\x74\x65\x72\x76\x61\x6c\x29\x3b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x75\x72\x6c\x20\x3d\x20\x24\x28\x27\x23\x6d\x65\x74\x72\x69\x63\x73\x2d\x67\x72\x69\x64\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x20\x2b\x20\x27\x3f\x6d\x3d\x27\x20\x2b\x20\x73\x74\x61\x74\x3b\x0a\x0a\x20\x20\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x6e\x64\x65\x72\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6a\x73\x6f\x6e\x20\x3d\x20\x24\x2e\x70\x61\x72\x73\x65\x4a\x53\x4f\x4e\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x69\x66\x20\x28\x6a\x73\x6f\x6e\x5b\x30\x5d\x20\x21\x3d\x3d\x20\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x29\x20\x63\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x2e\x61\x70\x70\x65\x6e\x64\x4d\x65\x74\x72\x69\x63\x28\x6a\x73\x6f\x6e\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x0a\x20\x20\x20\x20\x69\x6e\x74\x65\x72\x76\x61\x6c\x20\x3d\x20\x73\x65\x74\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x75\x72\x6c\x2c\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x3a\x20\x72\x65\x6e\x64\x65\x72\x2c\x0a\x20\x20\x20\x20\x20\x20\x7d\x29\x3b\x0a\x20\x20\x20\x20\x7d\x2c\x20\x31\x30\x30\x30\x29\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x6e\x64\x65\x72\x28\x6c\x69\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x73\x74\x61\x74\x20\x3d\x20\x6c\x69\x2e\x68\x74\x6d\x6c\x28\x29\x3b\x0a\x20\x20\x20\x20\x69\x66\x20\x28\x73\x65\x6c\x65\x63\x74\x65\x64\x20\x21\x3d\x3d\x20\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x29\x20\x73\x65\x6c\x65\x63\x74\x65\x64\x2e\x72\x65\x6d\x6f\x76\x65\x43\x6c\x61\x73\x73\x28\x27\x73\x65\x6c\x65\x63\x74\x65\x64\x27\x29\x3b\x0a\x20\x20\x20\x20\x6c\x69\x2e\x61\x64\x64\x43\x6c\x61\x73\x73\x28\x27\x73\x65\x6c\x65\x63\x74\x65\x64\x27\x29\x3b\x0a\x20\x20\x20\x20\x73\x65\x6c\x65\x63\x74\x65\x64\x20\x3d\x20\x6c\x69\x3b\x0a\x20\x20\x20\x20\x72\x65\x66\x72\x65\x73\x68\x53\x74\x61\x74\x73\x28\x73\x74\x61\x74\x2c\x20\x6e\x65\x77\x20\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x28\x63\x68\x61\x72\x44\x69\x76\x2c\x20\x73\x74\x61\x74\x29\x29\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x24\x28\x27\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x6c\x69\x27\x29\x2e\x6f\x6e\x28\x27\x63\x6c\x69\x63\x6b\x27\x2c\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x65\x29\x20\x7b\x0a\x20\x20\x20\x20\x72\x65\x6e\x64\x65\x72\x28\x24\x28\x65\x2e\x74\x61\x72\x67\x65\x74\x29\x29\x3b\x0a\x20\x20\x7d\x29\x3b\x0a\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x66\x72\x61\x67\x6d\x65\x6e\x74\x49\x64\x20\x3d\x20\x24\x28\x0a\x20\x20\x20\x20\x27\x23\x27\x20\x2b\x0a\x20\x20\x20\x20\x20\x20\x77\x69\x6e\x64\x6f\x77\x2e\x6c\x6f\x63\x61\x74\x69\x6f\x6e\x2e\x68\x61\x73\x68\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x2e\x72\x65\x70\x6c\x61\x63\x65\x28\x27\x23\x27\x2c\x20\x27\x27\x29\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x2e\x72\x65\x70\x6c\x61\x63\x65\x28\x2f\x5c\x2f\x2f\x67\x2c\x20\x27\x2d\x27\x29\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x2f\x2f\x20\x63\x73\x73\x20\x63\x68\x61\x72\x73\x20\x74\x6f\x20\x65\x73\x63\x61\x70\x65\x3a\x20\x21\x22\x23\x24\x25\x26\x27\x28\x29\x2a\x2b\x2c\x2d\x2e\x2f\x3a\x3b\x3c\x3d\x3e\x3f\x40\x5b\x5c\x5d\x5e\x60\x7b\x7c\x7d\x7e\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x2e\x72\x65\x70\x6c\x61\x63\x65\x28\x2f\x28\x21\x7c\x22\x7c\x23\x7c\x25\x7c\x26\x7c\x27\x7c\x5c\x28\x7c\x5c\x29\x7c\x5c\x2a\x7c\x5c\x2b\x7c\x2c\x7c\x2d\x7c\x5c\x2e\x7c\x5c\x2f\x7c\x3a\x7c\x3b\x7c\x3c\x7c\x3d\x7c\x3e\x7c\x5c\x3f\x7c\x40\x7c\x5c\x5b\x7c\x5c\x5c\x7c\x5c\x5d\x7c\x5c\x5e\x7c\x60\x7c\x7b\x7c\x5c\x7c\x7c\x7d\x7c\x7e\x29\x2f\x67\x2c\x20\x27\x5c\x5c\x24\x31\x27\x29\x0a\x20\x20\x29\x3b\x0a\x20\x20\x69\x66\x20\x28\x66\x72\x61\x67\x6d\x65\x6e\x74\x49\x64\x5b\x30\x5d\x20\x21\x3d\x3d\x20\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x29\x20\x7b\x0a\x20\x20\x20\x20\x24\x28\x66\x72\x61\x67\x6d\x65\x6e\x74\x49\x64\x29\x5b\x30\x5d\x2e\x73\x63\x72\x6f\x6c\x6c\x49\x6e\x74\x6f\x56\x69\x65\x77\x28\x74\x72\x75\x65\x29\x3b\x0a\x20\x20\x20\x20\x72\x65\x6e\x64\x65\x72\x28\x66\x72\x61\x67\x6d\x65\x6e\x74\x49\x64\x29\x3b\x0a\x20\x20\x7d\x20\x65\x6c\x73\x65\x20\x7b\x0a\x20\x20\x20\x20\x72\x65\x6e\x64\x65\x72\x28\x24\x28\x27\x23\x6d\x65\x74\x72\x69\x63\x73\x20\x6c\x69\x3a\x66\x69\x72\x73\x74\x27\x29\x29\x3b\x0a\x20\x20\x7d\x0a\x7d\x0a"
-
-func jsMetricQueryJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsMetricQueryJs,
-		"js/metric-query.js",
-	)
-}
-
-func jsMetricQueryJs() (*asset, error) {
-	bytes, err := jsMetricQueryJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/metric-query.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xef, 0x43, 0xe1, 0x29, 0x56, 0xef, 0xe9, 0xa2, 0xaf, 0xc4, 0xb4, 0xe5, 0xfa, 0x37, 0xdf, 0x89, 0x46, 0x1c, 0x49, 0xc9, 0xd2, 0x86, 0x88, 0xc4, 0x78, 0xfb, 0xc2, 0x1, 0x9f, 0x6f, 0x62, 0x40}}
-	return a, nil
-}
-
-var _jsServerRegistryJs = "\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x24\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x67\x6f\x6f\x67\x6c\x65\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x20\x2a\x2f\x0a\x0a\x67\x6f\x6f\x67\x6c\x65\x2e\x6c\x6f\x61\x64\x28\x27\x76\x69\x73\x75\x61\x6c\x69\x7a\x61\x74\x69\x6f\x6e\x27\x2c\x20\x27\x31\x27\x2c\x20\x7b\x70\x61\x63\x6b\x61\x67\x65\x73\x3a\x20\x5b\x27\x63\x6f\x72\x65\x63\x68\x61\x72\x74\x27\x5d\x2c\x20\x63\x61\x6c\x6c\x62\x61\x63\x6b\x3a\x20\x67\x72\x61\x70\x68\x4c\x69\x62\x4c\x6f\x61\x64\x65\x64\x7d\x29\x3b\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x67\x72\x61\x70\x68\x4c\x69\x62\x4c\x6f\x61\x64\x65\x64\x28\x29\x20\x7b\x0a\x20\x20\x6c\x65\x74\x20\x69\x6e\x74\x65\x72\x76\x61\x6c\x20\x3d\x20\x7b\x7d\x3b\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x66\x72\x65\x73\x68\x53\x74\x61\x74\x73\x28\x64\x64\x73\x2c\x20\x63\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6c\x65\x61\x72\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x69\x6e\x74\x65\x72\x76\x61\x6c\x29\x3b\x0a\x20\x20\x20\x20\x6c\x65\x74\x20\x75\x72\x6c\x20\x3d\x20\x24\x28\x27\x23\x73\x65\x72\x76\x65\x72\x2d\x74\x61\x62\x73\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x20\x2b\x20\x27\x3f\x27\x3b\x0a\x20\x20\x20\x20\x6c\x65\x74\x20\x6c\x61\x62\x65\x6c\x3b\x0a\x20\x20\x20\x20\x66\x6f\x72\x20\x28\x6c\x65\x74\x20\x69\x20\x3d\x20\x30\x3b\x20\x69\x20\x3c\x20\x64\x64\x73\x2e\x6c\x65\x6e\x67\x74\x68\x3b\x20\x69\x2b\x2b\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6b\x65\x79\x20\x3d\x20\x24\x28\x64\x64\x73\x5b\x69\x5d\x29\x2e\x64\x61\x74\x61\x28\x27\x6b\x65\x79\x27\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x75\x72\x6c\x20\x2b\x3d\x20\x27\x6d\x3d\x27\x20\x2b\x20\x6b\x65\x79\x20\x2b\x20\x27\x26\x27\x3b\x0a\x20\x20\x20\x20\x20\x20\x6c\x61\x62\x65\x6c\x20\x3d\x20\x24\x28\x64\x64\x73\x5b\x69\x5d\x29\x2e\x64\x61\x74\x61\x28\x27\x6c\x61\x62\x65\x6c\x27\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x0a\x20\x20\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x6e\x64\x65\x72\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6a\x73\x6f\x6e\x20\x3d\x20\x24\x2e\x70\x61\x72\x73\x65\x4a\x53\x4f\x4e\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x6c\x65\x74\x20\x66\x61\x69\x6c\x75\x72\x65\x73\x20\x3d\x20\x30\x3b\x0a\x20\x20\x20\x20\x20\x20\x6c\x65\x74\x20\x72\x65\x71\x75\x65\x73\x74\x73\x20\x3d\x20\x30\x3b\x0a\x20\x20\x20\x20\x20\x20\x66\x6f\x72\x20\x28\x6c\x65\x74\x20\x69\x20\x3d\x20\x30\x3b\x20\x69\x20\x3c\x20\x6a\x73\x6f\x6e\x2e\x6c\x65\x6e\x67\x74\x68\x3b\x20\x69\x2b\x2b\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6e\x61\x6d\x65\x20\x3d\x20\x6a\x73\x6f\x6e\x5b\x69\x5d\x2e\x6e\x61\x6d\x65\x3b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x76\x61\x6c\x75\x65\x20\x3d\x20\x6a\x73\x6f\x6e\x5b\x69\x5d\x2e\x76\x61\x6c\x75\x65\x3b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x69\x64\x20\x3d\x20\x6e\x61\x6d\x65\x2e\x72\x65\x70\x6c\x61\x63\x65\x28\x2f\x5c\x2f\x7c\x7b\x7c\x7d\x7c\x3d\x2f\x67\x2c\x20\x27\x2d\x27\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x2e\x69\x6e\x64\x65\x78\x4f\x66\x28\x6c\x61\x62\x65\x6c\x29\x20\x3e\x20\x2d\x31\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x20\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x2e\x69\x6e\x64\x65\x78\x4f\x66\x28\x27\x66\x61\x69\x6c\x75\x72\x65\x73\x27\x29\x20\x3e\x20\x2d\x31\x29\x20\x66\x61\x69\x6c\x75\x72\x65\x73\x20\x3d\x20\x76\x61\x6c\x75\x65\x3b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x2e\x69\x6e\x64\x65\x78\x4f\x66\x28\x27\x72\x65\x71\x75\x65\x73\x74\x73\x27\x29\x20\x3e\x20\x2d\x31\x29\x20\x72\x65\x71\x75\x65\x73\x74\x73\x20\x3d\x20\x76\x61\x6c\x75\x65\x3b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x7d\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x24\x28\x27\x23\x27\x20\x2b\x20\x69\x64\x29\x2e\x74\x65\x78\x74\x28\x76\x61\x6c\x75\x65\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x7d\x0a\x0a\x20\x20\x20\x20\x20\x20\x6c\x65\x74\x20\x73\x72\x20\x3d\x20\x30\x2e\x30\x3b\x0a\x20\x20\x20\x20\x20\x20\x69\x66\x20\x28\x72\x65\x71\x75\x65\x73\x74\x73\x20\x3e\x20\x30\x29\x20\x73\x72\x20\x3d\x20\x4e\x75\x6d\x62\x65\x72\x28\x28\x28\x31\x2e\x30\x20\x2d\x20\x66\x61\x69\x6c\x75\x72\x65\x73\x20\x2f\x20\x72\x65\x71\x75\x65\x73\x74\x73\x29\x20\x2a\x20\x31\x30\x30\x2e\x30\x29\x2e\x74\x6f\x46\x69\x78\x65\x64\x28\x34\x29\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x63\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x2e\x61\x70\x70\x65\x6e\x64\x4d\x65\x74\x72\x69\x63\x28\x5b\x7b\x6e\x61\x6d\x65\x3a\x20\x27\x27\x2c\x20\x76\x61\x6c\x75\x65\x3a\x20\x73\x72\x7d\x5d\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x0a\x20\x20\x20\x20\x69\x6e\x74\x65\x72\x76\x61\x6c\x20\x3d\x20\x73\x65\x74\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x75\x72\x6c\x2c\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x3a\x20\x72\x65\x6e\x64\x65\x72\x2c\x0a\x20\x20\x20\x20\x20\x20\x7d\x29\x3b\x0a\x20\x20\x20\x20\x7d\x2c\x20\x31\x30\x30\x30\x29\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x24\x28\x27\x61\x5b\x64\x61\x74\x61\x2d\x74\x6f\x67\x67\x6c\x65\x3d\x22\x74\x61\x62\x22\x5d\x27\x29\x2e\x6f\x6e\x28\x27\x73\x68\x6f\x77\x6e\x2e\x62\x73\x2e\x74\x61\x62\x27\x2c\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x61\x63\x74\x69\x76\x65\x20\x3d\x20\x24\x28\x27\x23\x73\x65\x72\x76\x65\x72\x73\x27\x29\x2e\x66\x69\x6e\x64\x28\x27\x2e\x74\x61\x62\x2d\x70\x61\x6e\x65\x2e\x61\x63\x74\x69\x76\x65\x27\x29\x3b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x67\x72\x61\x70\x68\x44\x69\x76\x20\x3d\x20\x61\x63\x74\x69\x76\x65\x2e\x66\x69\x6e\x64\x28\x27\x23\x73\x65\x72\x76\x65\x72\x2d\x67\x72\x61\x70\x68\x27\x29\x3b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x63\x68\x61\x72\x74\x20\x3d\x20\x6e\x65\x77\x20\x43\x68\x61\x72\x74\x52\x65\x6e\x64\x65\x72\x65\x72\x28\x67\x72\x61\x70\x68\x44\x69\x76\x5b\x30\x5d\x2c\x20\x27\x53\x75\x63\x63\x65\x73\x73\x20\x52\x61\x74\x65\x27\x29\x3b\x0a\x20\x20\x20\x20\x72\x65\x66\x72\x65\x73\x68\x53\x74\x61\x74\x73\x28\x61\x63\x74\x69\x76\x65\x2e\x66\x69\x6e\x64\x28\x27\x64\x64\x27\x29\x2c\x20\x63\x68\x61\x72\x74\x29\x3b\x0a\x20\x20\x7d\x29\x3b\x0a\x0a\x20\x20\x24\x28\x27\x23\x73\x65\x72\x76\x65\x72\x2d\x74\x61\x62\x73\x20\x61\x3a\x66\x69\x72\x73\x74\x27\x29\x2e\x74\x61\x62\x28\x27\x73\x68\x6f\x77\x27\x29\x3b\x0a\x7d\x0a"
-
-func jsServerRegistryJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsServerRegistryJs,
-		"js/server-registry.js",
-	)
-}
-
-func jsServerRegistryJs() (*asset, error) {
-	bytes, err := jsServerRegistryJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/server-registry.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x37, 0xd1, 0x8d, 0x8f, 0x6f, 0xf7, 0x8b, 0x89, 0x61, 0x76, 0xf5, 0x32, 0xfe, 0x78, 0xba, 0xbe, 0x43, 0x65, 0xe2, 0x27, 0x18, 0x32, 0xdd, 0x21, 0xc2, 0x1c, 0xb2, 0xbf, 0xe3, 0x87, 0x7b, 0x75}}
-	return a, nil
-}
-
-var _jsSummaryJs = "\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x24\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x20\x2a\x2f\x0a\x2f\x2a\x20\x67\x6c\x6f\x62\x61\x6c\x20\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x20\x2a\x2f\x0a\x0a\x63\x6f\x6e\x73\x74\x20\x77\x61\x69\x74\x46\x6f\x72\x44\x6f\x6d\x20\x3d\x20\x73\x65\x74\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x29\x20\x7b\x0a\x20\x20\x69\x66\x20\x28\x24\x28\x27\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x27\x29\x20\x21\x3d\x3d\x20\x6e\x75\x6c\x6c\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6c\x65\x61\x72\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x77\x61\x69\x74\x46\x6f\x72\x44\x6f\x6d\x29\x3b\x0a\x20\x20\x20\x20\x6c\x6f\x61\x64\x50\x72\x6f\x63\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x20\x20\x6c\x6f\x61\x64\x43\x6c\x69\x65\x6e\x74\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x20\x20\x6c\x6f\x61\x64\x53\x65\x72\x76\x65\x72\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x20\x20\x6c\x6f\x61\x64\x4c\x69\x6e\x74\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x7d\x0a\x7d\x2c\x20\x32\x35\x30\x29\x3b\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x6c\x6f\x61\x64\x50\x72\x6f\x63\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x6c\x65\x74\x20\x75\x72\x6c\x20\x3d\x20\x24\x28\x27\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x20\x2b\x20\x27\x3f\x27\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x6c\x69\x73\x74\x20\x3d\x20\x24\x28\x27\x23\x70\x72\x6f\x63\x65\x73\x73\x2d\x69\x6e\x66\x6f\x20\x75\x6c\x20\x6c\x69\x27\x29\x3b\x0a\x0a\x20\x20\x66\x6f\x72\x20\x28\x6c\x65\x74\x20\x69\x20\x3d\x20\x30\x3b\x20\x69\x20\x3c\x20\x6c\x69\x73\x74\x2e\x6c\x65\x6e\x67\x74\x68\x3b\x20\x69\x2b\x2b\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6b\x65\x79\x20\x3d\x20\x24\x28\x6c\x69\x73\x74\x5b\x69\x5d\x29\x2e\x64\x61\x74\x61\x28\x27\x6b\x65\x79\x27\x29\x3b\x0a\x20\x20\x20\x20\x69\x66\x20\x28\x6b\x65\x79\x20\x21\x3d\x3d\x20\x75\x6e\x64\x65\x66\x69\x6e\x65\x64\x29\x20\x75\x72\x6c\x20\x2b\x3d\x20\x27\x26\x6d\x3d\x27\x20\x2b\x20\x6b\x65\x79\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x6d\x73\x54\x6f\x53\x74\x72\x20\x3d\x20\x6e\x65\x77\x20\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x28\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x62\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x20\x3d\x20\x6e\x65\x77\x20\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x28\x29\x3b\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x70\x72\x65\x74\x74\x79\x28\x6e\x61\x6d\x65\x2c\x20\x76\x61\x6c\x75\x65\x29\x20\x7b\x0a\x20\x20\x20\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x20\x3d\x3d\x3d\x20\x27\x70\x72\x6f\x63\x65\x73\x73\x5f\x75\x70\x74\x69\x6d\x65\x27\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x6d\x73\x54\x6f\x53\x74\x72\x2e\x63\x6f\x6e\x76\x65\x72\x74\x28\x76\x61\x6c\x75\x65\x20\x2a\x20\x31\x30\x30\x30\x29\x3b\x0a\x20\x20\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x20\x3d\x3d\x3d\x20\x27\x67\x6f\x5f\x6d\x65\x6d\x73\x74\x61\x74\x73\x5f\x61\x6c\x6c\x6f\x63\x5f\x62\x79\x74\x65\x73\x27\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x62\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x2e\x63\x6f\x6e\x76\x65\x72\x74\x28\x76\x61\x6c\x75\x65\x29\x3b\x0a\x20\x20\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6e\x61\x6d\x65\x20\x3d\x3d\x3d\x20\x27\x67\x6f\x5f\x67\x63\x5f\x64\x75\x72\x61\x74\x69\x6f\x6e\x5f\x73\x65\x63\x6f\x6e\x64\x73\x27\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x6d\x73\x54\x6f\x53\x74\x72\x2e\x63\x6f\x6e\x76\x65\x72\x74\x28\x76\x61\x6c\x75\x65\x20\x2a\x20\x31\x30\x30\x30\x29\x3b\x0a\x20\x20\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x76\x61\x6c\x75\x65\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x72\x65\x6e\x64\x65\x72\x50\x72\x6f\x63\x49\x6e\x66\x6f\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x6a\x73\x6f\x6e\x20\x3d\x20\x24\x2e\x70\x61\x72\x73\x65\x4a\x53\x4f\x4e\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x66\x6f\x72\x20\x28\x6c\x65\x74\x20\x69\x20\x3d\x20\x30\x3b\x20\x69\x20\x3c\x20\x6a\x73\x6f\x6e\x2e\x6c\x65\x6e\x67\x74\x68\x3b\x20\x69\x2b\x2b\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x69\x64\x20\x3d\x20\x6a\x73\x6f\x6e\x5b\x69\x5d\x2e\x6e\x61\x6d\x65\x2e\x72\x65\x70\x6c\x61\x63\x65\x28\x2f\x5c\x2f\x2f\x67\x2c\x20\x27\x2d\x27\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x63\x6f\x6e\x73\x74\x20\x76\x61\x6c\x75\x65\x20\x3d\x20\x70\x72\x65\x74\x74\x79\x28\x6a\x73\x6f\x6e\x5b\x69\x5d\x2e\x6e\x61\x6d\x65\x2c\x20\x6a\x73\x6f\x6e\x5b\x69\x5d\x2e\x76\x61\x6c\x75\x65\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x24\x28\x27\x23\x27\x20\x2b\x20\x69\x64\x29\x2e\x74\x65\x78\x74\x28\x76\x61\x6c\x75\x65\x29\x3b\x0a\x20\x20\x20\x20\x7d\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x66\x65\x74\x63\x68\x50\x72\x6f\x63\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x20\x20\x75\x72\x6c\x2c\x0a\x20\x20\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x63\x61\x63\x68\x65\x3a\x20\x66\x61\x6c\x73\x65\x2c\x0a\x20\x20\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x3a\x20\x72\x65\x6e\x64\x65\x72\x50\x72\x6f\x63\x49\x6e\x66\x6f\x2c\x0a\x20\x20\x20\x20\x7d\x29\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x66\x65\x74\x63\x68\x50\x72\x6f\x63\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x73\x65\x74\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x66\x65\x74\x63\x68\x50\x72\x6f\x63\x49\x6e\x66\x6f\x2c\x20\x31\x30\x30\x30\x29\x3b\x0a\x7d\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x6c\x6f\x61\x64\x43\x6c\x69\x65\x6e\x74\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x66\x65\x74\x63\x68\x43\x6c\x69\x65\x6e\x74\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x20\x20\x75\x72\x6c\x3a\x20\x24\x28\x27\x23\x63\x6c\x69\x65\x6e\x74\x2d\x69\x6e\x66\x6f\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x2c\x0a\x20\x20\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x20\x20\x63\x61\x63\x68\x65\x3a\x20\x66\x61\x6c\x73\x65\x2c\x0a\x20\x20\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x20\x20\x24\x28\x27\x23\x63\x6c\x69\x65\x6e\x74\x2d\x69\x6e\x66\x6f\x27\x29\x2e\x68\x74\x6d\x6c\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x20\x20\x7d\x29\x3b\x0a\x20\x20\x7d\x0a\x0a\x20\x20\x66\x65\x74\x63\x68\x43\x6c\x69\x65\x6e\x74\x49\x6e\x66\x6f\x28\x29\x3b\x0a\x20\x20\x73\x65\x74\x49\x6e\x74\x65\x72\x76\x61\x6c\x28\x66\x65\x74\x63\x68\x43\x6c\x69\x65\x6e\x74\x49\x6e\x66\x6f\x2c\x20\x31\x30\x30\x30\x29\x3b\x0a\x7d\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x6c\x6f\x61\x64\x53\x65\x72\x76\x65\x72\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x75\x72\x6c\x3a\x20\x24\x28\x27\x23\x73\x65\x72\x76\x65\x72\x2d\x69\x6e\x66\x6f\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x2c\x0a\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x63\x61\x63\x68\x65\x3a\x20\x66\x61\x6c\x73\x65\x2c\x0a\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x24\x28\x27\x23\x73\x65\x72\x76\x65\x72\x2d\x69\x6e\x66\x6f\x27\x29\x2e\x68\x74\x6d\x6c\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x7d\x29\x3b\x0a\x7d\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x6c\x6f\x61\x64\x4c\x69\x6e\x74\x49\x6e\x66\x6f\x28\x29\x20\x7b\x0a\x20\x20\x24\x2e\x61\x6a\x61\x78\x28\x7b\x0a\x20\x20\x20\x20\x75\x72\x6c\x3a\x20\x24\x28\x27\x23\x6c\x69\x6e\x74\x2d\x77\x61\x72\x6e\x69\x6e\x67\x73\x27\x29\x2e\x64\x61\x74\x61\x28\x27\x72\x65\x66\x72\x65\x73\x68\x2d\x75\x72\x69\x27\x29\x20\x2b\x20\x27\x3f\x27\x2c\x0a\x20\x20\x20\x20\x64\x61\x74\x61\x54\x79\x70\x65\x3a\x20\x27\x74\x65\x78\x74\x27\x2c\x0a\x20\x20\x20\x20\x63\x61\x63\x68\x65\x3a\x20\x66\x61\x6c\x73\x65\x2c\x0a\x20\x20\x20\x20\x73\x75\x63\x63\x65\x73\x73\x28\x64\x61\x74\x61\x29\x20\x7b\x0a\x20\x20\x20\x20\x20\x20\x24\x28\x27\x23\x6c\x69\x6e\x74\x2d\x77\x61\x72\x6e\x69\x6e\x67\x73\x27\x29\x2e\x68\x74\x6d\x6c\x28\x64\x61\x74\x61\x29\x3b\x0a\x20\x20\x20\x20\x7d\x2c\x0a\x20\x20\x7d\x29\x3b\x0a\x7d\x0a"
-
-func jsSummaryJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsSummaryJs,
-		"js/summary.js",
-	)
-}
-
-func jsSummaryJs() (*asset, error) {
-	bytes, err := jsSummaryJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/summary.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2b, 0x45, 0x51, 0xed, 0x40, 0xf, 0x62, 0x9d, 0xb5, 0xf, 0x8a, 0x70, 0x2f, 0x7b, 0x15, 0xa2, 0x4c, 0xef, 0x6f, 0x4e, 0xd1, 0x29, 0xb, 0x11, 0xf0, 0x30, 0x86, 0x35, 0x4a, 0x46, 0xf3, 0x48}}
-	return a, nil
-}
-
-var _jsUtilsJs = "\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x28\x29\x20\x7b\x0a\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x53\x65\x63\x6f\x6e\x64\x20\x3d\x20\x31\x30\x30\x30\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x4d\x69\x6e\x75\x74\x65\x20\x3d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x53\x65\x63\x6f\x6e\x64\x20\x2a\x20\x36\x30\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x20\x3d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x4d\x69\x6e\x75\x74\x65\x20\x2a\x20\x36\x30\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x20\x3d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x20\x2a\x20\x32\x34\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x20\x3d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x20\x2a\x20\x33\x36\x35\x2e\x32\x34\x32\x3b\x0a\x7d\x0a\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x4d\x69\x6c\x6c\x69\x73\x65\x63\x6f\x6e\x64\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x6d\x73\x20\x2b\x20\x27\x6d\x73\x27\x3b\x0a\x7d\x3b\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x53\x65\x63\x6f\x6e\x64\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x73\x65\x63\x6f\x6e\x64\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x73\x65\x63\x6f\x6e\x64\x73\x20\x2b\x20\x27\x73\x27\x3b\x0a\x7d\x3b\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x4d\x69\x6e\x75\x74\x65\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x69\x6e\x75\x74\x65\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x6d\x69\x6e\x75\x74\x65\x73\x20\x2b\x20\x27\x6d\x27\x3b\x0a\x7d\x3b\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x48\x6f\x75\x72\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x68\x6f\x75\x72\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x68\x6f\x75\x72\x73\x20\x2b\x20\x27\x68\x27\x3b\x0a\x7d\x3b\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x44\x61\x79\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x64\x61\x79\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x64\x61\x79\x73\x20\x2b\x20\x27\x64\x27\x3b\x0a\x7d\x3b\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x59\x65\x61\x72\x73\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x79\x65\x61\x72\x73\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x79\x65\x61\x72\x73\x20\x2b\x20\x27\x79\x27\x3b\x0a\x7d\x3b\x0a\x0a\x4d\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x63\x6f\x6e\x76\x65\x72\x74\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x73\x29\x20\x7b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x79\x65\x61\x72\x73\x20\x3d\x20\x28\x6d\x73\x20\x2f\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x29\x2e\x74\x6f\x46\x69\x78\x65\x64\x28\x30\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x64\x61\x79\x73\x20\x3d\x20\x4d\x61\x74\x68\x2e\x66\x6c\x6f\x6f\x72\x28\x28\x6d\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x20\x2a\x20\x79\x65\x61\x72\x73\x29\x20\x2f\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x68\x6f\x75\x72\x73\x20\x3d\x20\x4d\x61\x74\x68\x2e\x66\x6c\x6f\x6f\x72\x28\x28\x6d\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x20\x2a\x20\x79\x65\x61\x72\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x20\x2a\x20\x64\x61\x79\x73\x29\x20\x2f\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x6d\x69\x6e\x75\x74\x65\x73\x20\x3d\x20\x4d\x61\x74\x68\x2e\x66\x6c\x6f\x6f\x72\x28\x0a\x20\x20\x20\x20\x28\x6d\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x20\x2a\x20\x79\x65\x61\x72\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x20\x2a\x20\x64\x61\x79\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x20\x2a\x20\x68\x6f\x75\x72\x73\x29\x20\x2f\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x4d\x69\x6e\x75\x74\x65\x0a\x20\x20\x29\x3b\x0a\x20\x20\x63\x6f\x6e\x73\x74\x20\x73\x65\x63\x6f\x6e\x64\x73\x20\x3d\x20\x4d\x61\x74\x68\x2e\x66\x6c\x6f\x6f\x72\x28\x0a\x20\x20\x20\x20\x28\x6d\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x20\x2a\x20\x79\x65\x61\x72\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x20\x2a\x20\x64\x61\x79\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x20\x2a\x20\x68\x6f\x75\x72\x73\x20\x2d\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x4d\x69\x6e\x75\x74\x65\x20\x2a\x20\x6d\x69\x6e\x75\x74\x65\x73\x29\x20\x2f\x0a\x20\x20\x20\x20\x20\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x53\x65\x63\x6f\x6e\x64\x0a\x20\x20\x29\x3b\x0a\x0a\x20\x20\x69\x66\x20\x28\x6d\x73\x20\x3c\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x53\x65\x63\x6f\x6e\x64\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4d\x69\x6c\x6c\x69\x73\x65\x63\x6f\x6e\x64\x73\x28\x6d\x73\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6d\x73\x20\x3c\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x4d\x69\x6e\x75\x74\x65\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x53\x65\x63\x6f\x6e\x64\x73\x28\x73\x65\x63\x6f\x6e\x64\x73\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6d\x73\x20\x3c\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x48\x6f\x75\x72\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4d\x69\x6e\x75\x74\x65\x73\x28\x6d\x69\x6e\x75\x74\x65\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x53\x65\x63\x6f\x6e\x64\x73\x28\x73\x65\x63\x6f\x6e\x64\x73\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6d\x73\x20\x3c\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x44\x61\x79\x29\x0a\x20\x20\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x48\x6f\x75\x72\x73\x28\x68\x6f\x75\x72\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4d\x69\x6e\x75\x74\x65\x73\x28\x6d\x69\x6e\x75\x74\x65\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x53\x65\x63\x6f\x6e\x64\x73\x28\x73\x65\x63\x6f\x6e\x64\x73\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x6d\x73\x20\x3c\x20\x74\x68\x69\x73\x2e\x6d\x73\x49\x6e\x59\x65\x61\x72\x29\x0a\x20\x20\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x44\x61\x79\x73\x28\x64\x61\x79\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x48\x6f\x75\x72\x73\x28\x68\x6f\x75\x72\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4d\x69\x6e\x75\x74\x65\x73\x28\x6d\x69\x6e\x75\x74\x65\x73\x29\x3b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x59\x65\x61\x72\x73\x28\x79\x65\x61\x72\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x44\x61\x79\x73\x28\x64\x61\x79\x73\x29\x20\x2b\x20\x27\x20\x27\x20\x2b\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x48\x6f\x75\x72\x73\x28\x68\x6f\x75\x72\x73\x29\x3b\x0a\x7d\x3b\x0a\x0a\x66\x75\x6e\x63\x74\x69\x6f\x6e\x20\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x28\x29\x20\x7b\x0a\x20\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4b\x42\x20\x3d\x20\x31\x30\x32\x34\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4d\x42\x20\x3d\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4b\x42\x20\x2a\x20\x31\x30\x32\x34\x3b\x0a\x20\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x47\x42\x20\x3d\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4d\x42\x20\x2a\x20\x31\x30\x32\x34\x3b\x0a\x7d\x0a\x0a\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x42\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x62\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x62\x20\x2b\x20\x27\x42\x27\x3b\x0a\x7d\x3b\x0a\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x4b\x42\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6b\x62\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x6b\x62\x20\x2b\x20\x27\x4b\x42\x27\x3b\x0a\x7d\x3b\x0a\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x4d\x42\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x6d\x62\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x6d\x62\x20\x2b\x20\x27\x4d\x42\x27\x3b\x0a\x7d\x3b\x0a\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x73\x68\x6f\x77\x47\x42\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x67\x62\x29\x20\x7b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x67\x62\x20\x2b\x20\x27\x47\x42\x27\x3b\x0a\x7d\x3b\x0a\x0a\x42\x79\x74\x65\x73\x54\x6f\x53\x74\x72\x69\x6e\x67\x43\x6f\x6e\x76\x65\x72\x74\x65\x72\x2e\x70\x72\x6f\x74\x6f\x74\x79\x70\x65\x2e\x63\x6f\x6e\x76\x65\x72\x74\x20\x3d\x20\x66\x75\x6e\x63\x74\x69\x6f\x6e\x28\x62\x29\x20\x7b\x0a\x20\x20\x69\x66\x20\x28\x62\x20\x3c\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4b\x42\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x42\x28\x62\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x62\x20\x3c\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4d\x42\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4b\x42\x28\x28\x62\x20\x2f\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4b\x42\x29\x2e\x74\x6f\x46\x69\x78\x65\x64\x28\x31\x29\x29\x3b\x0a\x20\x20\x65\x6c\x73\x65\x20\x69\x66\x20\x28\x62\x20\x3c\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x47\x42\x29\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x4d\x42\x28\x28\x62\x20\x2f\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x4d\x42\x29\x2e\x74\x6f\x46\x69\x78\x65\x64\x28\x31\x29\x29\x3b\x0a\x20\x20\x72\x65\x74\x75\x72\x6e\x20\x74\x68\x69\x73\x2e\x73\x68\x6f\x77\x47\x42\x28\x28\x62\x20\x2f\x20\x74\x68\x69\x73\x2e\x62\x79\x74\x65\x73\x49\x6e\x47\x42\x29\x2e\x74\x6f\x46\x69\x78\x65\x64\x28\x31\x29\x29\x3b\x0a\x7d\x3b\x0a"
-
-func jsUtilsJsBytes() ([]byte, error) {
-	return bindataRead(
-		_jsUtilsJs,
-		"js/utils.js",
-	)
-}
-
-func jsUtilsJs() (*asset, error) {
-	bytes, err := jsUtilsJsBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "js/utils.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x19, 0x40, 0xca, 0xc6, 0xdc, 0xf9, 0x84, 0xaf, 0xaa, 0xc4, 0x1c, 0x32, 0x1e, 0xf1, 0xeb, 0xb8, 0xff, 0xa6, 0xc9, 0x1, 0x28, 0x1e, 0xec, 0xba, 0x6a, 0xc, 0xe1, 0x9c, 0xc3, 0xb4, 0x34, 0x19}}
-	return a, nil
-}
-
-// Asset loads and returns the asset for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
-func Asset(name string) ([]byte, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
-		}
-		return a.bytes, nil
-	}
-	return nil, fmt.Errorf("Asset %s not found", name)
-}
-
-// AssetString returns the asset contents as a string (instead of a []byte).
-func AssetString(name string) (string, error) {
-	data, err := Asset(name)
-	return string(data), err
-}
-
-// MustAsset is like Asset but panics when Asset would return an error.
-// It simplifies safe initialization of global variables.
-func MustAsset(name string) []byte {
-	a, err := Asset(name)
-	if err != nil {
-		panic("asset: Asset(" + name + "): " + err.Error())
-	}
-
-	return a
-}
-
-// MustAssetString is like AssetString but panics when Asset would return an
-// error. It simplifies safe initialization of global variables.
-func MustAssetString(name string) string {
-	return string(MustAsset(name))
-}
-
-// AssetInfo loads and returns the asset info for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
-func AssetInfo(name string) (os.FileInfo, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
-		}
-		return a.info, nil
-	}
-	return nil, fmt.Errorf("AssetInfo %s not found", name)
-}
-
-// AssetDigest returns the digest of the file with the given name. It returns an
-// error if the asset could not be found or the digest could not be loaded.
-func AssetDigest(name string) ([sha256.Size]byte, error) {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[canonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s can't read by error: %v", name, err)
-		}
-		return a.digest, nil
-	}
-	return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s not found", name)
-}
-
-// Digests returns a map of all known files and their checksums.
-func Digests() (map[string][sha256.Size]byte, error) {
-	mp := make(map[string][sha256.Size]byte, len(_bindata))
-	for name := range _bindata {
-		a, err := _bindata[name]()
-		if err != nil {
-			return nil, err
-		}
-		mp[name] = a.digest
-	}
-	return mp, nil
-}
-
-// AssetNames returns the names of the assets.
-func AssetNames() []string {
-	names := make([]string, 0, len(_bindata))
-	for name := range _bindata {
-		names = append(names, name)
-	}
-	return names
-}
-
-// _bindata is a table, holding each asset generator, mapped to its name.
-var _bindata = map[string]func() (*asset, error){
-	"css/client-registry.css": cssClientRegistryCss,
-
-	"css/index.css": cssIndexCss,
-
-	"css/metric-query.css": cssMetricQueryCss,
-
-	"css/server-registry.css": cssServerRegistryCss,
-
-	"css/summary.css": cssSummaryCss,
-
-	"img/favicon.ico": imgFaviconIco,
-
-	"js/chart-renderer.js": jsChartRendererJs,
-
-	"js/index.js": jsIndexJs,
-
-	"js/metric-query.js": jsMetricQueryJs,
-
-	"js/server-registry.js": jsServerRegistryJs,
-
-	"js/summary.js": jsSummaryJs,
-
-	"js/utils.js": jsUtilsJs,
-}
-
-// AssetDir returns the file names below a certain
-// directory embedded in the file by go-bindata.
-// For example if you run go-bindata on data/... and data contains the
-// following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
-// then AssetDir("data") would return []string{"foo.txt", "img"},
-// AssetDir("data/img") would return []string{"a.png", "b.png"},
-// AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
-// AssetDir("") will return []string{"data"}.
-func AssetDir(name string) ([]string, error) {
-	node := _bintree
-	if len(name) != 0 {
-		canonicalName := strings.Replace(name, "\\", "/", -1)
-		pathList := strings.Split(canonicalName, "/")
-		for _, p := range pathList {
-			node = node.Children[p]
-			if node == nil {
-				return nil, fmt.Errorf("Asset %s not found", name)
-			}
-		}
-	}
-	if node.Func != nil {
-		return nil, fmt.Errorf("Asset %s not found", name)
-	}
-	rv := make([]string, 0, len(node.Children))
-	for childName := range node.Children {
-		rv = append(rv, childName)
-	}
-	return rv, nil
-}
-
-type bintree struct {
-	Func     func() (*asset, error)
-	Children map[string]*bintree
-}
-
-var _bintree = &bintree{nil, map[string]*bintree{
-	"css": &bintree{nil, map[string]*bintree{
-		"client-registry.css": &bintree{cssClientRegistryCss, map[string]*bintree{}},
-		"index.css":           &bintree{cssIndexCss, map[string]*bintree{}},
-		"metric-query.css":    &bintree{cssMetricQueryCss, map[string]*bintree{}},
-		"server-registry.css": &bintree{cssServerRegistryCss, map[string]*bintree{}},
-		"summary.css":         &bintree{cssSummaryCss, map[string]*bintree{}},
-	}},
-	"img": &bintree{nil, map[string]*bintree{
-		"favicon.ico": &bintree{imgFaviconIco, map[string]*bintree{}},
-	}},
-	"js": &bintree{nil, map[string]*bintree{
-		"chart-renderer.js":  &bintree{jsChartRendererJs, map[string]*bintree{}},
-		"index.js":           &bintree{jsIndexJs, map[string]*bintree{}},
-		"metric-query.js":    &bintree{jsMetricQueryJs, map[string]*bintree{}},
-		"server-registry.js": &bintree{jsServerRegistryJs, map[string]*bintree{}},
-		"summary.js":         &bintree{jsSummaryJs, map[string]*bintree{}},
-		"utils.js":           &bintree{jsUtilsJs, map[string]*bintree{}},
-	}},
-}}
-
-// RestoreAsset restores an asset under the given directory.
-func RestoreAsset(dir, name string) error {
-	data, err := Asset(name)
-	if err != nil {
-		return err
-	}
-	info, err := AssetInfo(name)
-	if err != nil {
-		return err
-	}
-	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
-	if err != nil {
-		return err
-	}
-	return os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
-}
-
-// RestoreAssets restores an asset under the given directory recursively.
-func RestoreAssets(dir, name string) error {
-	children, err := AssetDir(name)
-	// File
-	if err != nil {
-		return RestoreAsset(dir, name)
-	}
-	// Dir
-	for _, child := range children {
-		err = RestoreAssets(dir, filepath.Join(name, child))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func _filePath(dir, name string) string {
-	canonicalName := strings.Replace(name, "\\", "/", -1)
-	return filepath.Join(append([]string{dir}, strings.Split(canonicalName, "/")...)...)
-}
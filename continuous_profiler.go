@@ -0,0 +1,467 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Defaults for continuous profiling when the corresponding Opts aren't set.
+const (
+	defaultContinuousProfilingInterval = time.Minute
+	defaultContinuousProfilingRetain   = 20
+)
+
+// defaultContinuousProfilingKinds are the kinds of profile captured continuously unless Opts.ContinuousProfilingKinds
+// is set. Mutex & block profiles are empty unless their rates are set, e.g. via /admin/runtime.
+var defaultContinuousProfilingKinds = []string{"cpu", "heap", "goroutine", "mutex", "block"}
+
+// profileExportTimeout bounds how long each exporter can take to export a profile.
+var profileExportTimeout = 30 * time.Second
+
+// A CapturedProfile is a profile captured by the continuous profiler.
+type CapturedProfile struct {
+	Kind string
+	Time time.Time
+	// Duration is how long CPU profiles were captured over. It's zero for other kinds of profile.
+	Duration time.Duration
+	// Labels are those set via Opts.ContinuousProfilingLabels, e.g. to identify the service.
+	Labels map[string]string
+	// Data is the profile in the gzipped protobuf format understood by go tool pprof.
+	Data []byte
+}
+
+// filename is the name the profile is written as by DirectoryExporter.
+func (p CapturedProfile) filename() string {
+	return fmt.Sprintf("%s-%s.pb.gz", p.Kind, p.Time.UTC().Format("20060102T150405.000Z"))
+}
+
+// A ProfileExporter receives profiles captured by the continuous profiler, for example to push them to a
+// profiling service. Export is called from the profiler's goroutine with a context that expires after 30s.
+type ProfileExporter interface {
+	Export(ctx context.Context, profile CapturedProfile) error
+}
+
+// ProfileExporterFunc adapts a function to the ProfileExporter interface.
+type ProfileExporterFunc func(ctx context.Context, profile CapturedProfile) error
+
+// Export implements the ProfileExporter interface.
+func (f ProfileExporterFunc) Export(ctx context.Context, profile CapturedProfile) error {
+	return f(ctx, profile)
+}
+
+// A DirectoryExporter writes profiles to files in a local directory, named by their kind & time.
+type DirectoryExporter struct {
+	Dir string
+}
+
+// Export implements the ProfileExporter interface.
+func (e DirectoryExporter) Export(ctx context.Context, profile CapturedProfile) error {
+	if err := os.MkdirAll(e.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.Dir, profile.filename()), profile.Data, 0644)
+}
+
+func (e DirectoryExporter) String() string {
+	return "directory " + e.Dir
+}
+
+// An HTTPExporter POSTs profiles to a URL. The profile's kind, time (in RFC3339 format), duration (in seconds) &
+// labels are added to the URL as query parameters, the labels as label.<name>=<value>.
+type HTTPExporter struct {
+	URL string
+	// Client is used to make requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Header is added to each request, e.g. to authenticate it.
+	Header http.Header
+}
+
+// Export implements the ProfileExporter interface.
+func (e HTTPExporter) Export(ctx context.Context, profile CapturedProfile) error {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("kind", profile.Kind)
+	q.Set("time", profile.Time.UTC().Format(time.RFC3339Nano))
+	q.Set("duration", strconv.FormatFloat(profile.Duration.Seconds(), 'f', -1, 64))
+	for name, value := range profile.Labels {
+		q.Set("label."+name, value)
+	}
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(profile.Data))
+	if err != nil {
+		return err
+	}
+	for name, values := range e.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if uerr, ok := err.(*url.Error); ok {
+		uerr.URL = e.redactedURL()
+		return uerr
+	} else if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", e.redactedURL(), resp.Status)
+	}
+	return nil
+}
+
+// redactedURL is the URL without its userinfo or query, either of which might hold credentials, since it's shown on
+// the status page and in metrics.
+func (e HTTPExporter) redactedURL() string {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+}
+
+func (e HTTPExporter) String() string {
+	return "HTTP " + e.redactedURL()
+}
+
+// exporterName describes an exporter on the status page.
+func exporterName(e ProfileExporter) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+var (
+	continuousProfiles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "admin_continuous_profiles_total",
+		Help: "Number of profiles captured by the continuous profiler, by kind and outcome: success or failure.",
+	}, []string{"kind", "outcome"})
+	profileExports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "admin_profile_exports_total",
+		Help: "Number of continuously captured profiles exported, by exporter and outcome: success or failure.",
+	}, []string{"exporter", "outcome"})
+)
+
+func init() {
+	adminRegistry.MustRegister(continuousProfiles, profileExports)
+}
+
+// A profileExport is the outcome of exporting a profile to one exporter.
+type profileExport struct {
+	Exporter string        `json:"exporter"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// A continuousCapture is the outcome of capturing one profile, which is retained until there are too many.
+type continuousCapture struct {
+	Seq      int             `json:"seq"`
+	Kind     string          `json:"kind"`
+	Time     time.Time       `json:"time"`
+	Duration time.Duration   `json:"duration,omitempty"`
+	Size     int             `json:"size"`
+	Error    string          `json:"error,omitempty"`
+	Exports  []profileExport `json:"exports"`
+	data     []byte
+}
+
+// failed returns true if capturing or exporting the profile failed.
+func (c *continuousCapture) failed() bool {
+	if c.Error != "" {
+		return true
+	}
+	for _, e := range c.Exports {
+		if e.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// A continuousProfiler captures profiles periodically and passes them to its exporters.
+type continuousProfiler struct {
+	interval    time.Duration
+	cpuDuration time.Duration
+	kinds       []string
+	labels      map[string]string
+	exporters   []ProfileExporter
+	retain      int
+	// limits are those on the CPU profiler, which are shared with everything else that uses it.
+	limits *debugLimiters
+
+	mutex    sync.Mutex
+	captures []*continuousCapture // oldest first
+	seq      int
+	started  time.Time
+}
+
+// newContinuousProfiler returns the continuous profiler configured by the given Opts, or nil if it's not enabled.
+func newContinuousProfiler(opts Opts) (*continuousProfiler, error) {
+	if !opts.ContinuousProfiling {
+		return nil, nil
+	}
+	p := &continuousProfiler{
+		interval:    opts.ContinuousProfilingInterval,
+		cpuDuration: opts.ContinuousProfilingCPUDuration,
+		kinds:       opts.ContinuousProfilingKinds,
+		labels:      opts.ContinuousProfilingLabels,
+		exporters:   append([]ProfileExporter{}, opts.ProfileExporters...),
+		retain:      opts.ContinuousProfilingRetain,
+	}
+	if p.interval <= 0 {
+		p.interval = defaultContinuousProfilingInterval
+	}
+	if p.cpuDuration <= 0 {
+		p.cpuDuration = defaultProfileCPUDuration
+	}
+	if p.cpuDuration >= p.interval {
+		return nil, fmt.Errorf("continuous profiling CPU duration %s must be shorter than its interval %s", p.cpuDuration, p.interval)
+	}
+	if len(p.kinds) == 0 {
+		p.kinds = defaultContinuousProfilingKinds
+	}
+	for _, kind := range p.kinds {
+		if err := checkProfileKind(kind); err != nil {
+			return nil, err
+		}
+	}
+	if p.retain <= 0 {
+		p.retain = defaultContinuousProfilingRetain
+	}
+	if opts.ContinuousProfilingDir != "" {
+		p.exporters = append(p.exporters, DirectoryExporter{Dir: opts.ContinuousProfilingDir})
+	}
+	if opts.ContinuousProfilingURL != "" {
+		if _, err := url.Parse(opts.ContinuousProfilingURL); err != nil {
+			return nil, fmt.Errorf("invalid continuous profiling URL: %s", err)
+		}
+		p.exporters = append(p.exporters, HTTPExporter{URL: opts.ContinuousProfilingURL})
+	}
+	return p, nil
+}
+
+// run captures profiles every interval until the context is done.
+func (p *continuousProfiler) run(ctx context.Context) {
+	p.mutex.Lock()
+	p.started = time.Now()
+	p.mutex.Unlock()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.captureAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// captureAll captures & exports one profile of each kind.
+func (p *continuousProfiler) captureAll(ctx context.Context) {
+	for _, kind := range p.kinds {
+		if ctx.Err() != nil {
+			return
+		}
+		p.capture(ctx, kind)
+	}
+}
+
+// capture captures & exports a profile of the given kind, and records the outcome.
+func (p *continuousProfiler) capture(ctx context.Context, kind string) *continuousCapture {
+	profile := CapturedProfile{Kind: kind, Time: time.Now(), Labels: p.labels}
+	if kind == "cpu" {
+		profile.Duration = p.cpuDuration
+	}
+	c := &continuousCapture{Kind: kind, Time: profile.Time, Duration: profile.Duration, Exports: []profileExport{}}
+	data, err := captureProfile(withDebugSource(ctx, "continuous profiler"), p.limits, kind, p.cpuDuration)
+	if err != nil {
+		log.Warningf("Failed to capture %s profile: %s", kind, err)
+		continuousProfiles.WithLabelValues(kind, "failure").Inc()
+		c.Error = err.Error()
+	} else {
+		continuousProfiles.WithLabelValues(kind, "success").Inc()
+		profile.Data = data
+		c.data, c.Size = data, len(data)
+		for _, e := range p.exporters {
+			c.Exports = append(c.Exports, p.export(ctx, e, profile))
+		}
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.seq++
+	c.Seq = p.seq
+	p.captures = append(p.captures, c)
+	if len(p.captures) > p.retain {
+		p.captures = p.captures[1:]
+	}
+	return c
+}
+
+func (p *continuousProfiler) export(ctx context.Context, e ProfileExporter, profile CapturedProfile) profileExport {
+	ctx, cancel := context.WithTimeout(ctx, profileExportTimeout)
+	defer cancel()
+	start := time.Now()
+	err := e.Export(ctx, profile)
+	ret := profileExport{Exporter: exporterName(e), Duration: time.Since(start)}
+	if err != nil {
+		log.Warningf("Failed to export %s profile to %s: %s", profile.Kind, ret.Exporter, err)
+		profileExports.WithLabelValues(ret.Exporter, "failure").Inc()
+		ret.Error = err.Error()
+	} else {
+		profileExports.WithLabelValues(ret.Exporter, "success").Inc()
+	}
+	return ret
+}
+
+// history returns the retained captures, newest first.
+func (p *continuousProfiler) history() []*continuousCapture {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	ret := make([]*continuousCapture, len(p.captures))
+	for i, c := range p.captures {
+		ret[len(ret)-1-i] = c
+	}
+	return ret
+}
+
+// get returns a retained capture by its sequence number.
+func (p *continuousProfiler) get(seq string) *continuousCapture {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, c := range p.captures {
+		if strconv.Itoa(c.Seq) == seq {
+			return c
+		}
+	}
+	return nil
+}
+
+func (a *HTTPServer) getProfiler() *continuousProfiler {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.profiler
+}
+
+// profilingStatus is the status of the continuous profiler.
+type profilingStatus struct {
+	Enabled     bool                 `json:"enabled"`
+	Started     time.Time            `json:"started,omitempty"`
+	Interval    time.Duration        `json:"interval,omitempty"`
+	CPUDuration time.Duration        `json:"cpu_duration,omitempty"`
+	Kinds       []string             `json:"kinds,omitempty"`
+	Labels      map[string]string    `json:"labels,omitempty"`
+	Exporters   []string             `json:"exporters,omitempty"`
+	Failures    int                  `json:"failures"`
+	Captures    []*continuousCapture `json:"captures"`
+}
+
+func (a *HTTPServer) profilingStatus() profilingStatus {
+	p := a.getProfiler()
+	if p == nil {
+		return profilingStatus{Captures: []*continuousCapture{}}
+	}
+	status := profilingStatus{
+		Enabled:     true,
+		Interval:    p.interval,
+		CPUDuration: p.cpuDuration,
+		Kinds:       p.kinds,
+		Labels:      p.labels,
+		Captures:    p.history(),
+	}
+	p.mutex.Lock()
+	status.Started = p.started
+	p.mutex.Unlock()
+	for _, e := range p.exporters {
+		status.Exporters = append(status.Exporters, exporterName(e))
+	}
+	for _, c := range status.Captures {
+		if c.failed() {
+			status.Failures++
+		}
+	}
+	return status
+}
+
+var profilingTemplate = template.Must(template.New("profiling").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"size": func(n int) string { return formatBytes(uint64(n)) },
+	"join": strings.Join,
+}).Parse(`
+{{if not .Enabled}}
+<p>Continuous profiling isn't enabled. Set <code>--continuous_profiling</code> or <code>Opts.ContinuousProfiling</code> to enable it.</p>
+{{else}}
+<p>
+	Capturing {{join .Kinds ", "}} profiles every {{.Interval}}{{if .Started.IsZero}}{{else}} since {{time .Started}}{{end}}, with CPU profiles over {{.CPUDuration}}.
+	{{range $k, $v := .Labels}}<span class="badge badge-light">{{$k}}={{$v}}</span> {{end}}
+</p>
+<p>Exporting to {{range $i, $e := .Exporters}}{{if $i}}, {{end}}{{$e}}{{else}}nowhere; the profiles below are only held in memory{{end}}.</p>
+{{if .Failures}}<div class="alert alert-warning">{{.Failures}} of the captures below failed.</div>{{end}}
+<table class="table table-sm">
+	<thead><tr><th>captured</th><th>kind</th><th>size</th><th>outcome</th><th></th></tr></thead>
+	<tbody>
+	{{range .Captures}}
+		<tr{{if .Error}} class="table-danger"{{end}}>
+			<td>{{time .Time}}</td>
+			<td>{{.Kind}}{{if .Duration}} ({{.Duration}}){{end}}</td>
+			<td>{{if not .Error}}{{size .Size}}{{end}}</td>
+			<td>{{if .Error}}{{.Error}}{{else}}{{range .Exports}}<span class="badge {{if .Error}}badge-danger{{else}}badge-success{{end}}" title="{{if .Error}}{{.Error}}{{else}}took {{.Duration}}{{end}}">{{.Exporter}}</span> {{else}}captured{{end}}{{end}}</td>
+			<td>{{if not .Error}}<a href="/admin/profiles/view?capture={{.Seq}}">view</a> <a href="/admin/profiling/{{.Seq}}">download</a>{{end}}</td>
+		</tr>
+	{{else}}
+		<tr><td colspan="5">Nothing has been captured yet.</td></tr>
+	{{end}}
+	</tbody>
+</table>
+{{end}}
+`))
+
+// profilingHandler renders the status of the continuous profiler & its recent captures.
+func (a *HTTPServer) profilingHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := profilingTemplate.Execute(w, a.profilingStatus()); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// profilingJSONHandler returns the status of the continuous profiler & its recent captures.
+func (a *HTTPServer) profilingJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.profilingStatus())
+	w.Write(b)
+}
+
+// continuousProfileHandler downloads the retained profile whose sequence number follows /admin/profiling/.
+func (a *HTTPServer) continuousProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var c *continuousCapture
+	if p := a.getProfiler(); p != nil {
+		c = p.get(strings.TrimPrefix(r.URL.Path, "/admin/profiling/"))
+	}
+	if c == nil || c.Error != "" {
+		http.Error(w, "No such profile; only the most recent are retained", http.StatusNotFound)
+		return
+	}
+	writeContentType(w, "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, CapturedProfile{Kind: c.Kind, Time: c.Time}.filename()))
+	w.Write(c.data)
+}
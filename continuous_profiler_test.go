@@ -0,0 +1,195 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestNewContinuousProfiler(t *testing.T) {
+	if p, err := newContinuousProfiler(Opts{}); p != nil || err != nil {
+		t.Errorf("profiler created when disabled: %v, %v", p, err)
+	}
+	p, err := newContinuousProfiler(Opts{ContinuousProfiling: true, ContinuousProfilingDir: "/tmp/profiles", ContinuousProfilingURL: "http://localhost/profiles"})
+	if err != nil {
+		t.Fatal(err)
+	} else if p.interval != defaultContinuousProfilingInterval || p.cpuDuration != defaultProfileCPUDuration || p.retain != defaultContinuousProfilingRetain ||
+		len(p.kinds) != len(defaultContinuousProfilingKinds) || len(p.exporters) != 2 {
+		t.Errorf("unexpected profiler %+v", p)
+	}
+	for _, opts := range []Opts{
+		{ContinuousProfiling: true, ContinuousProfilingInterval: time.Second, ContinuousProfilingCPUDuration: time.Second},
+		{ContinuousProfiling: true, ContinuousProfilingKinds: []string{"heap", "nonsense"}},
+		{ContinuousProfiling: true, ContinuousProfilingURL: "http://%zz"},
+	} {
+		if _, err := newContinuousProfiler(opts); err == nil {
+			t.Errorf("%+v accepted", opts)
+		}
+	}
+}
+
+func TestContinuousProfiler(t *testing.T) {
+	var exported []CapturedProfile
+	p, err := newContinuousProfiler(Opts{
+		ContinuousProfiling:            true,
+		ContinuousProfilingInterval:    time.Second,
+		ContinuousProfilingCPUDuration: 50 * time.Millisecond,
+		ContinuousProfilingKinds:       []string{"cpu", "heap", "goroutine"},
+		ContinuousProfilingRetain:      4,
+		ContinuousProfilingLabels:      map[string]string{"service": "test"},
+		ProfileExporters: []ProfileExporter{
+			ProfileExporterFunc(func(ctx context.Context, profile CapturedProfile) error {
+				exported = append(exported, profile)
+				return nil
+			}),
+			ProfileExporterFunc(func(ctx context.Context, profile CapturedProfile) error {
+				if profile.Kind == "heap" {
+					return fmt.Errorf("heap rejected")
+				}
+				return nil
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.captureAll(context.Background())
+	if len(exported) != 3 {
+		t.Fatalf("exported %d profiles", len(exported))
+	}
+	for i, kind := range []string{"cpu", "heap", "goroutine"} {
+		if e := exported[i]; e.Kind != kind || e.Labels["service"] != "test" {
+			t.Errorf("unexpected profile %+v", e)
+		} else if _, err := profile.ParseData(e.Data); err != nil {
+			t.Errorf("%s profile doesn't parse: %s", kind, err)
+		}
+	}
+	if exported[0].Duration != 50*time.Millisecond || exported[1].Duration != 0 {
+		t.Errorf("unexpected durations %s, %s", exported[0].Duration, exported[1].Duration)
+	}
+
+	// Only the most recent are retained.
+	p.captureAll(context.Background())
+	history := p.history()
+	if len(history) != 4 || history[0].Seq != 6 || history[0].Kind != "goroutine" || history[3].Seq != 3 {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if heap := history[1]; heap.Kind != "heap" || !heap.failed() || heap.Exports[1].Error != "heap rejected" || heap.Exports[0].Error != "" {
+		t.Errorf("unexpected heap capture %+v", heap)
+	}
+
+	s := &HTTPServer{profiler: p}
+	var status profilingStatus
+	if err := json.Unmarshal(get(t, s, "/admin/profiling.json", false).Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	} else if !status.Enabled || status.Failures != 1 || len(status.Captures) != 4 || len(status.Exporters) != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	body := get(t, s, "/admin/profiling", true).Body.String()
+	if !strings.Contains(body, "1 of the captures below failed") || !strings.Contains(body, `title="heap rejected"`) ||
+		!strings.Contains(body, "service=test") {
+		t.Errorf("unexpected page %s", body)
+	}
+	w := get(t, s, "/admin/profiling/"+strconv.Itoa(history[0].Seq), false)
+	if _, err := profile.Parse(w.Body); err != nil {
+		t.Errorf("downloaded profile doesn't parse: %s", err)
+	}
+	if w := get(t, s, "/admin/profiling/1", false); w.Code != http.StatusNotFound {
+		t.Errorf("discarded profile: unexpected %d", w.Code)
+	}
+}
+
+func TestContinuousProfilingDisabled(t *testing.T) {
+	s := &HTTPServer{}
+	if body := get(t, s, "/admin/profiling", true).Body.String(); !strings.Contains(body, "isn't enabled") {
+		t.Errorf("unexpected page %s", body)
+	}
+	if w := get(t, s, "/admin/profiling/1", false); w.Code != http.StatusNotFound {
+		t.Errorf("unexpected %d", w.Code)
+	}
+}
+
+func TestContinuousProfileFailures(t *testing.T) {
+	p, _ := newContinuousProfiler(Opts{ContinuousProfiling: true, ContinuousProfilingKinds: []string{"cpu"}, ContinuousProfilingCPUDuration: 10 * time.Millisecond})
+	p.limits, _ = newDebugLimiters(Opts{})
+	// CPU profiles can't be captured while another is in progress, which shares the profiler's limits.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		captureProfile(ctx, p.limits, "cpu", 30*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	c := p.capture(context.Background(), "cpu")
+	cancel()
+	<-done
+	if !strings.Contains(c.Error, "cpu is limited") || !c.failed() || c.Size != 0 {
+		t.Errorf("unexpected capture %+v", c)
+	}
+}
+
+func TestDirectoryExporter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	e := DirectoryExporter{Dir: dir}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := e.Export(context.Background(), CapturedProfile{Kind: "heap", Time: now, Data: []byte("profile")}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "heap-20200102T030405.000Z.pb.gz")); err != nil || string(b) != "profile" {
+		t.Errorf("unexpected file %q, %v", b, err)
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	e := HTTPExporter{URL: strings.Replace(server.URL, "://", "://user:password@", 1) + "/ingest?app=test&token=secret", Header: http.Header{"Authorization": {"Bearer secret"}}}
+	profile := CapturedProfile{
+		Kind:     "cpu",
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration: 10 * time.Second,
+		Labels:   map[string]string{"service": "foo"},
+		Data:     []byte("profile"),
+	}
+	if err := e.Export(context.Background(), profile); err != nil {
+		t.Fatal(err)
+	}
+	q := received.URL.Query()
+	if received.Method != http.MethodPost || received.URL.Path != "/ingest" || q.Get("app") != "test" || q.Get("kind") != "cpu" ||
+		q.Get("time") != "2020-01-02T03:04:05Z" || q.Get("duration") != "10" || q.Get("label.service") != "foo" ||
+		received.Header.Get("Authorization") != "Bearer secret" || string(body) != "profile" {
+		t.Errorf("unexpected request %s %s %v: %q", received.Method, received.URL, received.Header, body)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := e.Export(context.Background(), profile); err == nil || !strings.Contains(err.Error(), "503") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("unexpected error %v", err)
+	}
+	// Credentials in the URL aren't shown on the status page or in metrics.
+	if name := exporterName(e); name != "HTTP "+server.URL+"/ingest" {
+		t.Errorf("unexpected name %s", name)
+	}
+	server.Close()
+	if err := e.Export(context.Background(), profile); err == nil || strings.Contains(err.Error(), "secret") || strings.Contains(err.Error(), "password") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
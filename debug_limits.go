@@ -0,0 +1,500 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultDebugMaxDuration is the longest an expensive debug endpoint can run for if Opts.DebugMaxDuration isn't set.
+const defaultDebugMaxDuration = 60 * time.Second
+
+// The process-wide resources limited by the Debug options, since they can only be used by one caller at a time, can
+// be used for as long as they're asked to & are costly while they are.
+const (
+	cpuProfilerResource = "cpu"
+	tracerResource      = "trace"
+)
+
+// debugResourceUsers describe what uses each of the limited resources.
+var debugResourceUsers = map[string]string{
+	cpuProfilerResource: "CPU profiles via /debug/pprof/profile, /admin/profiles & continuous profiling",
+	tracerResource:      "execution traces via /debug/pprof/trace & /admin/traces",
+}
+
+// limitedDebugRoutes are the built-in routes that use the limited resources directly.
+var limitedDebugRoutes = map[string]string{
+	"/debug/pprof/profile": cpuProfilerResource,
+	"/debug/pprof/trace":   tracerResource,
+}
+
+// A DebugLimit restricts how the CPU profiler, the tracer or an expensive debug endpoint can be used.
+type DebugLimit struct {
+	// MaxConcurrent is the number of requests that can run at once. Defaults to 1.
+	MaxConcurrent int
+	// MaxDuration is the longest a request can run for. Requests whose seconds parameter exceeds it are rejected, and
+	// any still running after it are cancelled. Defaults to a minute.
+	MaxDuration time.Duration
+	// Cooldown is how long after a request finishes before another can start.
+	Cooldown time.Duration
+	// QueueTimeout is how long a request waits for its turn before it's rejected. If 0, it's rejected immediately.
+	QueueTimeout time.Duration
+}
+
+// withDefaults returns the limit with any unset fields defaulted.
+func (l DebugLimit) withDefaults() DebugLimit {
+	if l.MaxConcurrent <= 0 {
+		l.MaxConcurrent = 1
+	}
+	if l.MaxDuration <= 0 {
+		l.MaxDuration = defaultDebugMaxDuration
+	}
+	return l
+}
+
+// A debugRun is a use in progress of a limited resource or route.
+type debugRun struct {
+	ID       int    `json:"id"`
+	Resource string `json:"resource"`
+	Actor    string `json:"actor,omitempty"`
+	// Source is the remote address of the request using the resource, or what's using it in the background.
+	Source    string        `json:"source"`
+	Started   time.Time     `json:"started"`
+	Duration  time.Duration `json:"duration"`
+	Cancelled bool          `json:"cancelled"`
+	cancel    context.CancelFunc
+}
+
+type debugSourceKey struct{}
+
+// withDebugSource records what's about to use a limited resource, for display on /admin/debug/limits.
+func withDebugSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, debugSourceKey{}, source)
+}
+
+// A debugLimitError is returned when a limited resource can't be used in time.
+type debugLimitError struct {
+	resource string
+	limit    DebugLimit
+	wait     time.Duration
+}
+
+func (e *debugLimitError) Error() string {
+	return fmt.Sprintf("%s is limited to %d at a time, with %s between runs; retry in %ds", e.resource, e.limit.MaxConcurrent, e.limit.Cooldown, e.retryAfter())
+}
+
+// retryAfter is the number of seconds after which the resource might be available.
+func (e *debugLimitError) retryAfter() int {
+	return int(math.Ceil(e.wait.Seconds()))
+}
+
+// writeDebugLimitError responds with a 429 and a Retry-After header if err is a debugLimitError, returning whether
+// it did.
+func writeDebugLimitError(w http.ResponseWriter, err error) bool {
+	e, ok := err.(*debugLimitError)
+	if ok {
+		w.Header().Set("Retry-After", strconv.Itoa(e.retryAfter()))
+		http.Error(w, e.Error(), http.StatusTooManyRequests)
+	}
+	return ok
+}
+
+// debugLimiters enforce the limits on the resources & routes that have them, keyed by resource name or route path.
+type debugLimiters struct {
+	mutex    sync.Mutex
+	limits   map[string]DebugLimit
+	runs     map[string][]*debugRun
+	lastEnd  map[string]time.Time
+	waiting  map[string]int
+	rejected map[string]int
+	changed  chan struct{}
+	nextID   int
+}
+
+// newDebugLimiters returns limiters for the CPU profiler & tracer according to the Debug options, and for any routes
+// given in Opts.DebugLimits. Limits given there for /debug/pprof/profile & /debug/pprof/trace apply to the resources
+// they use.
+func newDebugLimiters(opts Opts) (*debugLimiters, error) {
+	l := &debugLimiters{
+		limits:   map[string]DebugLimit{},
+		runs:     map[string][]*debugRun{},
+		lastEnd:  map[string]time.Time{},
+		waiting:  map[string]int{},
+		rejected: map[string]int{},
+		changed:  make(chan struct{}),
+	}
+	for resource := range debugResourceUsers {
+		l.limits[resource] = DebugLimit{
+			MaxConcurrent: opts.DebugMaxConcurrent,
+			MaxDuration:   opts.DebugMaxDuration,
+			Cooldown:      opts.DebugCooldown,
+			QueueTimeout:  opts.DebugQueueTimeout,
+		}.withDefaults()
+	}
+	for path, limit := range opts.DebugLimits {
+		if limit.MaxConcurrent < 0 || limit.MaxDuration < 0 || limit.Cooldown < 0 || limit.QueueTimeout < 0 {
+			return nil, fmt.Errorf("invalid debug limit for %s: limits can't be negative", path)
+		}
+		if resource, present := limitedDebugRoutes[path]; present {
+			path = resource
+		}
+		l.limits[path] = limit.withDefaults()
+	}
+	for _, d := range []struct {
+		name     string
+		duration time.Duration
+	}{{"profile CPU duration", opts.ProfileCPUDuration}, {"continuous profiling CPU duration", opts.ContinuousProfilingCPUDuration}} {
+		if max := l.limits[cpuProfilerResource].MaxDuration; d.duration > max {
+			return nil, fmt.Errorf("%s %s exceeds the debug max duration %s", d.name, d.duration, max)
+		}
+	}
+	return l, nil
+}
+
+// limit returns the limit on the given resource or route, if it has one.
+func (l *debugLimiters) limit(key string) (DebugLimit, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	limit, present := l.limits[key]
+	return limit, present
+}
+
+// maxDuration returns the longest the given resource can be used for: the lesser of max & the maximum of its limit.
+func (l *debugLimiters) maxDuration(resource string, max time.Duration) time.Duration {
+	if limit, present := l.limit(resource); present && limit.MaxDuration < max {
+		return limit.MaxDuration
+	}
+	return max
+}
+
+// do calls fn with the given resource, subject to its limit if it has one, for a use expected to last the given
+// duration. fn's context is cancelled once the limit's maximum duration has passed, or if the run is cancelled via
+// /admin/debug/limits. A *debugLimitError is returned if the resource can't be used in time. A nil *debugLimiters
+// doesn't limit anything.
+func (l *debugLimiters) do(ctx context.Context, resource string, duration time.Duration, fn func(context.Context) error) error {
+	if l == nil {
+		return fn(ctx)
+	}
+	limit, present := l.limit(resource)
+	if !present {
+		return fn(ctx)
+	} else if duration > limit.MaxDuration {
+		return fmt.Errorf("%s can be used for at most %s", resource, limit.MaxDuration)
+	}
+	run, runCtx, wait := l.acquire(ctx, resource, limit, duration)
+	if run == nil {
+		return &debugLimitError{resource: resource, limit: limit, wait: wait}
+	}
+	defer l.release(run)
+	return fn(runCtx)
+}
+
+// getDebugLimiters returns the server's limiters, which use the default limits if they've not been set via Start.
+func (a *HTTPServer) getDebugLimiters() *debugLimiters {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.debugLimitersLocked()
+}
+
+// debugLimitersLocked is getDebugLimiters for callers that already hold the mutex.
+func (a *HTTPServer) debugLimitersLocked() *debugLimiters {
+	if a.debugLimits == nil {
+		a.debugLimits, _ = newDebugLimiters(Opts{})
+	}
+	return a.debugLimits
+}
+
+// wait returns how long a use of the given resource must wait before it can start. The caller must hold the mutex.
+func (l *debugLimiters) wait(resource string, limit DebugLimit, now time.Time) time.Duration {
+	if runs := l.runs[resource]; len(runs) >= limit.MaxConcurrent {
+		soonest := time.Duration(math.MaxInt64)
+		for _, run := range runs {
+			if d := run.Started.Add(run.Duration).Sub(now); d < soonest {
+				soonest = d
+			}
+		}
+		// Runs can overrun their expected duration slightly while they write out their results.
+		if soonest < time.Second {
+			soonest = time.Second
+		}
+		return soonest + limit.Cooldown
+	} else if end := l.lastEnd[resource].Add(limit.Cooldown); end.After(now) {
+		return end.Sub(now)
+	}
+	return 0
+}
+
+// acquire starts a run of the given resource, waiting up to the limit's queue timeout for its turn. If it can't
+// start, it returns nil and how long the caller should wait before retrying.
+func (l *debugLimiters) acquire(ctx context.Context, resource string, limit DebugLimit, duration time.Duration) (*debugRun, context.Context, time.Duration) {
+	deadline := time.Now().Add(limit.QueueTimeout)
+	l.mutex.Lock()
+	l.waiting[resource]++
+	defer func() {
+		l.waiting[resource]--
+		l.mutex.Unlock()
+	}()
+	for {
+		now := time.Now()
+		wait := l.wait(resource, limit, now)
+		if wait == 0 {
+			break
+		} else if !now.Before(deadline) {
+			l.rejected[resource]++
+			return nil, nil, wait
+		}
+		timeout := deadline.Sub(now)
+		if wait < timeout {
+			timeout = wait
+		}
+		changed := l.changed
+		l.mutex.Unlock()
+		timer := time.NewTimer(timeout)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		l.mutex.Lock()
+		if ctx.Err() != nil {
+			return nil, nil, l.wait(resource, limit, time.Now())
+		}
+	}
+	runCtx, cancel := context.WithTimeout(ctx, limit.MaxDuration)
+	l.nextID++
+	run := &debugRun{
+		ID:       l.nextID,
+		Resource: resource,
+		Started:  time.Now(),
+		Duration: duration,
+		cancel:   cancel,
+	}
+	run.Source, _ = ctx.Value(debugSourceKey{}).(string)
+	if p := PrincipalFromContext(ctx); p != nil {
+		run.Actor = p.Name
+	}
+	l.runs[resource] = append(l.runs[resource], run)
+	return run, runCtx, 0
+}
+
+// release ends a run, waking any requests waiting for their turn.
+func (l *debugLimiters) release(run *debugRun) {
+	run.cancel()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	runs := l.runs[run.Resource]
+	for i, r := range runs {
+		if r == run {
+			l.runs[run.Resource] = append(runs[:i:i], runs[i+1:]...)
+			break
+		}
+	}
+	l.lastEnd[run.Resource] = time.Now()
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// cancel cancels the run with the given ID, returning it, or nil if there's no such run in progress.
+func (l *debugLimiters) cancel(id int) *debugRun {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, runs := range l.runs {
+		for _, run := range runs {
+			if run.ID == id {
+				run.Cancelled = true
+				run.cancel()
+				c := *run
+				return &c
+			}
+		}
+	}
+	return nil
+}
+
+// A debugLimitStatus is the state of a limited resource or route.
+type debugLimitStatus struct {
+	Resource      string        `json:"resource"`
+	Users         string        `json:"users,omitempty"`
+	MaxConcurrent int           `json:"max_concurrent"`
+	MaxDuration   time.Duration `json:"max_duration"`
+	Cooldown      time.Duration `json:"cooldown"`
+	QueueTimeout  time.Duration `json:"queue_timeout"`
+	Waiting       int           `json:"waiting"`
+	Rejected      int           `json:"rejected"`
+	LastFinished  time.Time     `json:"last_finished"`
+	Runs          []debugRun    `json:"runs"`
+}
+
+// status returns the state of each limited resource or route, ordered by name.
+func (l *debugLimiters) status() []debugLimitStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	ret := make([]debugLimitStatus, 0, len(l.limits))
+	for key, limit := range l.limits {
+		s := debugLimitStatus{
+			Resource:      key,
+			Users:         debugResourceUsers[key],
+			MaxConcurrent: limit.MaxConcurrent,
+			MaxDuration:   limit.MaxDuration,
+			Cooldown:      limit.Cooldown,
+			QueueTimeout:  limit.QueueTimeout,
+			Waiting:       l.waiting[key],
+			Rejected:      l.rejected[key],
+			LastFinished:  l.lastEnd[key],
+			Runs:          []debugRun{},
+		}
+		for _, run := range l.runs[key] {
+			s.Runs = append(s.Runs, *run)
+		}
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Resource < ret[j].Resource })
+	return ret
+}
+
+// requestedDebugDuration returns how long a request to a limited route is expected to run for: the seconds
+// parameter, which pprof's handlers take, or the limit's maximum if it isn't given.
+func requestedDebugDuration(r *http.Request, limit DebugLimit) (time.Duration, error) {
+	s := r.FormValue("seconds")
+	if s == "" {
+		return limit.MaxDuration, nil
+	}
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("seconds must be a positive number")
+	}
+	duration := time.Duration(seconds * float64(time.Second))
+	if duration > limit.MaxDuration {
+		return 0, fmt.Errorf("seconds must be at most %s", strconv.FormatFloat(limit.MaxDuration.Seconds(), 'f', -1, 64))
+	}
+	return duration, nil
+}
+
+// limitDebug wraps a route's handler so that requests to it are subject to the limit on it, or on the resource it
+// uses. Requests that can't start in time are rejected with a 429, and a Retry-After header saying when they might
+// succeed.
+func (a *HTTPServer) limitDebug(path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := a.getDebugLimiters()
+		key := path
+		if resource, present := limitedDebugRoutes[path]; present {
+			key = resource
+		}
+		limit, present := l.limit(key)
+		if !present {
+			next.ServeHTTP(w, r)
+			return
+		}
+		duration, err := requestedDebugDuration(r, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = l.do(withDebugSource(r.Context(), r.RemoteAddr), key, duration, func(ctx context.Context) error {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		writeDebugLimitError(w, err)
+	})
+}
+
+var debugLimitsTemplate = template.Must(template.New("debug-limits").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"since": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+}).Parse(`
+<table class="table table-sm">
+	<thead><tr><th>resource</th><th>concurrency</th><th>max duration</th><th>cooldown</th><th>queue timeout</th><th>running</th><th>waiting</th><th>rejected</th><th>last finished</th></tr></thead>
+	<tbody>
+	{{range .Limits}}
+		<tr>
+			<td>{{.Resource}}{{with .Users}}<br/><small class="text-muted">{{.}}</small>{{end}}</td>
+			<td>{{.MaxConcurrent}}</td>
+			<td>{{.MaxDuration}}</td>
+			<td>{{if .Cooldown}}{{.Cooldown}}{{else}}none{{end}}</td>
+			<td>{{if .QueueTimeout}}{{.QueueTimeout}}{{else}}none{{end}}</td>
+			<td>{{len .Runs}}</td>
+			<td>{{.Waiting}}</td>
+			<td>{{.Rejected}}</td>
+			<td>{{if .LastFinished.IsZero}}never{{else}}{{time .LastFinished}}{{end}}</td>
+		</tr>
+	{{end}}
+	</tbody>
+</table>
+<h5>In progress</h5>
+<table class="table table-sm">
+	<thead><tr><th>id</th><th>resource</th><th>actor</th><th>source</th><th>started</th><th>running for</th><th>expected</th><th></th></tr></thead>
+	<tbody>
+	{{range .Limits}}{{range .Runs}}
+		<tr>
+			<td>{{.ID}}</td>
+			<td>{{.Resource}}</td>
+			<td>{{if .Actor}}{{.Actor}}{{else}}<em>anonymous</em>{{end}}</td>
+			<td>{{.Source}}</td>
+			<td>{{time .Started}}</td>
+			<td>{{since .Started}}</td>
+			<td>{{.Duration}}</td>
+			<td>{{if .Cancelled}}cancelling{{else}}<form method="POST" action="/admin/debug/limits/cancel" class="form-inline">{{$.CSRF}}<input type="hidden" name="id" value="{{.ID}}"/><input type="submit" class="btn btn-sm btn-danger" value="Cancel"/></form>{{end}}</td>
+		</tr>
+	{{end}}{{end}}
+	{{if not .Running}}<tr><td colspan="8">Nothing is running.</td></tr>{{end}}
+	</tbody>
+</table>
+`))
+
+type debugLimitsView struct {
+	Limits  []debugLimitStatus
+	Running int
+	CSRF    template.HTML
+}
+
+// debugLimitsHandler renders the limits on the CPU profiler, the tracer & expensive routes, and their uses in progress.
+func (a *HTTPServer) debugLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	view := debugLimitsView{Limits: a.getDebugLimiters().status(), CSRF: CSRFField(r)}
+	for _, s := range view.Limits {
+		view.Running += len(s.Runs)
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := debugLimitsTemplate.Execute(w, view); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// debugLimitsJSONHandler returns the limits on the CPU profiler, the tracer & expensive routes, and their uses in progress.
+func (a *HTTPServer) debugLimitsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.getDebugLimiters().status())
+	w.Write(b)
+}
+
+// cancelDebugRunHandler cancels the in-progress request given by the id form value. Profiles & traces that are
+// cancelled finish early, returning what they've captured so far.
+func (a *HTTPServer) cancelDebugRunHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "id must be an integer", http.StatusBadRequest)
+		return
+	}
+	run := a.getDebugLimiters().cancel(id)
+	if run == nil {
+		http.Error(w, fmt.Sprintf("No request %d is in progress", id), http.StatusNotFound)
+		return
+	}
+	AnnotateAudit(r, fmt.Sprintf("cancelled %s started by %s at %s", run.Resource, run.Source, run.Started.Format("15:04:05")))
+	if expectsHTML(r) {
+		http.Redirect(w, r, "/admin/debug/limits", http.StatusSeeOther)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(run)
+	w.Write(b)
+}
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowHandler blocks until the request is cancelled or release is closed, signalling started when it begins.
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+			w.Write([]byte("cancelled"))
+		case <-release:
+			w.Write([]byte("done"))
+		}
+	})
+}
+
+// limitedTestServer returns a server with /admin/slow limited as given.
+func limitedTestServer(t *testing.T, limit DebugLimit, started chan<- struct{}, release <-chan struct{}) *HTTPServer {
+	t.Helper()
+	s := &HTTPServer{}
+	s.debugLimits, _ = newDebugLimiters(Opts{DebugLimits: map[string]DebugLimit{"/admin/slow": limit}})
+	if err := s.Handle("/admin/slow", slowHandler(started, release), RouteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serveAsync serves a request for the given path in the background, sending the response when it's complete.
+func serveAsync(s http.Handler, path string) <-chan *httptest.ResponseRecorder {
+	ch := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		ch <- w
+	}()
+	return ch
+}
+
+func debugLimitStatusFor(t *testing.T, s http.Handler, path string) debugLimitStatus {
+	t.Helper()
+	var status []debugLimitStatus
+	if err := json.Unmarshal(get(t, s, "/admin/debug/limits.json", false).Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range status {
+		if st.Resource == path {
+			return st
+		}
+	}
+	t.Fatalf("no status for %s in %+v", path, status)
+	return debugLimitStatus{}
+}
+
+func TestDebugLimitConcurrency(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	s := limitedTestServer(t, DebugLimit{MaxConcurrent: 1, Cooldown: time.Minute}, started, release)
+
+	first := serveAsync(s, "/admin/slow?seconds=30")
+	<-started
+	w := get(t, s, "/admin/slow", false)
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || retry < 25 {
+		t.Errorf("unexpected %d, Retry-After %s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	st := debugLimitStatusFor(t, s, "/admin/slow")
+	if len(st.Runs) != 1 || st.Rejected != 1 || st.Runs[0].Duration != 30*time.Second {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	if body := get(t, s, "/admin/debug/limits", true).Body.String(); !strings.Contains(body, `name="id" value="`+strconv.Itoa(st.Runs[0].ID)+`"`) {
+		t.Errorf("unexpected page %s", body)
+	}
+	w = post(s, "/admin/debug/limits/cancel", url.Values{"id": {strconv.Itoa(st.Runs[0].ID)}, csrfField: {"x"}}, "x", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected %d: %s", w.Code, w.Body.String())
+	}
+	if w := <-first; w.Body.String() != "cancelled" {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+
+	// Another can't start until the cooldown has passed.
+	w = get(t, s, "/admin/slow", false)
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || retry < 55 || retry > 60 {
+		t.Errorf("unexpected %d, Retry-After %s", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := post(s, "/admin/debug/limits/cancel", url.Values{"id": {"999"}, csrfField: {"x"}}, "x", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unexpected %d", w.Code)
+	}
+}
+
+func TestDebugLimitQueue(t *testing.T) {
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	s := limitedTestServer(t, DebugLimit{MaxConcurrent: 1, QueueTimeout: 10 * time.Second}, started, release)
+
+	first := serveAsync(s, "/admin/slow")
+	<-started
+	second := serveAsync(s, "/admin/slow")
+	for deadline := time.Now().Add(5 * time.Second); debugLimitStatusFor(t, s, "/admin/slow").Waiting != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("second request isn't waiting")
+		}
+	}
+	close(release)
+	if w := <-first; w.Body.String() != "done" {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+	if w := <-second; w.Code != http.StatusOK || w.Body.String() != "done" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if st := debugLimitStatusFor(t, s, "/admin/slow"); st.Rejected != 0 || st.Waiting != 0 || len(st.Runs) != 0 || st.LastFinished.IsZero() {
+		t.Errorf("unexpected status %+v", st)
+	}
+}
+
+func TestDebugLimitDuration(t *testing.T) {
+	started := make(chan struct{}, 1)
+	s := limitedTestServer(t, DebugLimit{MaxDuration: 50 * time.Millisecond}, started, nil)
+	start := time.Now()
+	if w := get(t, s, "/admin/slow", false); w.Body.String() != "cancelled" || time.Since(start) > 5*time.Second {
+		t.Errorf("request wasn't cancelled after its maximum duration: %s", w.Body.String())
+	}
+	for _, path := range []string{"/admin/slow?seconds=1", "/admin/slow?seconds=nonsense", "/debug/pprof/profile?seconds=61"} {
+		if w := get(t, s, path, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: unexpected %d", path, w.Code)
+		}
+	}
+	if _, err := newDebugLimiters(Opts{DebugLimits: map[string]DebugLimit{"/admin/slow": {Cooldown: -time.Second}}}); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
+
+func TestDebugLimitSharedResources(t *testing.T) {
+	s := &HTTPServer{}
+	for _, c := range []struct{ resource, running, capture string }{
+		{"cpu", "/debug/pprof/profile?seconds=30", "/admin/profiles"},
+		{"trace", "/debug/pprof/trace?seconds=30", "/admin/traces"},
+	} {
+		running := serveAsync(s, c.running)
+		var st debugLimitStatus
+		for deadline := time.Now().Add(5 * time.Second); len(st.Runs) == 0; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s isn't running", c.running)
+			}
+			st = debugLimitStatusFor(t, s, c.resource)
+		}
+		if st.Runs[0].Source != "192.0.2.1:1234" {
+			t.Errorf("unexpected run %+v", st.Runs[0])
+		}
+		// Captures via the admin server share the limit with pprof's routes.
+		w := post(s, c.capture, url.Values{"kind": {"cpu"}, "seconds": {"1"}, csrfField: {"x"}}, "x", "")
+		if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || retry < 25 {
+			t.Errorf("%s: unexpected %d, Retry-After %s", c.capture, w.Code, w.Header().Get("Retry-After"))
+		}
+		if w := post(s, "/admin/debug/limits/cancel", url.Values{"id": {strconv.Itoa(st.Runs[0].ID)}, csrfField: {"x"}}, "x", ""); w.Code != http.StatusOK {
+			t.Fatalf("unexpected %d: %s", w.Code, w.Body.String())
+		}
+		<-running
+	}
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultQuitTimeout is used when Opts.QuitTimeout isn't set.
+const defaultQuitTimeout = 30 * time.Second
+
+// A ShutdownHook is notified when the process is drained, and run when it quits via /quitquitquit.
+type ShutdownHook struct {
+	// Name identifies the hook in logs.
+	Name string
+	// OnDrain, if set, is called once when the process enters drain mode, e.g. to stop consuming from a queue.
+	OnDrain func()
+	// OnQuit, if set, is called when the process quits. Hooks are run in the order they were registered,
+	// and should return promptly once the context is done: the process exits when it is, whether or not they have.
+	OnQuit func(ctx context.Context) error
+}
+
+var shutdownHooks = struct {
+	sync.Mutex
+	hooks    []ShutdownHook
+	draining bool
+}{}
+
+// exit is os.Exit, replaced in tests.
+var exit = os.Exit
+
+// RegisterShutdownHook registers a hook to be notified on drain and run on quit.
+// If the process is already draining, its OnDrain function is called immediately.
+func RegisterShutdownHook(hook ShutdownHook) error {
+	if hook.Name == "" {
+		return fmt.Errorf("shutdown hooks must have a name")
+	}
+	shutdownHooks.Lock()
+	shutdownHooks.hooks = append(shutdownHooks.hooks, hook)
+	draining := shutdownHooks.draining
+	shutdownHooks.Unlock()
+	if draining && hook.OnDrain != nil {
+		hook.OnDrain()
+	}
+	return nil
+}
+
+// Drain puts the process into drain (lame duck) mode: the readiness probe fails from then on, so the
+// instance is taken out of rotation, and all shutdown hooks are notified. It's a no-op if already draining.
+func Drain() {
+	shutdownHooks.Lock()
+	if shutdownHooks.draining {
+		shutdownHooks.Unlock()
+		return
+	}
+	shutdownHooks.draining = true
+	hooks := append([]ShutdownHook{}, shutdownHooks.hooks...)
+	shutdownHooks.Unlock()
+
+	log.Warningf("Draining")
+	for _, hook := range hooks {
+		if hook.OnDrain != nil {
+			log.Infof("Notifying shutdown hook %s of drain", hook.Name)
+			hook.OnDrain()
+		}
+	}
+}
+
+// Draining returns true if the process is in drain mode.
+func Draining() bool {
+	shutdownHooks.Lock()
+	defer shutdownHooks.Unlock()
+	return shutdownHooks.draining
+}
+
+// quitting ensures the process only quits once, however many times /quitquitquit is requested.
+var quitting sync.Once
+
+// quit drains the process, runs all shutdown hooks in order with the given deadline, then exits. It exits with
+// code 1 as soon as the deadline passes, even if a hook is still running.
+func quit(timeout time.Duration) {
+	Drain()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shutdownHooks.Lock()
+	hooks := append([]ShutdownHook{}, shutdownHooks.hooks...)
+	shutdownHooks.Unlock()
+
+	done := make(chan int, 1)
+	go func() {
+		code := 0
+		for _, hook := range hooks {
+			if hook.OnQuit == nil || ctx.Err() != nil {
+				continue
+			}
+			log.Infof("Running shutdown hook %s", hook.Name)
+			if err := hook.OnQuit(ctx); err != nil {
+				log.Errorf("Shutdown hook %s failed: %s", hook.Name, err)
+				code = 1
+			}
+		}
+		done <- code
+	}()
+	code := 1
+	select {
+	case code = <-done:
+	case <-ctx.Done():
+		log.Errorf("Quit deadline of %s exceeded while running shutdown hooks", timeout)
+	}
+	log.Warningf("Quitting")
+	exit(code)
+}
+
+// drainPageHandler renders the drain, quit & abort actions.
+func drainPageHandler(action, description string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeContentType(w, "text/html;charset=UTF-8")
+		if Draining() {
+			w.Write([]byte(`<div class="alert alert-warning" role="alert">This process is draining.</div>`))
+		}
+		fmt.Fprintf(w, `<p>%s</p><form action="%s" method="POST">%s<input type="submit" class="btn btn-danger" value="%s"/></form>`,
+			description, r.URL.Path, CSRFField(r), action)
+	}
+}
+
+func drainHandler(w http.ResponseWriter, r *http.Request) {
+	AnnotateAudit(r, "drained")
+	Drain()
+	w.Write([]byte("Draining"))
+}
+
+// quitHandler starts quitting once the request has been audited and responded to, since the process exits once
+// the hooks have run.
+func (a *HTTPServer) quitHandler(w http.ResponseWriter, r *http.Request) {
+	a.mutex.RLock()
+	timeout := a.quitTimeout
+	a.mutex.RUnlock()
+	if timeout == 0 {
+		timeout = defaultQuitTimeout
+	}
+	first := false
+	quitting.Do(func() { first = true })
+	if !first {
+		AnnotateAudit(r, "already quitting")
+		w.Write([]byte("Quitting"))
+		return
+	}
+	AnnotateAudit(r, "quit")
+	w.Write([]byte("Quitting"))
+	afterAudit(r, func() { go quit(timeout) })
+}
+
+// abortHandler exits immediately without running the shutdown hooks, once the request has been audited.
+func abortHandler(w http.ResponseWriter, r *http.Request) {
+	actor := "anonymous"
+	if p := PrincipalFromContext(r.Context()); p != nil {
+		actor = p.Name
+	}
+	log.Errorf("Aborting on request from %s at %s", actor, r.RemoteAddr)
+	AnnotateAudit(r, "aborted")
+	w.Write([]byte("Aborting"))
+	afterAudit(r, func() { go exit(1) })
+}
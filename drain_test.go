@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeExit replaces exit for the duration of a test, returning a channel that receives the exit code.
+func fakeExit(t *testing.T) chan int {
+	codes := make(chan int, 1)
+	exit = func(code int) { codes <- code }
+	t.Cleanup(func() {
+		exit = os.Exit
+		shutdownHooks.Lock()
+		shutdownHooks.hooks = nil
+		shutdownHooks.draining = false
+		shutdownHooks.Unlock()
+		quitting = sync.Once{}
+	})
+	return codes
+}
+
+func TestDrain(t *testing.T) {
+	fakeExit(t)
+	drained := make(chan struct{}, 2)
+	RegisterShutdownHook(ShutdownHook{Name: "queue", OnDrain: func() { drained <- struct{}{} }})
+	s := &HTTPServer{}
+
+	if code, _ := probe(t, s, "/admin/health/ready"); code != http.StatusOK {
+		t.Fatalf("ready before drain: unexpected %d", code)
+	}
+	if w := get(t, s, "/admin/drain", true); !strings.Contains(w.Body.String(), `action="/admin/drain"`) {
+		t.Errorf("drain page has no form: %s", w.Body.String())
+	}
+	for i := 0; i < 2; i++ {
+		if w := post(s, "/admin/drain", nil, "token", "token"); w.Code != http.StatusOK {
+			t.Fatalf("drain: unexpected %d %s", w.Code, w.Body.String())
+		}
+	}
+	if len(drained) != 1 {
+		t.Errorf("hook should be notified exactly once, was notified %d times", len(drained))
+	}
+	code, result := probe(t, s, "/admin/health/ready")
+	if code != http.StatusServiceUnavailable || result.Checks[len(result.Checks)-1].Error != "draining" {
+		t.Errorf("ready after drain: unexpected %d %+v", code, result)
+	}
+	if events := s.auditLog().Events(); len(events) != 2 || events[0].Detail != "drained" {
+		t.Errorf("unexpected audit events %+v", events)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	codes := fakeExit(t)
+	var mutex sync.Mutex
+	var order []string
+	run := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		order = append(order, name)
+	}
+	stuck := make(chan struct{})
+	defer close(stuck)
+	RegisterShutdownHook(ShutdownHook{Name: "first", OnQuit: func(ctx context.Context) error {
+		run("first")
+		return nil
+	}})
+	RegisterShutdownHook(ShutdownHook{Name: "stuck", OnQuit: func(ctx context.Context) error {
+		run("stuck")
+		<-stuck // Ignores the deadline.
+		return nil
+	}})
+	RegisterShutdownHook(ShutdownHook{Name: "skipped", OnQuit: func(ctx context.Context) error {
+		run("skipped")
+		return nil
+	}})
+	s := &HTTPServer{}
+	s.quitTimeout = 10 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		if w := post(s, "/quitquitquit", nil, "token", "token"); w.Code != http.StatusOK || w.Body.String() != "Quitting" {
+			t.Fatalf("quit: unexpected %d %s", w.Code, w.Body.String())
+		}
+	}
+	select {
+	case code := <-codes:
+		if code != 1 {
+			t.Errorf("expected exit code 1 after the deadline passed, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process didn't exit")
+	}
+	select {
+	case code := <-codes:
+		t.Errorf("quit twice, exiting %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	mutex.Lock()
+	if fmt.Sprint(order) != "[first stuck]" {
+		t.Errorf("unexpected hook order %v", order)
+	}
+	mutex.Unlock()
+	if !Draining() {
+		t.Error("quitting should drain first")
+	}
+	if events := s.auditLog().Events(); len(events) != 2 || events[0].Detail != "already quitting" || events[1].Detail != "quit" {
+		t.Errorf("unexpected audit events %+v", events)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	codes := fakeExit(t)
+	ran := false
+	RegisterShutdownHook(ShutdownHook{Name: "hook", OnQuit: func(ctx context.Context) error {
+		ran = true
+		return nil
+	}})
+	s := &HTTPServer{}
+	if w := post(s, "/abortabortabort", nil, "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("abort without CSRF token: unexpected %d", w.Code)
+	}
+	if w := post(s, "/abortabortabort", nil, "token", "token"); w.Body.String() != "Aborting" {
+		t.Errorf("abort: unexpected %d %s", w.Code, w.Body.String())
+	}
+	if code := <-codes; code != 1 || ran {
+		t.Errorf("abort should exit 1 without running hooks; exited %d, ran hooks: %v", code, ran)
+	}
+	if events := s.auditLog().Events(); len(events) != 2 || events[0].Detail != "aborted" {
+		t.Errorf("abort should be audited before exiting: %+v", events)
+	}
+}
+
+func TestDrainNav(t *testing.T) {
+	w := get(t, &HTTPServer{}, "/admin", true)
+	for _, path := range []string{"/admin/drain", "/quitquitquit", "/abortabortabort"} {
+		if !strings.Contains(w.Body.String(), `href="`+path+`"`) {
+			t.Errorf("nav missing %s", path)
+		}
+	}
+}
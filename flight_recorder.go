@@ -0,0 +1,415 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/trace"
+)
+
+// Defaults for the flight recorder when the corresponding Opts aren't set.
+const (
+	defaultFlightRecorderPeriod   = 10 * time.Second
+	defaultFlightRecorderSize     = 10 << 20
+	defaultFlightRecorderInterval = 5 * time.Second
+	defaultFlightRecorderCooldown = time.Minute
+)
+
+// errFlightRecorderDisabled is returned by snapshots when the flight recorder isn't running.
+var errFlightRecorderDisabled = errors.New("the flight recorder isn't running; set --flight_recorder or Opts.FlightRecorder to enable it")
+
+// A flightRecorderTrigger snapshots the flight recorder when the sum of the series matched by its selector
+// rises above, or falls below, its threshold.
+type flightRecorderTrigger struct {
+	Expr string `json:"expr"`
+	// Value is the sum when the trigger was last checked, or nil if it hasn't been yet.
+	Value     *float64 `json:"value"`
+	Triggered bool     `json:"triggered"`
+	selector  metricSelector
+	op        string
+	threshold float64
+}
+
+// flightRecorderTriggerOps are the comparisons a trigger may use, longest first so that >= isn't parsed as >.
+var flightRecorderTriggerOps = []string{">=", "<=", ">", "<"}
+
+// parseFlightRecorderTrigger parses a trigger of the form selector op value, where op is one of >, >=, < or <=.
+// The selector is parsed first, so its label values may contain < and >.
+func parseFlightRecorderTrigger(s string) (*flightRecorderTrigger, error) {
+	sel, rest, err := parseSelectorPrefix(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flight recorder trigger %q: %s", s, err)
+	}
+	t := &flightRecorderTrigger{Expr: s, selector: sel}
+	for _, op := range flightRecorderTriggerOps {
+		if strings.HasPrefix(rest, op) {
+			t.op, rest = op, strings.TrimSpace(rest[len(op):])
+			break
+		}
+	}
+	if t.op == "" {
+		return nil, fmt.Errorf("invalid flight recorder trigger %q: expected a selector, then one of >, >=, < or <=, then a value", s)
+	}
+	if t.threshold, err = strconv.ParseFloat(rest, 64); err != nil {
+		return nil, fmt.Errorf("invalid flight recorder trigger %q: %q is not a number", s, rest)
+	}
+	return t, nil
+}
+
+// crossed returns true if the value has crossed the trigger's threshold.
+func (t *flightRecorderTrigger) crossed(value float64) bool {
+	switch t.op {
+	case ">=":
+		return value >= t.threshold
+	case "<":
+		return value < t.threshold
+	case "<=":
+		return value <= t.threshold
+	default: // ">"
+		return value > t.threshold
+	}
+}
+
+// A flightRecorder keeps the most recent execution trace data in memory, from which it snapshots traces into a
+// traceStore on demand or when one of its triggers fires.
+type flightRecorder struct {
+	period   time.Duration
+	size     int
+	interval time.Duration
+	cooldown time.Duration
+	traces   *traceStore
+
+	// snapshotting is held while the recorder is written out or stopped, which mustn't happen concurrently.
+	snapshotting sync.Mutex
+	recorder     *trace.FlightRecorder
+
+	mutex        sync.Mutex
+	triggers     []*flightRecorderTrigger
+	recording    bool
+	started      time.Time
+	err          error
+	lastSnapshot time.Time
+	snapshots    int
+}
+
+// newFlightRecorder returns the flight recorder configured by the given Opts, which snapshots into the given
+// store, or nil if it's not enabled.
+func newFlightRecorder(opts Opts, traces *traceStore) (*flightRecorder, error) {
+	if !opts.FlightRecorder {
+		return nil, nil
+	}
+	r := &flightRecorder{
+		period:   opts.FlightRecorderPeriod,
+		size:     defaultFlightRecorderSize,
+		interval: opts.FlightRecorderInterval,
+		cooldown: opts.FlightRecorderCooldown,
+		traces:   traces,
+	}
+	if r.period <= 0 {
+		r.period = defaultFlightRecorderPeriod
+	}
+	if r.interval <= 0 {
+		r.interval = defaultFlightRecorderInterval
+	}
+	if r.cooldown <= 0 {
+		r.cooldown = defaultFlightRecorderCooldown
+	}
+	if opts.FlightRecorderSize != "" {
+		size, err := parseBytes(opts.FlightRecorderSize)
+		if err != nil || size <= 0 || size > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid flight recorder size %q: must be a number of bytes up to 2GiB", opts.FlightRecorderSize)
+		}
+		r.size = int(size)
+	}
+	for _, s := range opts.FlightRecorderTriggers {
+		t, err := parseFlightRecorderTrigger(s)
+		if err != nil {
+			return nil, err
+		}
+		r.triggers = append(r.triggers, t)
+	}
+	return r, nil
+}
+
+// start starts recording. It fails if something else is already tracing the process.
+func (r *flightRecorder) start() error {
+	r.snapshotting.Lock()
+	defer r.snapshotting.Unlock()
+	r.recorder = trace.NewFlightRecorder()
+	r.recorder.SetPeriod(r.period)
+	r.recorder.SetSize(r.size)
+	err := r.recorder.Start()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.recording, r.err = err == nil, err
+	if err == nil {
+		r.started = time.Now()
+	}
+	return err
+}
+
+// stop stops recording, discarding whatever's been recorded.
+func (r *flightRecorder) stop() {
+	r.snapshotting.Lock()
+	defer r.snapshotting.Unlock()
+	r.mutex.Lock()
+	recording := r.recording
+	r.recording = false
+	r.mutex.Unlock()
+	if recording {
+		if err := r.recorder.Stop(); err != nil {
+			log.Warningf("Flight recorder failed: %s", err)
+		}
+	}
+}
+
+// run records until the context is done, checking the triggers every interval.
+func (r *flightRecorder) run(ctx context.Context) {
+	if err := r.start(); err != nil {
+		log.Errorf("Failed to start flight recorder: %s", err)
+		return
+	}
+	defer r.stop()
+	var ticks <-chan time.Time
+	if len(r.triggers) > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			r.checkTriggers()
+		}
+	}
+}
+
+// checkTriggers gathers metrics and snapshots the recorder when a trigger's threshold is first crossed.
+// No more snapshots are taken for a trigger until its value crosses back again.
+func (r *flightRecorder) checkTriggers() {
+	mfs, err := gather()
+	if err := withoutConflicts(err); err != nil {
+		log.Warningf("Failed to gather metrics for flight recorder triggers: %s", err)
+	}
+	for _, t := range r.triggers {
+		value := 0.0
+		for _, s := range selectSeries(mfs, []metricSelector{t.selector}) {
+			value += s.value()
+		}
+		crossed := t.crossed(value)
+		r.mutex.Lock()
+		fire := crossed && !t.Triggered
+		t.Value, t.Triggered = &value, crossed
+		r.mutex.Unlock()
+		if fire {
+			if _, err := r.snapshot(fmt.Sprintf("%s (%g)", t.Expr, value), true); err != nil {
+				log.Warningf("Failed to snapshot flight recorder: %s", err)
+			}
+		}
+	}
+}
+
+// snapshot writes out the recorded trace along with a dump of the process's goroutines, retaining both in the
+// trace store. If limited, it fails if the last snapshot was taken within the cooldown.
+func (r *flightRecorder) snapshot(trigger string, limited bool) (*capturedTrace, error) {
+	r.snapshotting.Lock()
+	defer r.snapshotting.Unlock()
+	r.mutex.Lock()
+	if !r.recording {
+		r.mutex.Unlock()
+		return nil, errFlightRecorderDisabled
+	} else if wait := r.cooldown - time.Since(r.lastSnapshot); limited && wait > 0 {
+		r.mutex.Unlock()
+		return nil, fmt.Errorf("the flight recorder was snapshotted too recently; try again in %s", wait.Round(time.Second))
+	}
+	r.mutex.Unlock()
+
+	t := &capturedTrace{Time: time.Now(), Trigger: trigger}
+	var buf bytes.Buffer
+	if _, err := r.recorder.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	t.data = buf.Bytes()
+	var dump bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&dump, 2); err != nil {
+		return nil, err
+	}
+	t.goroutines = dump.Bytes()
+	// The dump is also kept as a goroutine snapshot, so it can be browsed & compared to the current goroutines.
+	if gs, err := parseGoroutines(bytes.NewReader(t.goroutines)); err != nil {
+		log.Warningf("Failed to parse goroutines for flight recorder snapshot: %s", err)
+	} else {
+		s := &goroutineSnapshot{Time: t.Time, Total: len(gs), Groups: groupGoroutines(gs)}
+		saveGoroutineSnapshot(s)
+		t.GoroutineSnapshot = s.ID
+	}
+	r.traces.add(t)
+
+	r.mutex.Lock()
+	r.lastSnapshot = t.Time
+	r.snapshots++
+	r.mutex.Unlock()
+	log.Infof("Snapshotted flight recorder as trace %d: %s", t.ID, trigger)
+	return t, nil
+}
+
+func (a *HTTPServer) getFlightRecorder() *flightRecorder {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.recorder
+}
+
+// SnapshotFlightRecorder snapshots the server's flight recorder, retaining the execution trace of the last few
+// seconds & a dump of the process's goroutines for viewing at /admin/traces. It's intended to be called by the
+// application when it notices something worth investigating, such as a slow request, so it fails if the flight
+// recorder isn't running or was last snapshotted within Opts.FlightRecorderCooldown.
+func (a *HTTPServer) SnapshotFlightRecorder(reason string) error {
+	r := a.getFlightRecorder()
+	if r == nil {
+		return errFlightRecorderDisabled
+	}
+	_, err := r.snapshot("api: "+reason, true)
+	return err
+}
+
+// SnapshotFlightRecorder snapshots the default HTTPServer's flight recorder. See HTTPServer.SnapshotFlightRecorder.
+func SnapshotFlightRecorder(reason string) error {
+	return DefaultAdminHTTPServer.SnapshotFlightRecorder(reason)
+}
+
+// flightRecorderStatus is the status of the flight recorder.
+type flightRecorderStatus struct {
+	Enabled      bool                    `json:"enabled"`
+	Recording    bool                    `json:"recording"`
+	Error        string                  `json:"error,omitempty"`
+	Started      time.Time               `json:"started,omitempty"`
+	Period       time.Duration           `json:"period,omitempty"`
+	Size         int                     `json:"size,omitempty"`
+	Interval     time.Duration           `json:"interval,omitempty"`
+	Cooldown     time.Duration           `json:"cooldown,omitempty"`
+	Triggers     []flightRecorderTrigger `json:"triggers,omitempty"`
+	LastSnapshot time.Time               `json:"last_snapshot,omitempty"`
+	Snapshots    int                     `json:"snapshots"`
+	CSRF         template.HTML           `json:"-"`
+}
+
+func (a *HTTPServer) flightRecorderStatus() flightRecorderStatus {
+	r := a.getFlightRecorder()
+	if r == nil {
+		return flightRecorderStatus{}
+	}
+	status := flightRecorderStatus{
+		Enabled:  true,
+		Period:   r.period,
+		Size:     r.size,
+		Interval: r.interval,
+		Cooldown: r.cooldown,
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	status.Recording, status.Started = r.recording, r.started
+	status.LastSnapshot, status.Snapshots = r.lastSnapshot, r.snapshots
+	if r.err != nil {
+		status.Error = r.err.Error()
+	}
+	for _, t := range r.triggers {
+		status.Triggers = append(status.Triggers, *t)
+	}
+	return status
+}
+
+var flightRecorderTemplate = template.Must(template.New("flightrecorder").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"size": func(n int) string { return formatBytes(uint64(n)) },
+}).Parse(`
+{{if not .Enabled}}
+<p>The flight recorder isn't enabled. Set <code>--flight_recorder</code> or <code>Opts.FlightRecorder</code> to enable it.</p>
+{{else}}
+{{if .Error}}<div class="alert alert-danger">The flight recorder failed to start: {{.Error}}</div>{{end}}
+{{if .Recording}}
+<p>
+	Recording the last {{.Period}} of execution, up to {{size .Size}}, since {{time .Started}}.
+	{{.Snapshots}} snapshots have been taken{{if not .LastSnapshot.IsZero}}, the last at {{time .LastSnapshot}}{{end}};
+	see <a href="/admin/traces">Execution Traces</a>.
+</p>
+<form method="POST" action="/admin/flightrecorder/snapshot" class="form-inline mb-3">{{.CSRF}}
+	<input type="text" name="reason" class="form-control form-control-sm mr-2 mb-1" placeholder="Reason"/>
+	<input type="submit" class="btn btn-sm btn-primary mb-1" value="Snapshot"/>
+</form>
+{{else if not .Error}}
+<p>The flight recorder isn't recording.</p>
+{{end}}
+<h5>Triggers</h5>
+<p>Checked every {{.Interval}}; a snapshot is taken when a threshold is crossed, at most once per {{.Cooldown}}.</p>
+<table class="table table-sm">
+	<thead><tr><th>trigger</th><th>current value</th></tr></thead>
+	<tbody>
+	{{range .Triggers}}
+		<tr{{if .Triggered}} class="table-warning"{{end}}><td><code>{{.Expr}}</code></td><td>{{with .Value}}{{.}}{{else}}not yet checked{{end}}</td></tr>
+	{{else}}
+		<tr><td colspan="2">None. Set <code>--flight_recorder_trigger</code> to snapshot when a metric crosses a threshold, e.g. <code>http_requests_in_flight > 100</code>.</td></tr>
+	{{end}}
+	</tbody>
+</table>
+{{end}}
+`))
+
+// flightRecorderHandler renders the status of the flight recorder, with a button to snapshot it.
+func (a *HTTPServer) flightRecorderHandler(w http.ResponseWriter, r *http.Request) {
+	status := a.flightRecorderStatus()
+	status.CSRF = CSRFField(r)
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := flightRecorderTemplate.Execute(w, status); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// flightRecorderJSONHandler returns the status of the flight recorder.
+func (a *HTTPServer) flightRecorderJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.flightRecorderStatus())
+	w.Write(b)
+}
+
+// snapshotFlightRecorderHandler snapshots the flight recorder, with the reason given by the reason form value.
+// Unlike snapshots taken by the application, these aren't limited by the cooldown.
+func (a *HTTPServer) snapshotFlightRecorderHandler(w http.ResponseWriter, r *http.Request) {
+	rec := a.getFlightRecorder()
+	if rec == nil {
+		http.Error(w, errFlightRecorderDisabled.Error(), http.StatusConflict)
+		return
+	}
+	trigger := "manual"
+	if reason := strings.TrimSpace(r.FormValue("reason")); reason != "" {
+		trigger += ": " + reason
+	}
+	t, err := rec.snapshot(trigger, false)
+	if err == errFlightRecorderDisabled {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to snapshot flight recorder: %s", err), http.StatusInternalServerError)
+		return
+	}
+	AnnotateAudit(r, fmt.Sprintf("snapshotted flight recorder as trace %d", t.ID))
+	if expectsHTML(r) {
+		http.Redirect(w, r, "/admin/traces/"+strconv.Itoa(t.ID), http.StatusSeeOther)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(t)
+	w.Write(b)
+}
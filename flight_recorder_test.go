@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recordingTestServer returns a server whose flight recorder is recording, until the test finishes.
+func recordingTestServer(t *testing.T, opts Opts) (*HTTPServer, *flightRecorder) {
+	t.Helper()
+	opts.FlightRecorder = true
+	s := &HTTPServer{}
+	r, err := newFlightRecorder(opts, s.getTraces())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.stop)
+	s.recorder = r
+	return s, r
+}
+
+func TestParseFlightRecorderTrigger(t *testing.T) {
+	trigger, err := parseFlightRecorderTrigger(`http_requests_in_flight{path=~"/api/.*"} > 100`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trigger.op != ">" || trigger.threshold != 100 || !trigger.selector.matches("http_requests_in_flight", map[string]string{"path": "/api/foo"}) {
+		t.Errorf("unexpected trigger %+v", trigger)
+	}
+	if !trigger.crossed(101) || trigger.crossed(100) {
+		t.Error("trigger crossed incorrectly")
+	}
+	if trigger, err := parseFlightRecorderTrigger("up<1"); err != nil || trigger.op != "<" || !trigger.crossed(0) {
+		t.Errorf("unexpected trigger %+v: %v", trigger, err)
+	}
+	if trigger, err := parseFlightRecorderTrigger("x >= 5"); err != nil || !trigger.crossed(5) || trigger.crossed(4) {
+		t.Errorf("unexpected trigger %+v: %v", trigger, err)
+	}
+	if trigger, err := parseFlightRecorderTrigger("x<=5"); err != nil || !trigger.crossed(5) || trigger.crossed(6) {
+		t.Errorf("unexpected trigger %+v: %v", trigger, err)
+	}
+	trigger, err = parseFlightRecorderTrigger(`http_request_duration_seconds_bucket{le=">1"} > 10`)
+	if err != nil || trigger.threshold != 10 || !trigger.selector.matches("http_request_duration_seconds_bucket", map[string]string{"le": ">1"}) {
+		t.Errorf("unexpected trigger %+v: %v", trigger, err)
+	}
+	for _, s := range []string{"up", "up > lots", "up{ > 1", "> 1", "up => 1", "up > > 1", "up == 1"} {
+		if _, err := parseFlightRecorderTrigger(s); err == nil {
+			t.Errorf("%q: invalid trigger parsed", s)
+		}
+	}
+	if _, err := newFlightRecorder(Opts{FlightRecorder: true, FlightRecorderSize: "lots"}, nil); err == nil {
+		t.Error("invalid size accepted")
+	}
+}
+
+func TestFlightRecorderSnapshots(t *testing.T) {
+	s, r := recordingTestServer(t, Opts{FlightRecorderCooldown: time.Hour})
+	traceWorkload(t)
+
+	w := postHTML(s, "/admin/flightrecorder/snapshot", url.Values{"reason": {"slow requests"}, csrfField: {"x"}})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/traces/1" {
+		t.Fatalf("unexpected %d: %s", w.Code, w.Body.String())
+	}
+	trace := s.getTraces().get("1")
+	if trace.Trigger != "manual: slow requests" || trace.GoroutineSnapshot == 0 || trace.Size == 0 {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if a, err := trace.analyse(); err != nil || a.Events == 0 || len(a.GC) == 0 {
+		t.Errorf("unexpected analysis %+v: %v", a, err)
+	}
+	if body := get(t, s, "/admin/traces/1/goroutines", false).Body.String(); !strings.Contains(body, "goroutine ") {
+		t.Errorf("unexpected goroutines %s", body)
+	}
+	if body := get(t, s, "/admin/traces/1", true).Body.String(); !strings.Contains(body, "/admin/goroutines?base=") {
+		t.Errorf("unexpected page %s", body)
+	}
+
+	// Snapshots from the application are limited by the cooldown; those via the admin server aren't.
+	if err := s.SnapshotFlightRecorder("timeout"); err == nil || !strings.Contains(err.Error(), "too recently") {
+		t.Errorf("unexpected error %v", err)
+	}
+	r.mutex.Lock()
+	r.lastSnapshot = time.Time{}
+	r.mutex.Unlock()
+	if err := s.SnapshotFlightRecorder("timeout"); err != nil {
+		t.Fatal(err)
+	}
+	if traces := s.getTraces().list(); len(traces) != 2 || traces[0].Trigger != "api: timeout" {
+		t.Errorf("unexpected traces %+v", traces)
+	}
+
+	// Traces can't be captured separately while the recorder's tracing the process.
+	if w := postHTML(s, "/admin/traces", url.Values{"seconds": {"1"}, csrfField: {"x"}}); w.Code != http.StatusConflict {
+		t.Errorf("unexpected %d", w.Code)
+	}
+	if body := get(t, s, "/admin/traces", true).Body.String(); strings.Contains(body, "Capture trace") {
+		t.Errorf("unexpected page %s", body)
+	}
+}
+
+func TestFlightRecorderTriggers(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	inFlight := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "in_flight"}, []string{"path"})
+	registry.MustRegister(inFlight)
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: registry})
+	s, r := recordingTestServer(t, Opts{FlightRecorderTriggers: []string{"in_flight > 5"}})
+
+	var status flightRecorderStatus
+	if err := json.Unmarshal(get(t, s, "/admin/flightrecorder.json", false).Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Recording || len(status.Triggers) != 1 || status.Triggers[0].Value != nil {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	inFlight.WithLabelValues("/a").Set(3)
+	r.checkTriggers()
+	if traces := s.getTraces().list(); len(traces) != 0 {
+		t.Fatalf("unexpected traces %+v", traces)
+	}
+	inFlight.WithLabelValues("/b").Set(3)
+	r.checkTriggers()
+	r.checkTriggers()
+	if traces := s.getTraces().list(); len(traces) != 1 || traces[0].Trigger != "in_flight > 5 (6)" {
+		t.Fatalf("unexpected traces %+v", traces)
+	}
+	if body := get(t, s, "/admin/flightrecorder", true).Body.String(); !strings.Contains(body, `class="table-warning"><td><code>in_flight &gt; 5</code></td><td>6</td>`) {
+		t.Errorf("unexpected page %s", body)
+	}
+}
+
+func TestFlightRecorderDisabled(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.SnapshotFlightRecorder("timeout"); err != errFlightRecorderDisabled {
+		t.Errorf("unexpected error %v", err)
+	}
+	if body := get(t, s, "/admin/flightrecorder", true).Body.String(); !strings.Contains(body, "isn't enabled") {
+		t.Errorf("unexpected page %s", body)
+	}
+	if w := postHTML(s, "/admin/flightrecorder/snapshot", url.Values{csrfField: {"x"}}); w.Code != http.StatusConflict {
+		t.Errorf("unexpected %d", w.Code)
+	}
+}
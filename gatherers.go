@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_model/go"
+)
+
+// Gatherer is the thing we gather metrics from, unless Opts.Gatherers is set.
+var Gatherer = prometheus.DefaultGatherer
+
+// adminRegistry holds the metrics exported by the admin server itself, which are served alongside Gatherer's.
+var adminRegistry = prometheus.NewRegistry()
+
+// Names of the gatherers that are always present.
+const (
+	DefaultGathererName = "default"
+	adminGathererName   = "admin"
+)
+
+// A NamedGatherer is a source of metrics, such as an application's registry or that of a third-party library.
+// Queries can be restricted to particular gatherers by passing their names as g parameters.
+type NamedGatherer struct {
+	Name     string
+	Gatherer prometheus.Gatherer
+}
+
+// gatherers holds the gatherers set via Opts.Gatherers. If there are none, Gatherer is used.
+var gatherers struct {
+	sync.RWMutex
+	list []NamedGatherer
+}
+
+// setGatherers sets the gatherers metrics are gathered from. Their names must be unique. If there are none,
+// Gatherer is used.
+func setGatherers(gs []NamedGatherer) error {
+	names := map[string]bool{adminGathererName: true}
+	for _, g := range gs {
+		if g.Name == "" || g.Gatherer == nil {
+			return fmt.Errorf("gatherers must have a name and a Gatherer")
+		} else if names[g.Name] {
+			return fmt.Errorf("gatherer name %s is used more than once", g.Name)
+		}
+		names[g.Name] = true
+	}
+	gatherers.Lock()
+	defer gatherers.Unlock()
+	gatherers.list = gs
+	return nil
+}
+
+// namedGatherers returns all the gatherers metrics are gathered from, in order of precedence.
+func namedGatherers() []NamedGatherer {
+	gatherers.RLock()
+	defer gatherers.RUnlock()
+	gs := gatherers.list
+	if len(gs) == 0 {
+		gs = []NamedGatherer{{Name: DefaultGathererName, Gatherer: Gatherer}}
+	}
+	return append(append([]NamedGatherer{}, gs...), NamedGatherer{Name: adminGathererName, Gatherer: adminRegistry})
+}
+
+// gathererNames returns the names of the gatherers requested by the g parameters of a request, which
+// are all of them if there are none.
+func gathererNames(r *http.Request) ([]string, error) {
+	names := r.URL.Query()["g"]
+	known := map[string]bool{}
+	for _, g := range namedGatherers() {
+		known[g.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown gatherer %s", name)
+		}
+	}
+	return names, nil
+}
+
+// A gatherConflict is an error describing a conflict between gatherers.
+type gatherConflict struct {
+	msg string
+}
+
+func (c gatherConflict) Error() string {
+	return c.msg
+}
+
+// withoutConflicts removes any conflicts from an error returned by gather.
+func withoutConflicts(err error) error {
+	var errs prometheus.MultiError
+	if multi, ok := err.(prometheus.MultiError); ok {
+		errs = multi
+	} else if err != nil {
+		errs = prometheus.MultiError{err}
+	}
+	var ret prometheus.MultiError
+	for _, err := range errs {
+		if _, ok := err.(gatherConflict); !ok {
+			ret = append(ret, err)
+		}
+	}
+	return ret.MaybeUnwrap()
+}
+
+// reportedConflicts records the conflicts between gatherers that have been logged, so each is only logged once.
+var reportedConflicts sync.Map
+
+// gather gathers metrics from the named gatherers, or all of them if none are named, and merges them.
+// Where gatherers conflict, by exporting a family with different types or the same series, the first takes
+// precedence and the conflict is returned as an error alongside the merged metrics.
+func gather(names ...string) ([]*io_prometheus_client.MetricFamily, error) {
+	include := map[string]bool{}
+	for _, name := range names {
+		include[name] = true
+	}
+	families := map[string]*io_prometheus_client.MetricFamily{}
+	familySources := map[string]string{}
+	seriesSources := map[string]string{}
+	var errs prometheus.MultiError
+	conflict := func(format string, args ...interface{}) {
+		err := gatherConflict{msg: fmt.Sprintf(format, args...)}
+		if _, reported := reportedConflicts.LoadOrStore(err.msg, true); !reported {
+			log.Warningf("Conflicting metrics: %s", err)
+		}
+		errs = append(errs, err)
+	}
+	for _, g := range namedGatherers() {
+		if len(include) > 0 && !include[g.Name] {
+			continue
+		}
+		mfs, err := g.Gatherer.Gather()
+		if err != nil {
+			// Gatherers can return partial results alongside an error, so carry on with what we have.
+			errs = append(errs, fmt.Errorf("gatherer %s: %w", g.Name, err))
+		}
+		for _, mf := range mfs {
+			name := mf.GetName()
+			merged, present := families[name]
+			if !present {
+				merged = &io_prometheus_client.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				families[name] = merged
+				familySources[name] = g.Name
+			} else if merged.GetType() != mf.GetType() {
+				conflict("%s is a %s in gatherer %s but a %s in gatherer %s", name, merged.GetType(), familySources[name], mf.GetType(), g.Name)
+				continue
+			}
+			for _, m := range mf.Metric {
+				key := name + formatLabels(labelMap(m))
+				if source, present := seriesSources[key]; present {
+					conflict("%s is exported by both gatherer %s and gatherer %s", key, source, g.Name)
+					continue
+				}
+				seriesSources[key] = g.Name
+				merged.Metric = append(merged.Metric, m)
+			}
+		}
+	}
+	ret := make([]*io_prometheus_client.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		if len(mf.Metric) > 0 {
+			sort.SliceStable(mf.Metric, func(i, j int) bool { return labelsLess(mf.Metric[i].Label, mf.Metric[j].Label) })
+			ret = append(ret, mf)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].GetName() < ret[j].GetName() })
+	return ret, errs.MaybeUnwrap()
+}
+
+// labelsLess orders series by their labels, in the same way as the Prometheus client library.
+func labelsLess(a, b []*io_prometheus_client.LabelPair) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].GetName() != b[i].GetName() {
+			return a[i].GetName() < b[i].GetName()
+		} else if a[i].GetValue() != b[i].GetValue() {
+			return a[i].GetValue() < b[i].GetValue()
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// useGatherers sets the named gatherers for the duration of a test.
+func useGatherers(t *testing.T, gs ...NamedGatherer) {
+	if err := setGatherers(gs); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setGatherers(nil) })
+}
+
+func TestGatherersAreMerged(t *testing.T) {
+	app, lib := prometheus.NewRegistry(), prometheus.NewRegistry()
+	appRequests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	libRequests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	app.MustRegister(appRequests, prometheus.NewGauge(prometheus.GaugeOpts{Name: "pool_size"}))
+	lib.MustRegister(libRequests, prometheus.NewCounter(prometheus.CounterOpts{Name: "pool_size"}))
+	appRequests.WithLabelValues("200").Add(1)
+	appRequests.WithLabelValues("500").Add(2)
+	libRequests.WithLabelValues("200").Add(3)
+	libRequests.WithLabelValues("404").Add(4)
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: app}, NamedGatherer{Name: "lib", Gatherer: lib})
+
+	mfs, err := gather("app", "lib")
+	if len(mfs) != 2 || mfs[0].GetName() != "pool_size" || mfs[1].GetName() != "requests_total" {
+		t.Fatalf("unexpected families %v", mfs)
+	}
+	// The first gatherer takes precedence in conflicts, which are reported.
+	if mfs[0].GetType().String() != "GAUGE" {
+		t.Errorf("pool_size should be app's gauge, got %s", mfs[0].GetType())
+	}
+	if m := mfs[1].Metric; len(m) != 3 || formatLabels(labelMap(m[0])) != "{code=200}" || m[0].GetCounter().GetValue() != 1 || formatLabels(labelMap(m[1])) != "{code=404}" {
+		t.Errorf("unexpected requests %v", m)
+	}
+	errs, ok := err.(prometheus.MultiError)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two conflicts, got %v", err)
+	}
+	if msg := errs[0].Error(); msg != "pool_size is a GAUGE in gatherer app but a COUNTER in gatherer lib" {
+		t.Errorf("unexpected conflict %s", msg)
+	}
+	if msg := errs[1].Error(); msg != "requests_total{code=200} is exported by both gatherer app and gatherer lib" {
+		t.Errorf("unexpected conflict %s", msg)
+	}
+	if withoutConflicts(err) != nil {
+		t.Errorf("expected no errors other than conflicts, got %s", withoutConflicts(err))
+	}
+
+	// Conflicts don't prevent scraping.
+	w := get(t, authedServer(nil), "/metrics", false)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `requests_total{code="404"} 4`) {
+		t.Errorf("unexpected /metrics response %d: %s", w.Code, w.Body.String())
+	}
+	w = get(t, authedServer(nil), "/metrics?g=lib", false)
+	if body := w.Body.String(); !strings.Contains(body, `requests_total{code="200"} 3`) || strings.Contains(body, "admin_") {
+		t.Errorf("unexpected /metrics?g=lib response %s", body)
+	}
+
+	entries := queryMetrics(t, "m=requests_total&g=lib")
+	if len(entries) != 2 || *entries[0].Value != 3 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	w = get(t, &HTTPServer{}, "/admin/metrics?g=app", true)
+	if body := w.Body.String(); !strings.Contains(body, `data-gatherer="app"`) || !strings.Contains(body, `<option value="app" selected>`) {
+		t.Errorf("unexpected metrics page %s", body)
+	}
+	w = get(t, &HTTPServer{}, "/admin/metrics", true)
+	if body := w.Body.String(); !strings.Contains(body, "exported by both gatherer app and gatherer lib") {
+		t.Errorf("metrics page doesn't show conflicts %s", body)
+	}
+}
+
+func TestUnknownGatherer(t *testing.T) {
+	for _, path := range []string{"/metrics?g=nope", "/admin/metrics?g=nope", "/admin/metrics?m=x&g=nope", "/admin/metrics/labels?m=x&g=nope"} {
+		if w := get(t, authedServer(nil), path, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestGathererIsReadOnEachRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "late_gauge"}))
+	s := authedServer(nil)
+	get(t, s, "/metrics", false)
+	useGatherer(t, reg)
+	if w := get(t, s, "/metrics?g="+DefaultGathererName, false); !strings.Contains(w.Body.String(), "late_gauge 0") {
+		t.Errorf("reassigned Gatherer not used: %s", w.Body.String())
+	}
+}
+
+func TestSetGatherersValidation(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	for name, gs := range map[string][]NamedGatherer{
+		"no name":     {{Gatherer: reg}},
+		"no gatherer": {{Name: "app"}},
+		"duplicate":   {{Name: "app", Gatherer: reg}, {Name: "app", Gatherer: reg}},
+		"reserved":    {{Name: "admin", Gatherer: reg}},
+	} {
+		if err := setGatherers(gs); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestStartResetsGatherers(t *testing.T) {
+	defer setGatherers(nil)
+	s := &HTTPServer{}
+	opts := localOpts
+	opts.Gatherers = []NamedGatherer{{Name: "app", Gatherer: prometheus.NewRegistry()}}
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown(context.Background())
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if gs := namedGatherers(); len(gs) != 2 || gs[0].Name != DefaultGathererName {
+		t.Errorf("gatherers not reset on restart: %+v", gs)
+	}
+}
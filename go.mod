@@ -1,14 +1,28 @@
 module github.com/thought-machine/http-admin
 
-go 1.14
+go 1.24.0
 
 require (
+	github.com/google/pprof v0.0.0-20240227163752-401108e1b7e7
 	github.com/gorilla/mux v1.7.4
-	github.com/peterebden/go-cli-init v1.3.1-0.20200329085717-d04cad1849c3 // indirect
-	github.com/prometheus/client_golang v1.5.1
+	github.com/peterebden/go-cli-init v1.3.1-0.20200329085717-d04cad1849c3
+	github.com/prometheus/client_golang v1.11.1
 	github.com/prometheus/client_model v0.2.0
-	golang.org/x/crypto v0.0.0-20200323165209-0ec3e9974c59 // indirect
-	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e
-	golang.org/x/sys v0.0.0-20200327173247-9dae0f8f5775 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/exp v0.0.0-20260209203927-2842357ff358
+	golang.org/x/net v0.0.0-20200625001655-4c5254603344
 	gopkg.in/op/go-logging.v1 v1.0.0-20160211212156-b2cb9fa56473
 )
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/golang/protobuf v1.4.3 // indirect
+	github.com/jessevdk/go-flags v1.4.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/common v0.26.0 // indirect
+	github.com/prometheus/procfs v0.6.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	google.golang.org/protobuf v1.26.0-rc.1 // indirect
+)
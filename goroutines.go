@@ -0,0 +1,475 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxGoroutineSnapshots is the number of goroutine snapshots retained for diffing.
+const maxGoroutineSnapshots = 10
+
+// maxGoroutineIDs is the number of example goroutine IDs recorded for each group.
+const maxGoroutineIDs = 10
+
+// A goroutineFrame is a single frame of a goroutine's stack.
+type goroutineFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// A goroutine is a single goroutine parsed from a goroutine dump.
+type goroutine struct {
+	ID    int
+	State string
+	// Wait is how long the goroutine has been blocked, which the runtime only reports to the minute.
+	Wait      time.Duration
+	Locked    bool
+	Frames    []goroutineFrame
+	CreatedBy *goroutineFrame
+}
+
+// key identifies the goroutine's stack, including where it was created.
+func (g *goroutine) key() string {
+	var b strings.Builder
+	for _, f := range g.Frames {
+		fmt.Fprintf(&b, "%s %s:%d\n", f.Function, f.File, f.Line)
+	}
+	if g.CreatedBy != nil {
+		fmt.Fprintf(&b, "created by %s %s:%d\n", g.CreatedBy.Function, g.CreatedBy.File, g.CreatedBy.Line)
+	}
+	return b.String()
+}
+
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[(.*)\]:$`)
+
+// parseGoroutines parses a goroutine dump in the format of /debug/pprof/goroutine?debug=2.
+func parseGoroutines(r io.Reader) ([]*goroutine, error) {
+	var ret []*goroutine
+	var g *goroutine
+	var frame *goroutineFrame // the frame whose location is expected next
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			g, frame = nil, nil
+		case goroutineHeader.MatchString(line):
+			match := goroutineHeader.FindStringSubmatch(line)
+			id, _ := strconv.Atoi(match[1])
+			g = &goroutine{ID: id}
+			for i, attr := range strings.Split(match[2], ", ") {
+				if i == 0 {
+					g.State = attr
+				} else if attr == "locked to thread" {
+					g.Locked = true
+				} else if minutes := strings.TrimSuffix(strings.TrimSuffix(attr, " minutes"), " minute"); minutes != attr {
+					m, _ := strconv.Atoi(minutes)
+					g.Wait = time.Duration(m) * time.Minute
+				}
+			}
+			ret = append(ret, g)
+		case g == nil:
+			return nil, fmt.Errorf("line %d: expected a goroutine header, got %q", n, line)
+		case strings.HasPrefix(line, "\t"):
+			if frame == nil {
+				return nil, fmt.Errorf("line %d: unexpected location %q", n, line)
+			}
+			// Locations are of the form file:line +0xoffset, where the offset is optional.
+			loc := strings.TrimSpace(line)
+			if i := strings.LastIndex(loc, " +0x"); i >= 0 {
+				loc = loc[:i]
+			}
+			if i := strings.LastIndex(loc, ":"); i >= 0 {
+				frame.File = loc[:i]
+				frame.Line, _ = strconv.Atoi(loc[i+1:])
+			}
+			frame = nil
+		case strings.HasPrefix(line, "created by "):
+			function := strings.TrimPrefix(line, "created by ")
+			if i := strings.Index(function, " in goroutine "); i >= 0 {
+				function = function[:i]
+			}
+			g.CreatedBy = &goroutineFrame{Function: function}
+			frame = g.CreatedBy
+		case strings.HasPrefix(line, "..."):
+			// ...additional frames elided...
+		default:
+			// Functions are followed by their arguments, e.g. net/http.(*conn).serve(0xc000123456, ...)
+			function := line
+			if strings.HasSuffix(line, ")") {
+				function = line[:strings.LastIndex(line, "(")]
+			}
+			g.Frames = append(g.Frames, goroutineFrame{Function: function})
+			frame = &g.Frames[len(g.Frames)-1]
+		}
+	}
+	return ret, scanner.Err()
+}
+
+// A goroutineStateCount is the number of goroutines of a group in a given state.
+type goroutineStateCount struct {
+	State string `json:"state"`
+	Count int    `json:"count"`
+}
+
+// A goroutineGroup is a set of goroutines with identical stacks.
+type goroutineGroup struct {
+	Key       string                `json:"-"`
+	Count     int                   `json:"count"`
+	States    []goroutineStateCount `json:"states"`
+	MinWait   time.Duration         `json:"min_wait"`
+	MaxWait   time.Duration         `json:"max_wait"`
+	Locked    int                   `json:"locked,omitempty"`
+	IDs       []int                 `json:"ids"`
+	Frames    []goroutineFrame      `json:"frames"`
+	CreatedBy *goroutineFrame       `json:"created_by,omitempty"`
+	// Before and Delta are set when diffing snapshots.
+	Before int `json:"before,omitempty"`
+	Delta  int `json:"delta,omitempty"`
+}
+
+// groupGoroutines groups goroutines by their stacks, largest groups first.
+func groupGoroutines(gs []*goroutine) []*goroutineGroup {
+	groups := map[string]*goroutineGroup{}
+	var ret []*goroutineGroup
+	for _, g := range gs {
+		key := g.key()
+		group, present := groups[key]
+		if !present {
+			group = &goroutineGroup{Key: key, MinWait: g.Wait, Frames: g.Frames, CreatedBy: g.CreatedBy}
+			groups[key] = group
+			ret = append(ret, group)
+		}
+		group.Count++
+		if len(group.IDs) < maxGoroutineIDs {
+			group.IDs = append(group.IDs, g.ID)
+		}
+		if g.Locked {
+			group.Locked++
+		}
+		if g.Wait < group.MinWait {
+			group.MinWait = g.Wait
+		}
+		if g.Wait > group.MaxWait {
+			group.MaxWait = g.Wait
+		}
+		i := 0
+		for i < len(group.States) && group.States[i].State != g.State {
+			i++
+		}
+		if i == len(group.States) {
+			group.States = append(group.States, goroutineStateCount{State: g.State})
+		}
+		group.States[i].Count++
+	}
+	for _, group := range ret {
+		sort.Slice(group.States, func(i, j int) bool { return group.States[i].Count > group.States[j].Count })
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Count > ret[j].Count })
+	return ret
+}
+
+// filter returns the number of the group's goroutines that match the given query & state, which match all
+// goroutines if empty. The query matches groups with a function whose name contains it, e.g. a package name.
+func (g *goroutineGroup) filter(query, state string) int {
+	if query != "" {
+		query = strings.ToLower(query)
+		matches := g.CreatedBy != nil && strings.Contains(strings.ToLower(g.CreatedBy.Function), query)
+		for _, f := range g.Frames {
+			matches = matches || strings.Contains(strings.ToLower(f.Function), query)
+		}
+		if !matches {
+			return 0
+		}
+	}
+	if state == "" {
+		return g.Count
+	}
+	for _, s := range g.States {
+		if s.State == state {
+			return s.Count
+		}
+	}
+	return 0
+}
+
+// A goroutineSnapshot is the goroutines of the process at a point in time.
+type goroutineSnapshot struct {
+	ID     int               `json:"id"`
+	Time   time.Time         `json:"time"`
+	Total  int               `json:"total"`
+	Groups []*goroutineGroup `json:"-"`
+}
+
+// takeGoroutineSnapshot parses the current goroutines of the process.
+func takeGoroutineSnapshot() (*goroutineSnapshot, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return nil, err
+	}
+	gs, err := parseGoroutines(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return &goroutineSnapshot{Time: time.Now(), Total: len(gs), Groups: groupGoroutines(gs)}, nil
+}
+
+// goroutineSnapshots holds the snapshots taken via the admin server, oldest first.
+var goroutineSnapshots struct {
+	sync.Mutex
+	nextID int
+	list   []*goroutineSnapshot
+}
+
+// saveGoroutineSnapshot retains a snapshot, discarding the oldest if there are too many.
+func saveGoroutineSnapshot(s *goroutineSnapshot) {
+	goroutineSnapshots.Lock()
+	defer goroutineSnapshots.Unlock()
+	goroutineSnapshots.nextID++
+	s.ID = goroutineSnapshots.nextID
+	goroutineSnapshots.list = append(goroutineSnapshots.list, s)
+	if len(goroutineSnapshots.list) > maxGoroutineSnapshots {
+		goroutineSnapshots.list = goroutineSnapshots.list[1:]
+	}
+}
+
+func savedGoroutineSnapshots() []*goroutineSnapshot {
+	goroutineSnapshots.Lock()
+	defer goroutineSnapshots.Unlock()
+	return append([]*goroutineSnapshot{}, goroutineSnapshots.list...)
+}
+
+// findGoroutineSnapshot returns the snapshot with the given ID, or an error if it's unknown or has been discarded.
+func findGoroutineSnapshot(id string) (*goroutineSnapshot, error) {
+	for _, s := range savedGoroutineSnapshots() {
+		if strconv.Itoa(s.ID) == id {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown goroutine snapshot %q", id)
+}
+
+// filterGoroutineGroups returns copies of the groups matching the query & state, with their counts restricted to the
+// matching goroutines.
+func filterGoroutineGroups(groups []*goroutineGroup, query, state string) []*goroutineGroup {
+	ret := []*goroutineGroup{}
+	for _, g := range groups {
+		if n := g.filter(query, state); n > 0 {
+			filtered := *g
+			filtered.Count = n
+			ret = append(ret, &filtered)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Count > ret[j].Count })
+	return ret
+}
+
+// diffGoroutineGroups returns the groups whose counts differ between before & after, those that grew most first.
+func diffGoroutineGroups(before, after []*goroutineGroup) []*goroutineGroup {
+	counts := map[string]int{}
+	for _, g := range before {
+		counts[g.Key] = g.Count
+	}
+	ret := []*goroutineGroup{}
+	for _, g := range after {
+		if g.Count != counts[g.Key] {
+			diffed := *g
+			diffed.Before, diffed.Delta = counts[g.Key], g.Count-counts[g.Key]
+			ret = append(ret, &diffed)
+		}
+		delete(counts, g.Key)
+	}
+	// Groups that have gone entirely.
+	for _, g := range before {
+		if _, present := counts[g.Key]; present {
+			diffed := *g
+			diffed.Count, diffed.Before, diffed.Delta, diffed.IDs = 0, g.Count, -g.Count, nil
+			ret = append(ret, &diffed)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Delta > ret[j].Delta })
+	return ret
+}
+
+// goroutineView is what's rendered by goroutinesHandler.
+type goroutineView struct {
+	Total     int                  `json:"total"`
+	Matched   int                  `json:"matched"`
+	Query     string               `json:"-"`
+	State     string               `json:"-"`
+	States    []string             `json:"-"`
+	Base      *goroutineSnapshot   `json:"base,omitempty"`
+	Compare   *goroutineSnapshot   `json:"compare,omitempty"`
+	Groups    []*goroutineGroup    `json:"groups"`
+	Snapshots []*goroutineSnapshot `json:"snapshots"`
+	CSRF      template.HTML        `json:"-"`
+}
+
+// newGoroutineView builds the view of the current goroutines, or a diff between snapshots, requested by r.
+// The base parameter names a snapshot to diff from, and compare a snapshot to diff it against rather than the
+// current goroutines. The q & state parameters restrict the goroutines shown.
+func newGoroutineView(r *http.Request) (*goroutineView, error) {
+	v := &goroutineView{
+		Query:     strings.TrimSpace(r.URL.Query().Get("q")),
+		State:     r.URL.Query().Get("state"),
+		Snapshots: savedGoroutineSnapshots(),
+		CSRF:      CSRFField(r),
+	}
+	var err error
+	if id := r.URL.Query().Get("compare"); id != "" {
+		v.Compare, err = findGoroutineSnapshot(id)
+	} else {
+		v.Compare, err = takeGoroutineSnapshot()
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.Total = v.Compare.Total
+	v.Groups = filterGoroutineGroups(v.Compare.Groups, v.Query, v.State)
+	if id := r.URL.Query().Get("base"); id != "" {
+		if v.Base, err = findGoroutineSnapshot(id); err != nil {
+			return nil, err
+		}
+		v.Groups = diffGoroutineGroups(filterGoroutineGroups(v.Base.Groups, v.Query, v.State), v.Groups)
+	}
+	for _, g := range v.Groups {
+		v.Matched += g.Count
+	}
+	if v.Compare.ID == 0 {
+		// Only saved snapshots are worth referring to.
+		v.Compare = nil
+	}
+	states := map[string]bool{}
+	for _, g := range v.Snapshots {
+		for _, group := range g.Groups {
+			for _, s := range group.States {
+				states[s.State] = true
+			}
+		}
+	}
+	for _, group := range v.Groups {
+		for _, s := range group.States {
+			states[s.State] = true
+		}
+	}
+	for s := range states {
+		v.States = append(v.States, s)
+	}
+	sort.Strings(v.States)
+	return v, nil
+}
+
+var goroutinesTemplate = template.Must(template.New("goroutines").Funcs(template.FuncMap{
+	"wait": func(g *goroutineGroup) string {
+		if g.MaxWait == 0 {
+			return ""
+		} else if g.MinWait == g.MaxWait {
+			return fmt.Sprintf("waiting %s", g.MaxWait)
+		}
+		return fmt.Sprintf("waiting %s – %s", g.MinWait, g.MaxWait)
+	},
+	"time": func(t time.Time) string { return t.Format("15:04:05") },
+}).Parse(`
+<form method="GET" action="/admin/goroutines" class="form-inline mb-2">
+	<input type="text" name="q" value="{{.Query}}" class="form-control form-control-sm mr-2 mb-1" placeholder="Function or package"/>
+	<select name="state" class="form-control form-control-sm mr-2 mb-1">
+		<option value="">any state</option>
+		{{range .States}}<option value="{{.}}"{{if eq . $.State}} selected{{end}}>{{.}}</option>{{end}}
+	</select>
+	{{if .Snapshots}}
+	<select name="base" class="form-control form-control-sm mr-2 mb-1">
+		<option value="">no diff</option>
+		{{range .Snapshots}}<option value="{{.ID}}"{{if and $.Base (eq .ID $.Base.ID)}} selected{{end}}>diff from snapshot {{.ID}} ({{time .Time}})</option>{{end}}
+	</select>
+	<select name="compare" class="form-control form-control-sm mr-2 mb-1">
+		<option value="">against now</option>
+		{{range .Snapshots}}<option value="{{.ID}}"{{if and $.Compare (eq .ID $.Compare.ID)}} selected{{end}}>against snapshot {{.ID}} ({{time .Time}})</option>{{end}}
+	</select>
+	{{end}}
+	<input type="submit" class="btn btn-sm btn-primary mr-2 mb-1" value="Show"/>
+</form>
+<form method="POST" action="/admin/goroutines/snapshots" class="form-inline mb-3">{{.CSRF}}
+	<input type="submit" class="btn btn-sm btn-secondary mr-2" value="Take snapshot"/>
+	<a href="/debug/pprof/goroutine?debug=2">raw dump</a>
+</form>
+<p>
+	{{if .Base}}Changes from snapshot {{.Base.ID}} ({{.Base.Total}} goroutines at {{time .Base.Time}}) to
+	{{with .Compare}}snapshot {{.ID}} ({{.Total}} goroutines at {{time .Time}}){{else}}now ({{.Total}} goroutines){{end}}.
+	{{else}}{{.Matched}} of {{.Total}} goroutines in {{len .Groups}} groups with identical stacks.{{end}}
+</p>
+{{range .Groups}}
+<div class="card mb-2">
+	<div class="card-header">
+		{{if $.Base}}<span class="badge {{if gt .Delta 0}}badge-danger{{else}}badge-success{{end}}">{{if gt .Delta 0}}+{{end}}{{.Delta}}</span>
+		{{.Before}} → {{.Count}}{{else}}<span class="badge badge-primary">{{.Count}}</span>{{end}}
+		{{range .States}}<span class="badge badge-light">{{.State}} × {{.Count}}</span> {{end}}
+		{{with wait .}}<span class="text-muted small">{{.}}</span>{{end}}
+		{{if .Locked}}<span class="text-muted small">{{.Locked}} locked to thread</span>{{end}}
+	</div>
+	<div class="card-body py-2">
+		<pre class="mb-0 small">{{range .Frames}}{{.Function}}
+	{{.File}}:{{.Line}}
+{{end}}{{with .CreatedBy}}created by {{.Function}}
+	{{.File}}:{{.Line}}{{end}}</pre>
+		{{if .IDs}}<div class="small text-muted">e.g. goroutine{{range .IDs}} {{.}}{{end}}</div>{{end}}
+	</div>
+</div>
+{{end}}
+`))
+
+// goroutinesHandler renders the process's goroutines grouped by stack, or a diff between snapshots of them.
+func goroutinesHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := newGoroutineView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := goroutinesTemplate.Execute(w, v); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// goroutinesJSONHandler returns the same as goroutinesHandler, as JSON.
+func goroutinesJSONHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := newGoroutineView(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(v)
+	w.Write(b)
+}
+
+// goroutineSnapshotHandler takes a snapshot of the process's goroutines, for later diffing.
+func goroutineSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := takeGoroutineSnapshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	saveGoroutineSnapshot(s)
+	AnnotateAudit(r, fmt.Sprintf("snapshot %d of %d goroutines", s.ID, s.Total))
+	if expectsHTML(r) {
+		http.Redirect(w, r, "/admin/goroutines?base="+strconv.Itoa(s.ID), http.StatusSeeOther)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(s)
+	w.Write(b)
+}
@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testGoroutineDump = `goroutine 1 [running]:
+main.main()
+	/src/main.go:12 +0x1d
+
+goroutine 7 [chan receive, 5 minutes]:
+main.worker(0xc000012345, {0x1, 0x2})
+	/src/worker.go:20 +0x19
+created by main.main in goroutine 1
+	/src/main.go:10 +0x65
+
+goroutine 8 [chan receive, 1 minute, locked to thread]:
+main.worker(0xc000012399, {0x1, 0x2})
+	/src/worker.go:20 +0x19
+created by main.main in goroutine 1
+	/src/main.go:10 +0x65
+
+goroutine 9 [select]:
+main.worker(0xc000012399, {0x1, 0x2})
+	/src/worker.go:20 +0x19
+created by main.main in goroutine 1
+	/src/main.go:10 +0x65
+
+goroutine 10 gp=0xc000003500 m=nil [IO wait]:
+internal/poll.runtime_pollWait(0x7f, 0x72)
+	/go/src/runtime/netpoll.go:351 +0x85
+net/http.(*conn).serve(...)
+	/go/src/net/http/server.go:2000
+...additional frames elided...
+created by net/http.(*Server).Serve
+	/go/src/net/http/server.go:3000 +0x485
+`
+
+func TestParseGoroutines(t *testing.T) {
+	gs, err := parseGoroutines(strings.NewReader(testGoroutineDump))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(gs) != 5 {
+		t.Fatalf("parsed %d goroutines", len(gs))
+	}
+	if g := gs[1]; g.ID != 7 || g.State != "chan receive" || g.Wait != 5*time.Minute || g.Locked ||
+		len(g.Frames) != 1 || g.Frames[0] != (goroutineFrame{"main.worker", "/src/worker.go", 20}) ||
+		*g.CreatedBy != (goroutineFrame{"main.main", "/src/main.go", 10}) {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+	if g := gs[2]; g.Wait != time.Minute || !g.Locked {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+	if g := gs[4]; g.ID != 10 || g.State != "IO wait" || len(g.Frames) != 2 ||
+		g.Frames[1] != (goroutineFrame{"net/http.(*conn).serve", "/go/src/net/http/server.go", 2000}) ||
+		g.CreatedBy.Function != "net/http.(*Server).Serve" {
+		t.Errorf("unexpected goroutine %+v", g)
+	}
+
+	if _, err := parseGoroutines(strings.NewReader("not a dump\n")); err == nil {
+		t.Error("parsed garbage")
+	}
+}
+
+func TestGroupGoroutines(t *testing.T) {
+	gs, _ := parseGoroutines(strings.NewReader(testGoroutineDump))
+	groups := groupGoroutines(gs)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups", len(groups))
+	}
+	g := groups[0]
+	if g.Count != 3 || g.MinWait != 0 || g.MaxWait != 5*time.Minute || g.Locked != 1 || len(g.IDs) != 3 ||
+		len(g.States) != 2 || g.States[0] != (goroutineStateCount{"chan receive", 2}) {
+		t.Errorf("unexpected group %+v", g)
+	}
+
+	for _, test := range []struct {
+		query, state string
+		expected     int
+	}{
+		{"", "", 3},
+		{"WORKER", "", 3},
+		{"main", "select", 1},
+		{"net/http", "", 0},
+		{"", "IO wait", 0},
+	} {
+		if n := g.filter(test.query, test.state); n != test.expected {
+			t.Errorf("filter(%q, %q) = %d, expected %d", test.query, test.state, n, test.expected)
+		}
+	}
+	if filtered := filterGoroutineGroups(groups, "net/http", ""); len(filtered) != 1 || filtered[0].Count != 1 {
+		t.Errorf("unexpected filtered groups %+v", filtered)
+	}
+
+	diff := diffGoroutineGroups(groups[1:], groups[:2])
+	if len(diff) != 2 || diff[0].Delta != 3 || diff[0].Before != 0 || diff[1].Delta != -1 || diff[1].Count != 0 {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+}
+
+// goroutineTestBlocker blocks until done is closed, so tests can find goroutines by function name.
+func goroutineTestBlocker(done chan struct{}) {
+	<-done
+}
+
+// startGoroutineTestBlockers starts n goroutineTestBlockers, all created at the same place so they're grouped together.
+// It waits until they're all blocked, so their stacks are stable.
+func startGoroutineTestBlockers(t *testing.T, n int, done chan struct{}) {
+	t.Helper()
+	before := countGoroutineTestBlockers(t)
+	for i := 0; i < n; i++ {
+		go goroutineTestBlocker(done)
+	}
+	for deadline := time.Now().Add(5 * time.Second); countGoroutineTestBlockers(t) < before+n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines didn't block")
+		}
+	}
+}
+
+func countGoroutineTestBlockers(t *testing.T) int {
+	t.Helper()
+	s, err := takeGoroutineSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, g := range filterGoroutineGroups(s.Groups, "goroutineTestBlocker", "chan receive") {
+		n += g.Count
+	}
+	return n
+}
+
+func TestGoroutineSnapshots(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	s := &HTTPServer{}
+	startGoroutineTestBlockers(t, 3, done)
+
+	w := post(s, "/admin/goroutines/snapshots", url.Values{csrfField: {"x"}}, "x", "")
+	var snapshot goroutineSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+		t.Fatal(err)
+	} else if snapshot.ID == 0 || snapshot.Total < 3 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	startGoroutineTestBlockers(t, 2, done)
+
+	var v goroutineView
+	w = get(t, s, "/admin/goroutines.json?q=goroutineTestBlocker", false)
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	} else if len(v.Groups) != 1 || v.Groups[0].Count != 5 || v.Matched != 5 || v.Total < 5 {
+		t.Errorf("unexpected goroutines %+v", v)
+	}
+
+	v = goroutineView{}
+	w = get(t, s, "/admin/goroutines.json?q=goroutineTestBlocker&base="+strconv.Itoa(snapshot.ID), false)
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	} else if len(v.Groups) != 1 || v.Groups[0].Before != 3 || v.Groups[0].Delta != 2 || v.Base.ID != snapshot.ID {
+		t.Errorf("unexpected diff %+v", v)
+	}
+
+	body := get(t, s, "/admin/goroutines?q=goroutineTestBlocker&base="+strconv.Itoa(snapshot.ID), true).Body.String()
+	if !strings.Contains(body, "+2") || !strings.Contains(body, "3 → 5") || !strings.Contains(body, "admin.goroutineTestBlocker") {
+		t.Errorf("unexpected page %s", body)
+	}
+
+	for _, path := range []string{"/admin/goroutines.json?base=0", "/admin/goroutines.json?compare=nonsense"} {
+		if w := get(t, s, path, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: unexpected %d", path, w.Code)
+		}
+	}
+
+	// A browser is sent to the diff against the new snapshot.
+	w = postHTML(s, "/admin/goroutines/snapshots", url.Values{csrfField: {"x"}})
+	if w.Code != http.StatusSeeOther || !strings.HasPrefix(w.Header().Get("Location"), "/admin/goroutines?base=") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestGoroutineSnapshotRetention(t *testing.T) {
+	for i := 0; i < maxGoroutineSnapshots+2; i++ {
+		saveGoroutineSnapshot(&goroutineSnapshot{})
+	}
+	snapshots := savedGoroutineSnapshots()
+	if len(snapshots) != maxGoroutineSnapshots || snapshots[len(snapshots)-1].ID != snapshots[0].ID+maxGoroutineSnapshots-1 {
+		t.Errorf("unexpected snapshots %+v", snapshots)
+	}
+}
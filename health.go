@@ -0,0 +1,333 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Probe is a kind of health check performed by an orchestrator such as Kubernetes.
+type Probe string
+
+// The probes served under /admin/health/.
+const (
+	// Liveness checks fail when the process is wedged and should be restarted.
+	Liveness Probe = "live"
+	// Readiness checks fail when the process shouldn't receive traffic.
+	Readiness Probe = "ready"
+	// Startup checks fail until the process has finished starting up. Once they have all passed, they are not run again.
+	Startup Probe = "startup"
+)
+
+// defaultHealthCheckTimeout is used for checks that don't set a Timeout.
+const defaultHealthCheckTimeout = 5 * time.Second
+
+// healthHistorySize is the number of results retained for each check.
+const healthHistorySize = 20
+
+// A HealthCheck is a named check of some aspect of the process' health.
+type HealthCheck struct {
+	// Name uniquely identifies the check.
+	Name string
+	// Check returns an error if the check fails. It should respect the context's deadline.
+	Check func(ctx context.Context) error
+	// Probes are the probes this check contributes to. Defaults to Readiness.
+	Probes []Probe
+	// Timeout is the time after which the check is considered to have failed. Defaults to 5 seconds.
+	Timeout time.Duration
+	// Interval, if set, runs the check in the background at this interval, and probes report its most recent result.
+	// Otherwise the check is run each time a probe is requested.
+	Interval time.Duration
+}
+
+// A HealthResult is the result of a single run of a health check.
+type HealthResult struct {
+	Time    time.Time     `json:"time"`
+	Latency time.Duration `json:"latency_ns"`
+	Healthy bool          `json:"healthy"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type healthCheckState struct {
+	HealthCheck
+	mutex   sync.Mutex
+	history []HealthResult
+	stop    chan struct{}
+}
+
+// run runs the check once, records its result and returns it.
+func (h *healthCheckState) run() HealthResult {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", h.Timeout)
+	}
+	result := HealthResult{Time: start, Latency: time.Since(start), Healthy: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+		healthCheckFailures.WithLabelValues(h.Name).Inc()
+		healthCheckStatus.WithLabelValues(h.Name).Set(0)
+	} else {
+		healthCheckStatus.WithLabelValues(h.Name).Set(1)
+	}
+	healthCheckDuration.WithLabelValues(h.Name).Observe(result.Latency.Seconds())
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.history = append(h.history, result)
+	if len(h.history) > healthHistorySize {
+		h.history = h.history[len(h.history)-healthHistorySize:]
+	}
+	return result
+}
+
+// last returns the most recent result, if there is one.
+func (h *healthCheckState) last() (HealthResult, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.history) == 0 {
+		return HealthResult{}, false
+	}
+	return h.history[len(h.history)-1], true
+}
+
+func (h *healthCheckState) hasProbe(probe Probe) bool {
+	for _, p := range h.Probes {
+		if p == probe {
+			return true
+		}
+	}
+	return false
+}
+
+// runInBackground runs the check at its interval until stopped.
+func (h *healthCheckState) runInBackground() {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+	h.run()
+	for {
+		select {
+		case <-ticker.C:
+			h.run()
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+var healthChecks = struct {
+	sync.Mutex
+	checks        map[string]*healthCheckState
+	startupPassed bool
+}{checks: map[string]*healthCheckState{}}
+
+var (
+	healthCheckStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "admin_health_check_status",
+		Help: "Result of the most recent run of each health check; 1 if healthy, 0 if not.",
+	}, []string{"check"})
+	healthCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "admin_health_check_duration_seconds",
+		Help: "Time taken to run each health check.",
+	}, []string{"check"})
+	healthCheckFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "admin_health_check_failures_total",
+		Help: "Number of times each health check has failed.",
+	}, []string{"check"})
+)
+
+func init() {
+	adminRegistry.MustRegister(healthCheckStatus, healthCheckDuration, healthCheckFailures)
+}
+
+// RegisterHealthCheck registers a health check. An error is returned if the check is invalid or one
+// with the same name is already registered.
+func RegisterHealthCheck(check HealthCheck) error {
+	if check.Name == "" || check.Check == nil {
+		return fmt.Errorf("health checks must have a name and a check function")
+	}
+	if len(check.Probes) == 0 {
+		check.Probes = []Probe{Readiness}
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = defaultHealthCheckTimeout
+	}
+	healthChecks.Lock()
+	defer healthChecks.Unlock()
+	if _, present := healthChecks.checks[check.Name]; present {
+		return fmt.Errorf("health check %s is already registered", check.Name)
+	}
+	state := &healthCheckState{HealthCheck: check, stop: make(chan struct{})}
+	healthChecks.checks[check.Name] = state
+	if check.Interval > 0 {
+		go state.runInBackground()
+	}
+	return nil
+}
+
+// UnregisterHealthCheck removes a previously registered health check.
+func UnregisterHealthCheck(name string) {
+	healthChecks.Lock()
+	defer healthChecks.Unlock()
+	if state, present := healthChecks.checks[name]; present {
+		close(state.stop)
+		delete(healthChecks.checks, name)
+		healthCheckStatus.DeleteLabelValues(name)
+	}
+}
+
+// sortedHealthChecks returns all registered checks, sorted by name.
+func sortedHealthChecks() []*healthCheckState {
+	healthChecks.Lock()
+	defer healthChecks.Unlock()
+	ret := make([]*healthCheckState, 0, len(healthChecks.checks))
+	for _, c := range healthChecks.checks {
+		ret = append(ret, c)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+type probeCheckResult struct {
+	Name string `json:"name"`
+	HealthResult
+}
+
+type probeResult struct {
+	Probe   Probe              `json:"probe"`
+	Healthy bool               `json:"healthy"`
+	Checks  []probeCheckResult `json:"checks"`
+}
+
+// evaluate runs (or, for background checks, looks up) all checks contributing to the given probe.
+func evaluate(probe Probe) probeResult {
+	result := probeResult{Probe: probe, Healthy: true, Checks: []probeCheckResult{}}
+	if probe == Startup {
+		healthChecks.Lock()
+		passed := healthChecks.startupPassed
+		healthChecks.Unlock()
+		if passed {
+			return result
+		}
+	}
+
+	var checks []*healthCheckState
+	for _, c := range sortedHealthChecks() {
+		if c.hasProbe(probe) {
+			checks = append(checks, c)
+		}
+	}
+	results := make([]probeCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		if c.Interval > 0 {
+			last, ok := c.last()
+			if !ok {
+				last = HealthResult{Time: time.Now(), Error: "not yet run"}
+			}
+			results[i] = probeCheckResult{Name: c.Name, HealthResult: last}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c *healthCheckState) {
+			defer wg.Done()
+			results[i] = probeCheckResult{Name: c.Name, HealthResult: c.run()}
+		}(i, c)
+	}
+	wg.Wait()
+	if probe == Readiness && Draining() {
+		results = append(results, probeCheckResult{Name: "drain", HealthResult: HealthResult{Time: time.Now(), Error: "draining"}})
+	}
+	for _, r := range results {
+		result.Healthy = result.Healthy && r.Healthy
+	}
+	result.Checks = results
+
+	if probe == Startup && result.Healthy {
+		healthChecks.Lock()
+		healthChecks.startupPassed = true
+		healthChecks.Unlock()
+	}
+	return result
+}
+
+// probeHandler serves the JSON result of the given probe, with a 503 status if it's failing.
+func probeHandler(probe Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := evaluate(probe)
+		writeContentType(w, "application/json;charset=UTF-8")
+		if !result.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		b, _ := json.Marshal(result)
+		w.Write(b)
+	}
+}
+
+type healthCheckView struct {
+	Name     string
+	Probes   []Probe
+	Interval time.Duration
+	Last     *HealthResult
+	History  []HealthResult
+}
+
+var healthTemplate = template.Must(template.New("health").Parse(`
+<table class="table">
+	<thead>
+		<tr><th>check</th><th>probes</th><th>last result</th><th>latency</th><th>last run</th><th>history</th></tr>
+	</thead>
+	<tbody>
+{{range .}}
+		<tr class="{{with .Last}}{{if .Healthy}}table-success{{else}}table-danger{{end}}{{end}}">
+			<td>{{.Name}}{{if .Interval}} <small>(every {{.Interval}})</small>{{end}}</td>
+			<td>{{range .Probes}}<a href="/admin/health/{{.}}">{{.}}</a> {{end}}</td>
+{{with .Last}}
+			<td>{{if .Healthy}}healthy{{else}}{{.Error}}{{end}}</td>
+			<td>{{.Latency}}</td>
+			<td>{{.Time.Format "15:04:05.000"}}</td>
+{{else}}
+			<td colspan="3">not yet run</td>
+{{end}}
+			<td>{{range .History}}<span class="fa {{if .Healthy}}fa-check-circle text-success{{else}}fa-times-circle text-danger{{end}}" title="{{.Time.Format "15:04:05"}} {{.Error}}"></span>{{end}}</td>
+		</tr>
+{{else}}
+		<tr><td colspan="6">No health checks have been registered.</td></tr>
+{{end}}
+	</tbody>
+</table>
+`))
+
+// HealthHandler renders the most recent results of all health checks, without running them.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	var views []healthCheckView
+	for _, c := range sortedHealthChecks() {
+		c.mutex.Lock()
+		history := append([]HealthResult{}, c.history...)
+		c.mutex.Unlock()
+		view := healthCheckView{Name: c.Name, Probes: c.Probes, Interval: c.Interval, History: history}
+		if len(history) > 0 {
+			view.Last = &history[len(history)-1]
+		}
+		views = append(views, view)
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := healthTemplate.Execute(w, views); err != nil {
+		log.Errorf("%s", err)
+	}
+}
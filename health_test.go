@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func registerHealthCheck(t *testing.T, check HealthCheck) {
+	if err := RegisterHealthCheck(check); err != nil {
+		t.Fatalf("failed to register health check: %s", err)
+	}
+	t.Cleanup(func() { UnregisterHealthCheck(check.Name) })
+}
+
+func probe(t *testing.T, s http.Handler, path string) (int, probeResult) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	var result probeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("%s: invalid JSON %q: %s", path, w.Body.String(), err)
+	}
+	return w.Code, result
+}
+
+func TestHealthProbes(t *testing.T) {
+	var dbUp int32
+	registerHealthCheck(t, HealthCheck{Name: "deadlock", Probes: []Probe{Liveness}, Check: func(context.Context) error { return nil }})
+	registerHealthCheck(t, HealthCheck{Name: "db", Check: func(context.Context) error {
+		if atomic.LoadInt32(&dbUp) == 0 {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}})
+	s := authedServer(StaticTokenAuthenticator{})
+
+	if code, result := probe(t, s, "/admin/health/live"); code != http.StatusOK || !result.Healthy || len(result.Checks) != 1 || result.Checks[0].Name != "deadlock" {
+		t.Errorf("live: unexpected %d %+v", code, result)
+	}
+	code, result := probe(t, s, "/admin/health/ready")
+	if code != http.StatusServiceUnavailable || result.Healthy || len(result.Checks) != 1 || result.Checks[0].Error != "connection refused" {
+		t.Errorf("ready: unexpected %d %+v", code, result)
+	}
+	atomic.StoreInt32(&dbUp, 1)
+	if code, result := probe(t, s, "/admin/health/ready"); code != http.StatusOK || !result.Healthy {
+		t.Errorf("ready after recovery: unexpected %d %+v", code, result)
+	}
+
+	w := get(t, authedServer(nil), "/metrics", false)
+	for _, metric := range []string{
+		`admin_health_check_status{check="db"} 1`,
+		`admin_health_check_failures_total{check="db"} 1`,
+		`admin_health_check_duration_seconds_count{check="db"} 2`,
+	} {
+		if !strings.Contains(w.Body.String(), metric) {
+			t.Errorf("metrics missing %s", metric)
+		}
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	registerHealthCheck(t, HealthCheck{Name: "slow", Timeout: 10 * time.Millisecond, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+	start := time.Now()
+	if result := evaluate(Readiness); result.Healthy || result.Checks[0].Error != "timed out after 10ms" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("probe took %s, should have timed out", elapsed)
+	}
+}
+
+func TestStartupProbeLatches(t *testing.T) {
+	defer func() { healthChecks.startupPassed = false }()
+	var ready, runs int32
+	registerHealthCheck(t, HealthCheck{Name: "warmup", Probes: []Probe{Startup}, Check: func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		if atomic.LoadInt32(&ready) == 0 {
+			return fmt.Errorf("still warming up")
+		}
+		return nil
+	}})
+	if evaluate(Startup).Healthy {
+		t.Error("startup should fail before warmup")
+	}
+	atomic.StoreInt32(&ready, 1)
+	if !evaluate(Startup).Healthy {
+		t.Error("startup should pass after warmup")
+	}
+	atomic.StoreInt32(&ready, 0)
+	if !evaluate(Startup).Healthy || atomic.LoadInt32(&runs) != 2 {
+		t.Errorf("startup should not be rechecked once passed; ran %d times", runs)
+	}
+}
+
+func TestBackgroundHealthCheck(t *testing.T) {
+	var runs int32
+	registerHealthCheck(t, HealthCheck{Name: "cached", Interval: 10 * time.Millisecond, Check: func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+	deadline := time.Now().Add(5 * time.Second)
+	for !evaluate(Readiness).Healthy {
+		if time.Now().After(deadline) {
+			t.Fatal("background check never ran")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	n := atomic.LoadInt32(&runs)
+	evaluate(Readiness)
+	evaluate(Readiness)
+	if atomic.LoadInt32(&runs) > n+1 {
+		t.Errorf("probes should use the cached result, not rerun the check")
+	}
+
+	w := get(t, authedServer(nil), "/admin/health", true)
+	if !strings.Contains(w.Body.String(), "cached") || !strings.Contains(w.Body.String(), "table-success") {
+		t.Errorf("health page missing check: %s", w.Body.String())
+	}
+}
+
+func TestRegisterHealthCheckErrors(t *testing.T) {
+	registerHealthCheck(t, HealthCheck{Name: "dup", Check: func(context.Context) error { return nil }})
+	if err := RegisterHealthCheck(HealthCheck{Name: "dup", Check: func(context.Context) error { return nil }}); err == nil || err.Error() != "health check dup is already registered" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := RegisterHealthCheck(HealthCheck{Name: "nocheck"}); err == nil {
+		t.Error("expected an error registering a check without a function")
+	}
+}
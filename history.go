@@ -0,0 +1,294 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_model/go"
+)
+
+// Defaults used when Opts.HistoryInterval and Opts.HistoryRetention aren't set.
+const (
+	defaultHistoryInterval  = 5 * time.Second
+	defaultHistoryRetention = 30 * time.Minute
+)
+
+// maxHistorySeries bounds the number of series recorded, to bound the memory used by high-cardinality metrics.
+const maxHistorySeries = 10000
+
+type historyPoint struct {
+	time  time.Time
+	value float64
+}
+
+// A historySeries is a fixed size ring buffer of the values of a single series.
+type historySeries struct {
+	name, family string
+	gatherer     string
+	labels       map[string]string
+	counter      bool
+	points       []historyPoint
+	next         int
+	full         bool
+}
+
+func (s *historySeries) add(p historyPoint) {
+	s.points[s.next] = p
+	s.next = (s.next + 1) % len(s.points)
+	s.full = s.full || s.next == 0
+}
+
+// between returns the points in the given time range, oldest first.
+func (s *historySeries) between(from, to time.Time) []historyPoint {
+	n, start := s.next, 0
+	if s.full {
+		n, start = len(s.points), s.next
+	}
+	ret := []historyPoint{}
+	for i := 0; i < n; i++ {
+		if p := s.points[(start+i)%len(s.points)]; !p.time.Before(from) && !p.time.After(to) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// A metricHistory periodically samples metrics from each gatherer, retaining a bounded history of each series.
+type metricHistory struct {
+	mutex     sync.RWMutex
+	size      int
+	retention time.Duration
+	families  map[string]bool
+	series    map[string]*historySeries
+	dropped   bool
+}
+
+// newMetricHistory returns a history sampling the given families (or all if empty) that retains size samples of each.
+func newMetricHistory(size int, retention time.Duration, families []string) *metricHistory {
+	if size < 1 {
+		size = 1
+	}
+	h := &metricHistory{
+		size:      size,
+		retention: retention,
+		families:  make(map[string]bool, len(families)),
+		series:    map[string]*historySeries{},
+	}
+	for _, f := range families {
+		h.families[f] = true
+	}
+	return h
+}
+
+// run samples metrics at the given interval until the context is cancelled.
+func (h *metricHistory) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, g := range namedGatherers() {
+				mfs, err := g.Gatherer.Gather()
+				if err != nil {
+					log.Warningf("Failed to gather metrics from %s for history: %s", g.Name, err)
+				}
+				h.sample(now, g.Name, mfs)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sample records the current value of every series in the given families, gathered from the named gatherer.
+// Histograms & summaries are recorded as their _sum and _count, as in the Prometheus text format.
+func (h *metricHistory) sample(now time.Time, gatherer string, mfs []*io_prometheus_client.MetricFamily) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, mf := range mfs {
+		family := mf.GetName()
+		if len(h.families) > 0 && !h.families[family] {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := labelMap(m)
+			switch mf.GetType() {
+			case io_prometheus_client.MetricType_COUNTER:
+				h.record(now, gatherer, family, "", labels, true, m.GetCounter().GetValue())
+			case io_prometheus_client.MetricType_GAUGE:
+				h.record(now, gatherer, family, "", labels, false, m.GetGauge().GetValue())
+			case io_prometheus_client.MetricType_UNTYPED:
+				h.record(now, gatherer, family, "", labels, false, m.GetUntyped().GetValue())
+			case io_prometheus_client.MetricType_SUMMARY:
+				h.record(now, gatherer, family, "_sum", labels, true, m.GetSummary().GetSampleSum())
+				h.record(now, gatherer, family, "_count", labels, true, float64(m.GetSummary().GetSampleCount()))
+			case io_prometheus_client.MetricType_HISTOGRAM:
+				h.record(now, gatherer, family, "_sum", labels, true, m.GetHistogram().GetSampleSum())
+				h.record(now, gatherer, family, "_count", labels, true, float64(m.GetHistogram().GetSampleCount()))
+			}
+		}
+	}
+	// Forget series that have disappeared, once they have no points within the retention period.
+	for name, s := range h.series {
+		if last := s.points[(s.next-1+len(s.points))%len(s.points)]; now.Sub(last.time) > h.retention {
+			delete(h.series, name)
+		}
+	}
+}
+
+// record records a point of a series, whose name is the family name plus the given suffix and its labels.
+// If more than one gatherer exports the same series, only the first one's is recorded, as in gather.
+func (h *metricHistory) record(now time.Time, gatherer, family, suffix string, labels map[string]string, counter bool, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	name := family + suffix + formatLabels(labels)
+	s, present := h.series[name]
+	if !present {
+		if len(h.series) >= maxHistorySeries {
+			if !h.dropped {
+				log.Warningf("Metric history is limited to %d series, not recording %s or any further series", maxHistorySeries, name)
+				h.dropped = true
+			}
+			return
+		}
+		s = &historySeries{name: name, family: family, gatherer: gatherer, labels: labels, counter: counter, points: make([]historyPoint, h.size)}
+		h.series[name] = s
+	} else if s.gatherer != gatherer {
+		return
+	}
+	s.add(historyPoint{time: now, value: value})
+}
+
+// historyFunction transforms the points of a counter.
+type historyFunction func(prev, cur historyPoint) float64
+
+// historyFunctions are the functions that can be applied to counters; they're left as-is for gauges.
+// Both handle counter resets by assuming the counter restarted from zero.
+var historyFunctions = map[string]historyFunction{
+	"delta": func(prev, cur historyPoint) float64 {
+		if cur.value < prev.value {
+			return cur.value
+		}
+		return cur.value - prev.value
+	},
+	"rate": func(prev, cur historyPoint) float64 {
+		delta := cur.value - prev.value
+		if cur.value < prev.value {
+			delta = cur.value
+		}
+		return delta / cur.time.Sub(prev.time).Seconds()
+	},
+}
+
+// A historyEntry is the JSON representation of a series. Points are [unix milliseconds, value] pairs.
+type historyEntry struct {
+	Name   string            `json:"name"`
+	Family string            `json:"family"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Type   string            `json:"type"`
+	Points [][2]float64      `json:"points"`
+}
+
+// query returns the series matched by any of the given selectors in the given time range, with fn applied
+// to counters if non-nil. Selectors match the series of histograms & summaries by the family's name.
+// If any gatherers are given, only series from them are returned.
+func (h *metricHistory) query(sels []metricSelector, gatherers []string, from, to time.Time, fn historyFunction) []historyEntry {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	include := map[string]bool{}
+	for _, g := range gatherers {
+		include[g] = true
+	}
+	ret := []historyEntry{}
+	for _, s := range h.series {
+		if (len(include) > 0 && !include[s.gatherer]) || !anySelectorMatches(sels, s.family, s.labels) {
+			continue
+		}
+		entry := historyEntry{Name: s.name, Family: s.family, Labels: s.labels, Type: "gauge", Points: [][2]float64{}}
+		points := s.between(from, to)
+		if s.counter {
+			entry.Type = "counter"
+		}
+		for i, p := range points {
+			if !s.counter || fn == nil {
+				entry.Points = append(entry.Points, [2]float64{float64(p.time.UnixNano() / int64(time.Millisecond)), p.value})
+			} else if i > 0 {
+				if v := fn(points[i-1], p); !math.IsNaN(v) && !math.IsInf(v, 0) {
+					entry.Points = append(entry.Points, [2]float64{float64(p.time.UnixNano() / int64(time.Millisecond)), v})
+				}
+			}
+		}
+		ret = append(ret, entry)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// parseHistoryTime parses a time given as either unix seconds or RFC3339.
+func parseHistoryTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func (a *HTTPServer) getHistory() *metricHistory {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.history
+}
+
+// historyHandler returns the recorded history of the series matched by the selectors given as m parameters,
+// from the gatherers named by any g parameters, between the optional from and to parameters.
+// The fn parameter can be rate or delta to transform counters. It returns 404 if history isn't being recorded.
+func (a *HTTPServer) historyHandler(w http.ResponseWriter, r *http.Request) {
+	h := a.getHistory()
+	if h == nil {
+		http.Error(w, "Metric history is not being recorded", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	to := time.Now()
+	from := to.Add(-h.retention)
+	var err error
+	if s := q.Get("from"); s != "" {
+		if from, err = parseHistoryTime(s); err != nil {
+			http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if s := q.Get("to"); s != "" {
+		if to, err = parseHistoryTime(s); err != nil {
+			http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var fn historyFunction
+	if name := q.Get("fn"); name != "" {
+		var present bool
+		if fn, present = historyFunctions[name]; !present {
+			http.Error(w, "Unknown fn "+name+", must be rate or delta", http.StatusBadRequest)
+			return
+		}
+	}
+	sels, err := parseSelectors(q["m"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	gatherers, err := gathererNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(h.query(sels, gatherers, from, to, fn))
+	w.Write(b)
+}
@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func queryHistory(t *testing.T, s *HTTPServer, query string) []historyEntry {
+	t.Helper()
+	w := get(t, s, "/admin/metrics/history?"+query, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var entries []historyEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON %q: %s", w.Body.String(), err)
+	}
+	return entries
+}
+
+func TestMetricHistory(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inflight"})
+	reg.MustRegister(requests, inflight)
+
+	h := newMetricHistory(3, time.Minute, nil)
+	start := time.Unix(1000, 0)
+	sample := func(i int) {
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.sample(start.Add(time.Duration(i)*10*time.Second), "default", mfs)
+	}
+	for i, v := range []float64{10, 20, 40, 5} {
+		// The last value is lower, as if the process restarted.
+		requests.Reset()
+		requests.WithLabelValues("200").Add(v)
+		inflight.Set(float64(i))
+		sample(i)
+	}
+	s := &HTTPServer{history: h}
+
+	// Only the last three samples are retained.
+	entries := queryHistory(t, s, "m=inflight&m=requests_total&from=0")
+	if len(entries) != 2 || entries[0].Name != "inflight" || entries[1].Name != "requests_total{code=200}" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if e := entries[0]; e.Type != "gauge" || len(e.Points) != 3 || e.Points[0] != [2]float64{1010000, 1} {
+		t.Errorf("unexpected gauge %+v", e)
+	}
+	if e := entries[1]; e.Type != "counter" || len(e.Points) != 3 || e.Points[2][1] != 5 {
+		t.Errorf("unexpected counter %+v", e)
+	}
+
+	// The reset is treated as a restart from zero.
+	entries = queryHistory(t, s, "m=requests_total&from=0&fn=rate")
+	if len(entries) != 1 || len(entries[0].Points) != 2 || entries[0].Points[0] != [2]float64{1020000, 2} || entries[0].Points[1][1] != 0.5 {
+		t.Errorf("unexpected rates %+v", entries)
+	}
+	entries = queryHistory(t, s, "m=requests_total&from=0&fn=delta")
+	if len(entries) != 1 || entries[0].Points[0][1] != 20 || entries[0].Points[1][1] != 5 {
+		t.Errorf("unexpected deltas %+v", entries)
+	}
+	// Gauges are left as they are.
+	entries = queryHistory(t, s, "m=inflight&from=0&fn=rate")
+	if len(entries) != 1 || len(entries[0].Points) != 3 {
+		t.Errorf("unexpected gauge %+v", entries)
+	}
+
+	entries = queryHistory(t, s, "m=inflight&from=1015&to=1970-01-01T00:17:05Z")
+	if len(entries) != 1 || len(entries[0].Points) != 1 || entries[0].Points[0][1] != 2 {
+		t.Errorf("unexpected range %+v", entries)
+	}
+	if entries := queryHistory(t, s, "m=missing&from=0"); len(entries) != 0 {
+		t.Errorf("unexpected entries for missing metric %+v", entries)
+	}
+}
+
+func TestMetricHistoryDistributions(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds"})
+	reg.MustRegister(latency)
+	latency.Observe(2)
+	mfs, _ := reg.Gather()
+
+	h := newMetricHistory(10, time.Minute, nil)
+	h.sample(time.Now(), "default", mfs)
+	sel, _ := parseSelector("latency_seconds")
+	entries := h.query([]metricSelector{sel}, nil, time.Time{}, time.Now(), nil)
+	if len(entries) != 2 || entries[0].Name != "latency_seconds_count" || entries[1].Name != "latency_seconds_sum" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if entries[0].Points[0][1] != 1 || entries[1].Points[0][1] != 2 {
+		t.Errorf("unexpected values %+v", entries)
+	}
+}
+
+func TestMetricHistoryFamiliesAndPruning(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a := prometheus.NewGauge(prometheus.GaugeOpts{Name: "a"})
+	b := prometheus.NewGauge(prometheus.GaugeOpts{Name: "b"})
+	reg.MustRegister(a, b)
+	mfs, _ := reg.Gather()
+
+	h := newMetricHistory(10, time.Minute, []string{"a"})
+	start := time.Now()
+	h.sample(start, "default", mfs)
+	if _, present := h.series["b"]; present || h.series["a"] == nil {
+		t.Fatalf("unexpected series %v", h.series)
+	}
+	// Series that disappear are forgotten once they're older than the retention period.
+	h.sample(start.Add(30*time.Second), "default", nil)
+	if h.series["a"] == nil {
+		t.Error("series pruned too early")
+	}
+	h.sample(start.Add(2*time.Minute), "default", nil)
+	if len(h.series) != 0 {
+		t.Errorf("series not pruned %v", h.series)
+	}
+}
+
+func TestMetricHistoryErrors(t *testing.T) {
+	if w := get(t, &HTTPServer{}, "/admin/metrics/history?m=a", false); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without history, got %d", w.Code)
+	}
+	s := &HTTPServer{history: newMetricHistory(10, time.Minute, nil)}
+	for _, query := range []string{"m=a&from=yesterday", "m=a&to=x", "m=a&fn=sum"} {
+		if w := get(t, s, "/admin/metrics/history?"+query, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestMetricHistoryGatherers(t *testing.T) {
+	app, lib := prometheus.NewRegistry(), prometheus.NewRegistry()
+	appPool := prometheus.NewGauge(prometheus.GaugeOpts{Name: "pool_size"})
+	libPool := prometheus.NewGauge(prometheus.GaugeOpts{Name: "pool_size"})
+	app.MustRegister(appPool)
+	lib.MustRegister(libPool, prometheus.NewGauge(prometheus.GaugeOpts{Name: "lib_only"}))
+	appPool.Set(1)
+	libPool.Set(2)
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: app}, NamedGatherer{Name: "lib", Gatherer: lib})
+
+	h := newMetricHistory(10, time.Minute, nil)
+	now := time.Now()
+	for _, g := range namedGatherers() {
+		mfs, _ := g.Gatherer.Gather()
+		h.sample(now, g.Name, mfs)
+	}
+	s := &HTTPServer{history: h}
+	// The series exported by both is only recorded from the first.
+	entries := queryHistory(t, s, "m=pool_size&m=lib_only")
+	if len(entries) != 2 || entries[1].Name != "pool_size" || len(entries[1].Points) != 1 || entries[1].Points[0][1] != 1 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	if entries := queryHistory(t, s, "m=pool_size&m=lib_only&g=lib"); len(entries) != 1 || entries[0].Name != "lib_only" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
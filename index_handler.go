@@ -97,13 +97,13 @@ func renderNav(nav entrySlice, uri string) string {
 			}
 			collapse := ""
 			if isActive {
-				collapse = "fa-caret-square-up"
+				collapse = "fa-caret-square-o-up"
 			}
 
 			builder.WriteString(fmt.Sprintf(`
 <li class="subnav %s">
 	<div class="subnav-title selectable">
-		<span class="fas fa-caret-square-right %s"></span>
+		<span class="fa fa-caret-square-o-right %s"></span>
 		<span>%s</span>
 	</div>
 	<ul>%s</ul>
@@ -122,14 +122,14 @@ func render(title, uri string, nav entrySlice, contents io.Reader) io.Reader {
 	<head>
 		<title>%s &middot; TM Server Admin</title>
 		<!-- css -->
-		<link type="text/css" href="https://stackpath.bootstrapcdn.com/bootstrap/4.3.1/css/bootstrap.min.css" rel="stylesheet"/>
+		<link type="text/css" href="/admin/files/css/bootstrap.min.css" rel="stylesheet"/>
 		<link type="text/css" href="/admin/files/css/index.css" rel="stylesheet"/>
 		<link type="text/css" href="/admin/files/css/client-registry.css" rel="stylesheet"/>
-		<link rel="stylesheet" href="https://use.fontawesome.com/releases/v5.8.1/css/all.css" integrity="sha384-50oBUHEmvpQ+1lW4y57PTFmhCaXp0ML5d60M1M7uH2+nqUivzIebhndOJK28anvf" crossorigin="anonymous">
+		<link type="text/css" href="/admin/files/css/font-awesome.min.css" rel="stylesheet"/>
 		<!-- js -->
-		<script type="application/javascript" src="//www.google.com/jsapi"></script>
-		<script type="application/javascript" src="https://ajax.googleapis.com/ajax/libs/jquery/3.4.0/jquery.min.js"></script>
-		<script type="application/javascript" src="https://stackpath.bootstrapcdn.com/bootstrap/4.3.1/js/bootstrap.min.js"></script>
+		<script type="application/javascript" src="/admin/files/js/jquery.min.js"></script>
+		<script type="application/javascript" src="/admin/files/js/bootstrap.bundle.min.js"></script>
+		<script type="application/javascript" src="/admin/files/js/line-chart.js"></script>
 		<script type="application/javascript" src="/admin/files/js/index.js"></script>
 		<script type="application/javascript" src="/admin/files/js/utils.js"></script>
 	</head>
@@ -138,7 +138,7 @@ func render(title, uri string, nav entrySlice, contents io.Reader) io.Reader {
 			<nav class="nav" id="sidebar">
 				<ul>%s</ul>
 			</nav>
-			<div id="toggle"><span class="fas fa-angle-left"></span></div>
+			<div id="toggle"><span class="fa fa-angle-left"></span></div>
 			<div id="contents">
 				<div class="row">
 					<div class="col-md-12">`, title, renderNav(nav, uri))),
@@ -151,10 +151,16 @@ func render(title, uri string, nav entrySlice, contents io.Reader) io.Reader {
 </html>`))
 }
 
+// contentSecurityPolicy is sent with every rendered page. All scripts, styles & fonts are served from
+// the embedded assets under FilesPath, so nothing needs to be loaded from elsewhere, and inline scripts
+// & styles are never needed.
+const contentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; font-src 'self'; img-src 'self' data:; " +
+	"connect-src 'self'; form-action 'self'; base-uri 'none'; frame-ancestors 'none'"
+
 type indexView struct {
 	title   string
 	next    http.Handler
-	entries func() entrySlice
+	entries func(r *http.Request) entrySlice
 }
 
 type cachingResponseWriter struct {
@@ -190,7 +196,7 @@ func (i *indexView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		cw := mkCachingResponseWriter(w)
 		i.next.ServeHTTP(cw, r)
 
-		entries := i.entries()
+		entries := i.entries(r)
 		sort.Stable(entries)
 		contentType := cw.Header().Get("Content-Type")
 		content := cw.buffer.String()
@@ -200,6 +206,8 @@ func (i *indexView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			io.Copy(w, cw.buffer)
 		} else {
 			w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+			w.Header().Set("Content-Security-Policy", contentSecurityPolicy)
+			w.WriteHeader(cw.statusCode)
 			io.Copy(w, render(i.title, r.URL.Path, entries, cw.buffer))
 		}
 	}
@@ -224,24 +232,22 @@ func expectsHTML(r *http.Request) bool {
 
 // SummaryHandler renders the front-page content.
 func SummaryHandler(w http.ResponseWriter, r *http.Request) {
-	procInfo := []string{"process_uptime", "go_goroutines", "go_memstats_alloc_bytes", "go_gc_duration_seconds"}
-
 	var b bytes.Buffer
 	b.WriteString(`<script type="application/javascript" src="/admin/files/js/summary.js"></script>
       <link type="text/css" href="/admin/files/css/summary.css" rel="stylesheet">
       <div id="lint-warnings" data-refresh-uri="/admin/failedlint"></div>
-      <div id="process-info" class="text-center card" data-refresh-uri="/admin/metrics">
+      <div id="process-info" class="text-center card" data-refresh-uri="/admin/runtime/metrics.json">
         <ul class="list-inline">
-          <li class="list-inline-item"><span class="fas fa-info-circle"/></li>`)
+          <li class="list-inline-item"><span class="fa fa-info-circle"/></li>`)
 
-	for _, key := range procInfo {
+	for _, name := range currentSummaryMetrics() {
 		b.WriteString(fmt.Sprintf(`<li class="list-inline-item" data-key="%s">
                     <div>
-                      <a href="/admin/metrics#%s">%s:</a>
-                      <span id="%s">...</span>
+                      <a href="/admin/runtime/metrics#%s">%s:</a>
+                      <span>...</span>
                       &middot;
                     </div>
-                  </li>`, key, key, key, strings.Replace(key, "/", "-", -1)) + "\n")
+                  </li>`, name, name, name[:strings.LastIndex(name, ":")]) + "\n")
 	}
 	b.WriteString(`<br />
         </ul>
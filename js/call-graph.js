@@ -0,0 +1,160 @@
+/* global $ */
+/* global svgElement */
+/* global SVG_NS */
+/* global formatSampleValue */
+
+$(document).ready(function() {
+  const element = $('#call-graph');
+  if (element.length === 0) {
+    return;
+  }
+  $.ajax({
+    url: element.data('uri'),
+    dataType: 'json',
+    cache: false,
+    success(view) {
+      new CallGraph(element[0], view.graph, view.unit, view.total).draw();
+    },
+  });
+});
+
+// A call graph of a profile's functions, laid out in layers by their distance from the callers at the top.
+// Boxes are sized by the function's cumulative value, and edges by the value of the calls along them.
+function CallGraph(element, graph, unit, total) {
+  this.element = element;
+  this.nodes = graph.nodes || [];
+  this.edges = graph.edges || [];
+  this.unit = unit;
+  this.total = total || 1;
+  this.options = {nodeWidth: 180, nodeHeight: 44, columnGap: 20, rowGap: 50, padding: 10, fontSize: 11};
+}
+
+// layers assigns each node to a layer by breadth-first search from those that aren't called by any others.
+// Nodes only reachable via cycles start a new search from the one with the largest cumulative value.
+CallGraph.prototype.layers = function() {
+  const callees = {};
+  const called = {};
+  this.edges.forEach(function(e) {
+    (callees[e.from] = callees[e.from] || []).push(e.to);
+    called[e.to] = true;
+  });
+  const layer = {};
+  let queue = this.nodes
+    .filter(function(n) {
+      return !called[n.name];
+    })
+    .map(function(n) {
+      layer[n.name] = 0;
+      return n.name;
+    });
+  for (;;) {
+    while (queue.length > 0) {
+      const name = queue.shift();
+      (callees[name] || []).forEach(function(to) {
+        if (layer[to] === undefined) {
+          layer[to] = layer[name] + 1;
+          queue.push(to);
+        }
+      });
+    }
+    const unreached = this.nodes.filter(function(n) {
+      return layer[n.name] === undefined;
+    });
+    if (unreached.length === 0) {
+      break;
+    }
+    // Nodes are ordered by cumulative value, so the first is the largest.
+    layer[unreached[0].name] = 0;
+    queue = [unreached[0].name];
+  }
+  const layers = [];
+  this.nodes.forEach(function(n) {
+    (layers[layer[n.name]] = layers[layer[n.name]] || []).push(n);
+  });
+  return layers;
+};
+
+// shortName strips the package path from a function name.
+function shortName(name) {
+  return name.substring(name.lastIndexOf('/') + 1);
+}
+
+CallGraph.prototype.draw = function() {
+  const o = this.options;
+  const layers = this.layers();
+  const positions = {};
+  let width = 0;
+  layers.forEach(function(nodes, row) {
+    nodes.forEach(function(n, column) {
+      positions[n.name] = {
+        x: o.padding + column * (o.nodeWidth + o.columnGap),
+        y: o.padding + row * (o.nodeHeight + o.rowGap),
+      };
+    });
+    width = Math.max(width, o.padding * 2 + nodes.length * (o.nodeWidth + o.columnGap));
+  });
+  const svg = document.createElementNS(SVG_NS, 'svg');
+  svg.setAttribute('width', width);
+  svg.setAttribute('height', o.padding * 2 + layers.length * (o.nodeHeight + o.rowGap));
+
+  const self = this;
+  this.edges.forEach(function(e) {
+    const from = positions[e.from];
+    const to = positions[e.to];
+    const x1 = from.x + o.nodeWidth / 2;
+    const x2 = to.x + o.nodeWidth / 2;
+    let d;
+    if (to.y > from.y) {
+      const y1 = from.y + o.nodeHeight;
+      d = 'M' + x1 + ',' + y1 + ' C' + x1 + ',' + (y1 + o.rowGap / 2) + ' ' + x2 + ',' + (to.y - o.rowGap / 2) + ' ' + x2 + ',' + to.y;
+    } else {
+      // Calls back up the graph, or within a layer, loop around the side of the boxes.
+      const y1 = from.y + o.nodeHeight / 2;
+      const y2 = to.y + o.nodeHeight / 2;
+      const side = Math.max(from.x, to.x) + o.nodeWidth + o.columnGap / 2;
+      d = 'M' + (from.x + o.nodeWidth) + ',' + y1 + ' C' + side + ',' + y1 + ' ' + side + ',' + y2 + ' ' + (to.x + o.nodeWidth) + ',' + y2;
+    }
+    const path = svgElement('path', {
+      d,
+      fill: 'none',
+      stroke: '#999',
+      'stroke-width': 1 + (5 * e.value) / self.total,
+      class: 'call-graph-edge',
+    });
+    path.appendChild(svgElement('title', {}, e.from + ' → ' + e.to + '\n' + formatSampleValue(e.value, self.unit)));
+    svg.appendChild(path);
+  });
+
+  this.nodes.forEach(function(n) {
+    const p = positions[n.name];
+    const g = svgElement('g', {class: 'call-graph-node'});
+    const heat = Math.min(n.flat / self.total, 1);
+    g.appendChild(
+      svgElement('rect', {
+        x: p.x,
+        y: p.y,
+        width: o.nodeWidth,
+        height: o.nodeHeight,
+        rx: 3,
+        fill: 'hsl(10, ' + Math.round(20 + 70 * heat) + '%, ' + Math.round(92 - 30 * heat) + '%)',
+        stroke: '#666',
+      })
+    );
+    const text = {x: p.x + 5, 'font-size': o.fontSize};
+    g.appendChild(svgElement('text', Object.assign({y: p.y + 16, 'font-weight': 'bold'}, text), shortName(n.name)));
+    g.appendChild(
+      svgElement(
+        'text',
+        Object.assign({y: p.y + 34}, text),
+        formatSampleValue(n.flat, self.unit) + ' of ' + formatSampleValue(n.cum, self.unit)
+      )
+    );
+    g.appendChild(
+      svgElement('title', {}, n.name + '\nflat ' + formatSampleValue(n.flat, self.unit) + ', cum ' + formatSampleValue(n.cum, self.unit))
+    );
+    svg.appendChild(g);
+  });
+  $(this.element)
+    .empty()
+    .append(svg);
+};
@@ -1,5 +1,8 @@
 /* global $ */
-/* global google */
+/* global LineChart */
+
+// The number of samples displayed on each chart.
+const CHART_ROWS = 60;
 
 function ChartRenderer(element, title) {
   const header = $('<h5></h5>')
@@ -12,49 +15,54 @@ function ChartRenderer(element, title) {
     .append(header);
   $(element).append(chartDiv);
 
-  this.columns = {};
-
-  this.chart = new google.visualization.LineChart(chartDiv);
-  this.chartData = new google.visualization.DataTable();
-  this.chartData.addColumn('datetime', 'Time');
-  this.chartData.addRows(60);
-  this.chartOptions = {
-    legend: {position: 'none'},
-    width: '100%',
-    height: 350,
-    chartArea: {width: '90%', height: '85%'},
-    vAxis: {
-      viewWindow: {min: 0},
-      baselineColor: '#ddd',
-      gridlineColor: '#ddd',
-    },
-    hAxis: {
-      baselineColor: '#ddd',
-      gridlineColor: '#ddd',
-      textStyle: {
-        fontSize: 12,
-      },
-    },
-  };
-
-  this.chart.draw(this.chartData, this.chartOptions);
+  this.columns = [];
+  this.rows = [];
+
+  this.chart = new LineChart(chartDiv, {height: 350});
+  this.chart.draw([]);
 }
 
 ChartRenderer.prototype.appendMetric = function(metrics) {
   const row = [new Date()];
   const c = this;
   metrics.forEach(function(metric) {
-    if (!(metric.name in c.columns)) {
-      c.columns[metric.name] = true;
-      c.chartData.addColumn('number', metric.name);
+    let i = c.columns.indexOf(metric.name);
+    if (i === -1) {
+      i = c.columns.push(metric.name) - 1;
     }
-    row.push(metric.value);
+    row[i + 1] = metric.value;
   });
   this.addRow(row);
 };
 
 ChartRenderer.prototype.addRow = function(row) {
-  this.chartData.addRow(row);
-  this.chartData.removeRow(0);
-  this.chart.draw(this.chartData, this.chartOptions);
+  this.rows.push(row);
+  if (this.rows.length > CHART_ROWS) {
+    this.rows.shift();
+  }
+  const rows = this.rows;
+  this.chart.draw(
+    this.columns.map(function(name, i) {
+      return {
+        name,
+        points: rows.map(function(r) {
+          return {x: r[0], y: r[i + 1] === undefined ? null : r[i + 1]};
+        }),
+      };
+    })
+  );
+};
+
+// setSeries replaces the chart's data with the given series, as returned by /admin/metrics/history.
+ChartRenderer.prototype.setSeries = function(series) {
+  this.chart.draw(
+    series.map(function(s) {
+      return {
+        name: s.name,
+        points: s.points.map(function(p) {
+          return {x: new Date(p[0]), y: p[1]};
+        }),
+      };
+    })
+  );
 };
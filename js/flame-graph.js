@@ -0,0 +1,116 @@
+/* global $ */
+
+$(document).ready(function() {
+  const element = $('#flame-graph');
+  if (element.length === 0) {
+    return;
+  }
+  $.ajax({
+    url: element.data('uri'),
+    dataType: 'json',
+    cache: false,
+    success(view) {
+      new FlameGraph(element[0], view.flame, view.unit).draw();
+    },
+  });
+});
+
+// formatSampleValue formats a profile's sample value according to its unit, as the server does.
+function formatSampleValue(value, unit) {
+  if (unit === 'nanoseconds') {
+    const units = [[3600e9, 'h'], [60e9, 'm'], [1e9, 's'], [1e6, 'ms'], [1e3, 'µs']];
+    for (let i = 0; i < units.length; i++) {
+      if (Math.abs(value) >= units[i][0]) {
+        return (value / units[i][0]).toFixed(2) + units[i][1];
+      }
+    }
+    return value + 'ns';
+  } else if (unit === 'bytes') {
+    const units = [[1 << 30, 'GiB'], [1 << 20, 'MiB'], [1 << 10, 'KiB']];
+    for (let i = 0; i < units.length; i++) {
+      if (Math.abs(value) >= units[i][0]) {
+        return (value / units[i][0]).toFixed(1) + ' ' + units[i][1];
+      }
+    }
+    return value + ' B';
+  }
+  return String(value);
+}
+
+// A flame graph of a profile, drawn root first as rows of frames whose widths are proportional to their values.
+// Clicking a frame zooms in on it; its ancestors are drawn across the full width above it, to zoom back out.
+function FlameGraph(element, root, unit) {
+  this.element = element;
+  this.root = root;
+  this.unit = unit;
+  this.rowHeight = 18;
+  this.minWidth = 0.001;
+  (function setParents(node) {
+    (node.children || []).forEach(function(child) {
+      child.parent = node;
+      setParents(child);
+    });
+  })(root);
+}
+
+// colour picks a warm colour for a function, consistent across redraws.
+FlameGraph.prototype.colour = function(name) {
+  let hash = 0;
+  for (let i = 0; i < name.length; i++) {
+    hash = (hash * 31 + name.charCodeAt(i)) | 0;
+  }
+  hash = Math.abs(hash);
+  return 'hsl(' + (hash % 50) + ', ' + (60 + (hash % 30)) + '%, ' + (55 + (hash % 15)) + '%)';
+};
+
+FlameGraph.prototype.frame = function(node, depth, left, width, zoomed) {
+  const self = this;
+  const total = this.root.value || 1;
+  const percent = ((100 * node.value) / total).toFixed(2);
+  return $('<div class="flame-frame"></div>')
+    .toggleClass('flame-ancestor', !zoomed)
+    .text(node.name)
+    .attr('title', node.name + '\n' + formatSampleValue(node.value, this.unit) + ' (' + percent + '%)')
+    .css({
+      top: depth * this.rowHeight + 'px',
+      left: left * 100 + '%',
+      width: width * 100 + '%',
+      'background-color': zoomed ? this.colour(node.name) : '',
+    })
+    .on('click', function() {
+      self.draw(node);
+    });
+};
+
+// draw draws the graph zoomed in on the given node, or the root if it's not given.
+FlameGraph.prototype.draw = function(focus) {
+  focus = focus || this.root;
+  const frames = [];
+  const ancestors = [];
+  for (let n = focus.parent; n !== undefined; n = n.parent) {
+    ancestors.unshift(n);
+  }
+  ancestors.forEach(function(n, depth) {
+    frames.push(this.frame(n, depth, 0, 1, false));
+  }, this);
+  let depth = ancestors.length;
+  const scale = focus.value || 1;
+  const self = this;
+  (function add(node, d, left) {
+    const width = node.value / scale;
+    if (width < self.minWidth) {
+      return;
+    }
+    frames.push(self.frame(node, d, left, width, true));
+    depth = Math.max(depth, d);
+    let childLeft = left;
+    (node.children || []).forEach(function(child) {
+      add(child, d + 1, childLeft);
+      childLeft += child.value / scale;
+    });
+  })(focus, depth, 0);
+  $(this.element)
+    .empty()
+    .css('height', (depth + 1) * this.rowHeight + 'px')
+    .append(frames);
+};
@@ -0,0 +1,114 @@
+/* global svgElement */
+/* global formatTime */
+/* global SVG_NS */
+
+// A heatmap of histogram observations over time. Each column is a sample, each row a bucket, and the
+// shade of each cell is the number of observations that fell in that bucket since the previous sample.
+function Heatmap(element, options) {
+  this.options = Object.assign(
+    {
+      height: 250,
+      columns: 60,
+      padding: {top: 10, right: 15, bottom: 25, left: 70},
+      colour: '#3366cc',
+      textColour: '#666',
+      fontSize: 12,
+    },
+    options
+  );
+  this.svg = document.createElementNS(SVG_NS, 'svg');
+  this.svg.setAttribute('width', '100%');
+  this.svg.setAttribute('height', this.options.height);
+  element.appendChild(this.svg);
+  this.bounds = [];
+  this.columns = [];
+  this.previous = undefined;
+}
+
+// append adds a sample of cumulative bucket counts, [{le: string, count: number}], ordered by bound.
+Heatmap.prototype.append = function(time, buckets) {
+  const counts = buckets.map(function(b) {
+    return b.count;
+  });
+  if (this.previous !== undefined && this.previous.length === counts.length) {
+    const prev = this.previous;
+    // Convert cumulative counts to the number of new observations in each bucket.
+    const deltas = counts.map(function(c, i) {
+      const below = i === 0 ? 0 : counts[i - 1] - prev[i - 1];
+      return Math.max(c - prev[i] - below, 0);
+    });
+    this.columns.push({time, deltas});
+    if (this.columns.length > this.options.columns) {
+      this.columns.shift();
+    }
+  }
+  this.bounds = buckets.map(function(b) {
+    return b.le;
+  });
+  this.previous = counts;
+  this.draw();
+};
+
+Heatmap.prototype.draw = function() {
+  const o = this.options;
+  const width = this.svg.getBoundingClientRect().width || 600;
+  const plotWidth = Math.max(width - o.padding.left - o.padding.right, 1);
+  const plotHeight = Math.max(o.height - o.padding.top - o.padding.bottom, 1);
+  while (this.svg.firstChild) {
+    this.svg.removeChild(this.svg.firstChild);
+  }
+  const text = {fill: o.textColour, 'font-size': o.fontSize};
+  const rows = this.bounds.length;
+  if (rows === 0) return;
+
+  const cellWidth = plotWidth / o.columns;
+  const cellHeight = plotHeight / rows;
+  let max = 0;
+  this.columns.forEach(function(c) {
+    c.deltas.forEach(function(d) {
+      max = Math.max(max, d);
+    });
+  });
+
+  // Only label as many rows as fit.
+  const labelEvery = Math.ceil((rows * o.fontSize * 1.5) / plotHeight);
+  for (let i = 0; i < rows; i += labelEvery) {
+    const y = o.padding.top + plotHeight - (i + 0.5) * cellHeight;
+    this.svg.appendChild(
+      svgElement('text', Object.assign({x: o.padding.left - 5, y: y + 4, 'text-anchor': 'end'}, text), '≤ ' + this.bounds[i])
+    );
+  }
+
+  const offset = o.columns - this.columns.length;
+  const svg = this.svg;
+  const bounds = this.bounds;
+  this.columns.forEach(function(c, col) {
+    c.deltas.forEach(function(d, row) {
+      if (d === 0) return;
+      const cell = svgElement('rect', {
+        x: o.padding.left + (offset + col) * cellWidth,
+        y: o.padding.top + plotHeight - (row + 1) * cellHeight,
+        width: Math.max(cellWidth - 1, 1),
+        height: Math.max(cellHeight - 1, 1),
+        fill: o.colour,
+        'fill-opacity': (0.15 + (0.85 * d) / max).toFixed(2),
+      });
+      cell.appendChild(svgElement('title', {}, formatTime(c.time) + ': ' + d + ' in ≤ ' + bounds[row]));
+      svg.appendChild(cell);
+    });
+  });
+  if (this.columns.length > 0) {
+    const first = this.columns[0].time;
+    const last = this.columns[this.columns.length - 1].time;
+    this.svg.appendChild(
+      svgElement(
+        'text',
+        Object.assign({x: o.padding.left + offset * cellWidth, y: o.height - 5, 'text-anchor': 'start'}, text),
+        formatTime(first)
+      )
+    );
+    this.svg.appendChild(
+      svgElement('text', Object.assign({x: o.padding.left + plotWidth, y: o.height - 5, 'text-anchor': 'end'}, text), formatTime(last))
+    );
+  }
+};
@@ -0,0 +1,78 @@
+/* global $ */
+/* global ChartRenderer */
+/* global Heatmap */
+
+// Renders histograms & summaries as a chart of their percentiles over time and, for histograms,
+// a heatmap of their buckets. It has the same interface as ChartRenderer.
+function HistogramRenderer(element, title) {
+  const percentileDiv = $('<div></div>')[0];
+  const heatmapDiv = $('<div></div>')[0];
+  $(element)
+    .empty()
+    .append(percentileDiv)
+    .append(heatmapDiv);
+
+  this.percentiles = new ChartRenderer(percentileDiv, title + ' percentiles');
+  this.heatmapDiv = heatmapDiv;
+  this.heatmap = undefined;
+}
+
+HistogramRenderer.prototype.appendMetric = function(metrics) {
+  const values = [];
+  const prefix = function(metric) {
+    return metrics.length > 1 ? metric.name + ' ' : '';
+  };
+  metrics.forEach(function(metric) {
+    if (metric.percentiles !== undefined) {
+      Object.keys(metric.percentiles)
+        .sort()
+        .forEach(function(p) {
+          values.push({name: prefix(metric) + p, value: metric.percentiles[p]});
+        });
+    }
+    (metric.quantiles || []).forEach(function(q) {
+      values.push({name: prefix(metric) + 'q' + q.quantile, value: q.value});
+    });
+  });
+  this.percentiles.appendMetric(values);
+  this.appendBuckets(metrics);
+};
+
+// appendBuckets adds the buckets of all the series to the heatmap, summing those with the same bounds.
+HistogramRenderer.prototype.appendBuckets = function(metrics) {
+  const totals = {};
+  const bounds = [];
+  metrics.forEach(function(metric) {
+    if (metric.buckets === undefined) return;
+    // Observations above the highest bucket are only included in the total count.
+    const buckets = metric.buckets.concat(
+      metric.buckets.length > 0 && metric.buckets[metric.buckets.length - 1].le === '+Inf'
+        ? []
+        : [{le: '+Inf', count: metric.count}]
+    );
+    buckets.forEach(function(b) {
+      if (!(b.le in totals)) {
+        totals[b.le] = 0;
+        bounds.push(b.le);
+      }
+      totals[b.le] += b.count;
+    });
+  });
+  if (bounds.length === 0) return;
+  if (this.heatmap === undefined) {
+    $('<h5></h5>')
+      .addClass('text-center')
+      .text('Latency heatmap')
+      .appendTo(this.heatmapDiv);
+    this.heatmap = new Heatmap(this.heatmapDiv);
+  }
+  bounds.sort(function(a, b) {
+    return parseFloat(a) - parseFloat(b);
+  });
+  this.heatmap.append(
+    new Date(),
+    bounds.map(function(le) {
+      return {le, count: totals[le]};
+    })
+  );
+};
@@ -13,8 +13,8 @@ $(document).ready(function() {
       .find('ul')
       .slideToggle();
     $(this)
-      .find('.fas')
-      .toggleClass('fa-caret-square-up');
+      .find('.fa')
+      .toggleClass('fa-caret-square-o-up');
   });
 
   const confirmationLinks = {
@@ -0,0 +1,133 @@
+/* global $ */
+
+const AGGREGATIONS = ['sum', 'avg', 'min', 'max', 'count'];
+
+// LabelPicker renders controls to filter the series of a metric family by their labels and to aggregate them.
+// params are added to requests for labels. onChange is called with the resulting query, {selector, agg, by},
+// whenever it changes.
+function LabelPicker(element, labelsUri, params, onChange) {
+  this.element = element;
+  this.labelsUri = labelsUri;
+  this.params = params;
+  this.onChange = onChange;
+  this.generation = 0;
+}
+
+// quoteLabelValue quotes a label value for use in a selector. JSON's escaping is a subset of what the server accepts.
+function quoteLabelValue(value) {
+  return JSON.stringify(value);
+}
+
+// describeQuery returns a description of a query, in the style of a Prometheus expression.
+function describeQuery(query) {
+  if (!query.agg) return query.selector;
+  const by = query.by.length > 0 ? ' by (' + query.by.join(', ') + ')' : '';
+  return query.agg + by + ' (' + query.selector + ')';
+}
+
+// load shows the labels of the given family, and calls onChange with a query of all its series.
+LabelPicker.prototype.load = function(family) {
+  const picker = this;
+  const current = ++this.generation;
+  this.family = family;
+  $(this.element).empty();
+  this.onChange({selector: family, agg: '', by: []});
+  $.ajax({
+    url: this.labelsUri + '?' + $.param($.extend({m: family}, this.params)),
+    dataType: 'text',
+    success: function(data) {
+      if (current === picker.generation) picker.render($.parseJSON(data));
+    },
+  });
+};
+
+LabelPicker.prototype.render = function(labels) {
+  const picker = this;
+  const form = $('<form></form>').addClass('form-inline mb-2');
+  const names = Object.keys(labels).sort();
+  const select = function(name, options) {
+    const s = $('<select></select>')
+      .addClass('form-control form-control-sm mr-2 mb-1')
+      .attr('name', name);
+    options.forEach(function(o) {
+      s.append(
+        $('<option></option>')
+          .attr('value', o.value)
+          .text(o.text)
+      );
+    });
+    return s;
+  };
+
+  const filters = names.map(function(name) {
+    return select(
+      name,
+      [{value: '', text: name + ': any'}].concat(
+        labels[name].map(function(v) {
+          return {value: v, text: name + '=' + v};
+        })
+      )
+    );
+  });
+  const agg = select(
+    'agg',
+    [{value: '', text: 'no aggregation'}].concat(
+      AGGREGATIONS.map(function(a) {
+        return {value: a, text: a};
+      })
+    )
+  );
+  const by = select(
+    'by',
+    names.map(function(name) {
+      return {value: name, text: 'by ' + name};
+    })
+  )
+    .attr('multiple', 'multiple')
+    .attr('title', 'Labels to aggregate by')
+    .prop('disabled', true);
+  const selector = $('<input type="text">')
+    .addClass('form-control form-control-sm mr-2 mb-1 flex-grow-1')
+    .attr('title', 'Selector, e.g. ' + this.family + '{code=~"5.."}')
+    .val(this.family);
+
+  const query = function() {
+    return {selector: selector.val(), agg: agg.val(), by: agg.val() ? by.val() || [] : []};
+  };
+  // Changing a label filter rebuilds the selector from all of them.
+  filters.forEach(function(f) {
+    f.on('change', function() {
+      const matchers = filters
+        .filter(function(f) {
+          return f.val() !== '';
+        })
+        .map(function(f) {
+          return f.attr('name') + '=' + quoteLabelValue(f.val());
+        });
+      selector.val(picker.family + (matchers.length > 0 ? '{' + matchers.join(',') + '}' : ''));
+      picker.onChange(query());
+    });
+  });
+  agg.on('change', function() {
+    by.prop('disabled', !agg.val());
+    picker.onChange(query());
+  });
+  by.on('change', function() {
+    picker.onChange(query());
+  });
+  // The selector can also be edited directly, e.g. to use regex matchers.
+  form.on('submit', function(e) {
+    e.preventDefault();
+    picker.onChange(query());
+  });
+
+  form.append(selector);
+  filters.forEach(function(f) {
+    form.append(f);
+  });
+  form.append(agg);
+  if (names.length > 0) form.append(by);
+  $(this.element)
+    .empty()
+    .append(form);
+};
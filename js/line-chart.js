@@ -0,0 +1,139 @@
+// A small, dependency-free SVG line chart. It's served from the embedded assets so that charts
+// work without access to externally hosted charting libraries, and only uses presentation
+// attributes (never inline styles) so that it's compatible with the admin pages' CSP.
+
+const SVG_NS = 'http://www.w3.org/2000/svg';
+const LINE_COLOURS = ['#3366cc', '#dc3912', '#ff9900', '#109618', '#990099', '#0099c6', '#dd4477', '#66aa00'];
+
+function LineChart(element, options) {
+  this.options = Object.assign(
+    {
+      height: 350,
+      ticks: 5,
+      padding: {top: 10, right: 15, bottom: 25, left: 70},
+      gridColour: '#ddd',
+      textColour: '#666',
+      fontSize: 12,
+    },
+    options
+  );
+  this.svg = document.createElementNS(SVG_NS, 'svg');
+  this.svg.setAttribute('width', '100%');
+  this.svg.setAttribute('height', this.options.height);
+  element.appendChild(this.svg);
+}
+
+function svgElement(name, attrs, text) {
+  const elem = document.createElementNS(SVG_NS, name);
+  for (const key in attrs) {
+    if (attrs.hasOwnProperty(key)) {
+      elem.setAttribute(key, attrs[key]);
+    }
+  }
+  if (text !== undefined) {
+    elem.textContent = text;
+  }
+  return elem;
+}
+
+// niceStep returns a round number close to the given step between axis ticks.
+function niceStep(step) {
+  if (!(step > 0)) return 1;
+  const magnitude = Math.pow(10, Math.floor(Math.log10(step)));
+  const fraction = step / magnitude;
+  if (fraction <= 1) return magnitude;
+  if (fraction <= 2) return 2 * magnitude;
+  if (fraction <= 5) return 5 * magnitude;
+  return 10 * magnitude;
+}
+
+function formatValue(value) {
+  if (Math.abs(value) >= 1e9) return +(value / 1e9).toFixed(2) + 'G';
+  if (Math.abs(value) >= 1e6) return +(value / 1e6).toFixed(2) + 'M';
+  if (Math.abs(value) >= 1e3) return +(value / 1e3).toFixed(2) + 'k';
+  return String(+value.toPrecision(4));
+}
+
+function formatTime(date) {
+  return date.toTimeString().substring(0, 8);
+}
+
+// draw renders the given series, each of which is {name: string, points: [{x: Date, y: number or null}]}.
+// Null values leave a gap in the line. The y axis always starts at zero.
+LineChart.prototype.draw = function(series) {
+  const o = this.options;
+  const width = this.svg.getBoundingClientRect().width || 600;
+  const plotWidth = Math.max(width - o.padding.left - o.padding.right, 1);
+  const plotHeight = Math.max(o.height - o.padding.top - o.padding.bottom, 1);
+
+  let minX = Infinity;
+  let maxX = -Infinity;
+  let maxY = 0;
+  series.forEach(function(s) {
+    s.points.forEach(function(p) {
+      minX = Math.min(minX, p.x.getTime());
+      maxX = Math.max(maxX, p.x.getTime());
+      if (p.y !== null && isFinite(p.y)) maxY = Math.max(maxY, p.y);
+    });
+  });
+  if (minX === Infinity) {
+    maxX = Date.now();
+    minX = maxX - 60000;
+  } else if (minX === maxX) {
+    minX -= 1000;
+  }
+  const step = niceStep((maxY || 1) / o.ticks);
+  maxY = Math.max(Math.ceil(maxY / step), 1) * step;
+
+  const x = function(t) {
+    return o.padding.left + ((t - minX) / (maxX - minX)) * plotWidth;
+  };
+  const y = function(v) {
+    return o.padding.top + plotHeight - (v / maxY) * plotHeight;
+  };
+
+  while (this.svg.firstChild) {
+    this.svg.removeChild(this.svg.firstChild);
+  }
+  const text = {fill: o.textColour, 'font-size': o.fontSize};
+
+  for (let v = 0; v <= maxY + step / 2; v += step) {
+    this.svg.appendChild(
+      svgElement('line', {x1: o.padding.left, x2: o.padding.left + plotWidth, y1: y(v), y2: y(v), stroke: o.gridColour})
+    );
+    this.svg.appendChild(
+      svgElement('text', Object.assign({x: o.padding.left - 5, y: y(v) + 4, 'text-anchor': 'end'}, text), formatValue(v))
+    );
+  }
+  const xTicks = Math.max(Math.min(o.ticks, Math.floor(plotWidth / 80)), 1);
+  for (let i = 0; i <= xTicks; i++) {
+    const t = minX + ((maxX - minX) * i) / xTicks;
+    const anchor = i === 0 ? 'start' : i === xTicks ? 'end' : 'middle';
+    this.svg.appendChild(
+      svgElement('text', Object.assign({x: x(t), y: o.height - 5, 'text-anchor': anchor}, text), formatTime(new Date(t)))
+    );
+  }
+
+  const svg = this.svg;
+  series.forEach(function(s, i) {
+    let d = '';
+    let move = true;
+    s.points.forEach(function(p) {
+      if (p.y === null || !isFinite(p.y)) {
+        move = true;
+        return;
+      }
+      d += (move ? 'M' : 'L') + x(p.x.getTime()).toFixed(1) + ',' + y(p.y).toFixed(1);
+      move = false;
+    });
+    if (d === '') return;
+    const path = svgElement('path', {
+      d,
+      fill: 'none',
+      stroke: LINE_COLOURS[i % LINE_COLOURS.length],
+      'stroke-width': 2,
+    });
+    path.appendChild(svgElement('title', {}, s.name));
+    svg.appendChild(path);
+  });
+};
@@ -1,55 +1,152 @@
 /* global $ */
-/* global google */
 /* global ChartRenderer */
+/* global HistogramRenderer */
+/* global LabelPicker */
+/* global describeQuery */
 
-google.load('visualization', '1', {packages: ['corechart'], callback: graphLibLoaded});
+$(document).ready(initCharts);
 
-function graphLibLoaded() {
+function initCharts() {
   const charDiv = $('#chart-div')[0];
   let selected = undefined;
   let interval = {};
 
-  function refreshStats(stat, chartRenderer) {
-    clearInterval(interval);
-    const url = $('#metrics-grid').data('refresh-uri') + '?m=' + stat;
+  const grid = $('#metrics-grid');
+  // Queries are restricted to the selected gatherer, if any. attr is used since data would convert numeric names.
+  const gatherer = grid.attr('data-gatherer');
+  const gathererParams = gatherer ? {g: gatherer} : {};
+  let generation = 0;
 
-    function render(data) {
-      const json = $.parseJSON(data);
-      if (json[0] !== undefined) chartRenderer.appendMetric(json);
+  // refreshStats charts the series of a query, {selector, agg, by}, as returned by LabelPicker.
+  function refreshStats(query) {
+    clearInterval(interval);
+    const stat = describeQuery(query);
+    const params = $.extend({m: query.selector}, gathererParams);
+    if (query.agg) {
+      params.agg = query.agg;
+      params.by = query.by;
     }
+    const url = grid.data('refresh-uri') + '?' + $.param(params, true);
+    const historyUrl = grid.data('history-uri') + '?' + $.param($.extend({fn: 'rate', m: query.selector}, gathererParams));
+    // Responses for previously selected metrics are ignored.
+    const current = ++generation;
+    let chartRenderer = undefined;
+    let useHistory = false;
 
-    interval = setInterval(function() {
+    function fetch(url, success, error) {
       $.ajax({
         url,
         dataType: 'text',
-        success: render,
+        cache: false,
+        success: function(data) {
+          if (current === generation) success($.parseJSON(data));
+        },
+        error: function(xhr) {
+          if (current === generation && error !== undefined) error(xhr);
+        },
       });
-    }, 1000);
+    }
+
+    function poll() {
+      if (useHistory) {
+        fetch(historyUrl, function(json) {
+          chartRenderer.setSeries(json);
+        });
+      } else {
+        fetch(url, function(json) {
+          if (json[0] !== undefined) chartRenderer.appendMetric(json);
+        });
+      }
+    }
+
+    function start(renderer) {
+      chartRenderer = renderer;
+      interval = setInterval(poll, 1000);
+    }
+
+    function showMessage(element) {
+      $(charDiv)
+        .empty()
+        .append(element);
+    }
+
+    fetch(
+      url,
+      function(json) {
+        if (json[0] === undefined) {
+          showMessage(
+            $('<p></p>')
+              .addClass('text-muted text-center')
+              .text('No series match ' + stat)
+          );
+          return;
+        }
+        if (json[0].buckets !== undefined || json[0].quantiles !== undefined) {
+          // Histograms & summaries get a percentile chart rather than a chart of their sums.
+          start(new HistogramRenderer(charDiv, stat));
+          chartRenderer.appendMetric(json);
+          return;
+        }
+        // Other metrics are charted from the server's history if it's being recorded, so that the chart
+        // is populated immediately; otherwise it's built up by polling their current values.
+        // The history isn't aggregated, so aggregations are always polled.
+        if (query.agg) {
+          start(new ChartRenderer(charDiv, stat));
+          chartRenderer.appendMetric(json);
+          return;
+        }
+        fetch(
+          historyUrl,
+          function(history) {
+            const counter = history.some(function(series) {
+              return series.type === 'counter';
+            });
+            useHistory = true;
+            start(new ChartRenderer(charDiv, counter ? stat + ' (per second)' : stat));
+            chartRenderer.setSeries(history);
+          },
+          function() {
+            start(new ChartRenderer(charDiv, stat));
+            chartRenderer.appendMetric(json);
+          }
+        );
+      },
+      function(xhr) {
+        showMessage(
+          $('<div></div>')
+            .addClass('alert alert-warning')
+            .text(xhr.responseText || 'Failed to query ' + stat)
+        );
+      }
+    );
   }
 
+  const picker = new LabelPicker($('#label-picker')[0], grid.data('labels-uri'), gathererParams, refreshStats);
+
+  $('#gatherer').on('change', function() {
+    const g = $(this).val();
+    window.location.search = g ? $.param({g}) : '';
+  });
+
   function render(li) {
-    const stat = li.html();
     if (selected !== undefined) selected.removeClass('selected');
     li.addClass('selected');
     selected = li;
-    refreshStats(stat, new ChartRenderer(charDiv, stat));
+    $(charDiv).empty();
+    picker.load(li.data('metric'));
   }
 
   $('#metrics li').on('click', function(e) {
     render($(e.target));
   });
 
-  const fragmentId = $(
-    '#' +
-      window.location.hash
-        .replace('#', '')
-        .replace(/\//g, '-')
-        // css chars to escape: !"#$%&'()*+,-./:;<=>?@[\]^`{|}~
-        .replace(/(!|"|#|%|&|'|\(|\)|\*|\+|,|-|\.|\/|:|;|<|=|>|\?|@|\[|\\|\]|\^|`|{|\||}|~)/g, '\\$1')
-  );
-  if (fragmentId[0] !== undefined) {
-    $(fragmentId)[0].scrollIntoView(true);
-    render(fragmentId);
+  const family = decodeURIComponent(window.location.hash.replace('#', ''));
+  const fragment = $('#metrics li').filter(function() {
+    return $(this).data('metric') === family;
+  });
+  if (fragment[0] !== undefined) {
+    fragment[0].scrollIntoView(true);
+    render(fragment);
   } else {
     render($('#metrics li:first'));
   }
@@ -1,57 +1,42 @@
 /* global $ */
-/* global google */
 /* global ChartRenderer */
 
-google.load('visualization', '1', {packages: ['corechart'], callback: graphLibLoaded});
+$(document).ready(initCharts);
 
-function graphLibLoaded() {
+function initCharts() {
   let interval = {};
 
-  function refreshStats(dds, chartRenderer) {
+  function refreshStats(pane, chartRenderer) {
     clearInterval(interval);
-    let url = $('#server-tabs').data('refresh-uri') + '?';
-    let label;
-    for (let i = 0; i < dds.length; i++) {
-      const key = $(dds[i]).data('key');
-      url += 'm=' + key + '&';
-      label = $(dds[i]).data('label');
-    }
+    const url = pane.data('refresh-uri');
 
     function render(data) {
       const json = $.parseJSON(data);
-      let failures = 0;
-      let requests = 0;
-      for (let i = 0; i < json.length; i++) {
-        const name = json[i].name;
-        const value = json[i].value;
-        const id = name.replace(/\/|{|}|=/g, '-');
-        if (name.indexOf(label) > -1) {
-          if (name.indexOf('failures') > -1) failures = value;
-          else if (name.indexOf('requests') > -1) requests = value;
-        }
-        $('#' + id).text(value);
-      }
-
-      let sr = 0.0;
-      if (requests > 0) sr = Number(((1.0 - failures / requests) * 100.0).toFixed(4));
-      chartRenderer.appendMetric([{name: '', value: sr}]);
+      pane.find('dd[data-key]').each(function() {
+        const value = json[$(this).data('key')];
+        $(this).text(value === null || value === undefined ? 'N/A' : value);
+      });
+      chartRenderer.appendMetric([{name: 'Success Rate', value: json.success_rate}]);
     }
 
-    interval = setInterval(function() {
+    function fetchStats() {
       $.ajax({
         url,
         dataType: 'text',
+        cache: false,
         success: render,
       });
-    }, 1000);
+    }
+
+    fetchStats();
+    interval = setInterval(fetchStats, 1000);
   }
 
-  $('a[data-toggle="tab"]').on('shown.bs.tab', function() {
-    const active = $('#servers').find('.tab-pane.active');
-    const graphDiv = active.find('#server-graph');
-    const chart = new ChartRenderer(graphDiv[0], 'Success Rate');
-    refreshStats(active.find('dd'), chart);
+  $('#registry-tabs a[data-toggle="tab"]').on('shown.bs.tab', function(e) {
+    const pane = $($(e.target).attr('href'));
+    const chart = new ChartRenderer(pane.find('.registry-graph')[0], 'Success Rate');
+    refreshStats(pane, chart);
   });
 
-  $('#server-tabs a:first').tab('show');
+  $('#registry-tabs a:first').tab('show');
 }
@@ -1,6 +1,4 @@
 /* global $ */
-/* global MsToStringConverter */
-/* global BytesToStringConverter */
 
 const waitForDom = setInterval(function() {
   if ($('#process-info') !== null) {
@@ -18,25 +16,19 @@ function loadProcInfo() {
 
   for (let i = 0; i < list.length; i++) {
     const key = $(list[i]).data('key');
-    if (key !== undefined) url += '&m=' + key;
-  }
-
-  const msToStr = new MsToStringConverter();
-  const bytesToStr = new BytesToStringConverter();
-
-  function pretty(name, value) {
-    if (name === 'process_uptime') return msToStr.convert(value * 1000);
-    else if (name === 'go_memstats_alloc_bytes') return bytesToStr.convert(value);
-    else if (name === 'go_gc_duration_seconds') return msToStr.convert(value * 1000);
-    return value;
+    if (key !== undefined) url += '&m=' + encodeURIComponent(key);
   }
 
+  // Values are formatted according to their units by the server.
   function renderProcInfo(data) {
     const json = $.parseJSON(data);
     for (let i = 0; i < json.length; i++) {
-      const id = json[i].name.replace(/\//g, '-');
-      const value = pretty(json[i].name, json[i].value);
-      $('#' + id).text(value);
+      list
+        .filter(function() {
+          return $(this).data('key') === json[i].name;
+        })
+        .find('span')
+        .text(json[i].formatted);
     }
   }
 
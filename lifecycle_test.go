@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var localOpts = Opts{Host: "127.0.0.1", Port: 0}
+
+func baseURL(t *testing.T, s *HTTPServer) string {
+	t.Helper()
+	addr := s.Addr()
+	if addr == nil {
+		t.Fatal("server is not running")
+	}
+	return "http://" + addr.String()
+}
+
+func TestStartReturnsBindError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), Opts{Host: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}); err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("expected a bind error")
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	audits, traces, limits := s.auditLog(), s.getTraces(), s.getDebugLimiters()
+	opts := localOpts
+	opts.QuitTimeout = time.Minute
+	opts.TraceRetain = 1
+	opts.Authenticator = StaticTokenAuthenticator{}
+	if err := s.Start(context.Background(), opts); err == nil {
+		t.Fatal("expected an error starting a running server")
+	}
+	if s.auditLog() != audits || s.quitTimeout != 0 || s.authenticator != nil || s.getTraces() != traces || s.getDebugLimiters() != limits {
+		t.Error("starting a running server changed its state")
+	}
+}
+
+func TestShutdownDrainsProfile(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	url := baseURL(t, s)
+
+	type result struct {
+		code int
+		size int
+		err  error
+	}
+	results := make(chan result)
+	go func() {
+		resp, err := http.Get(url + "/debug/pprof/profile?seconds=30")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		results <- result{code: resp.StatusCode, size: len(b), err: err}
+	}()
+	time.Sleep(500 * time.Millisecond)
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shutdown took %s", elapsed)
+	}
+	r := <-results
+	if r.err != nil || r.code != http.StatusOK || r.size == 0 {
+		t.Fatalf("profile request was not drained cleanly: %+v", r)
+	}
+	if _, err := http.Get(url + "/admin/ping"); err == nil {
+		t.Fatal("server still accepting requests after shutdown")
+	}
+}
+
+func TestContextCancellationStopsServer(t *testing.T) {
+	s := &HTTPServer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Start(ctx, localOpts); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		s.wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after its context was cancelled")
+	}
+	// It can be started again afterwards.
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown(context.Background())
+}
+
+func TestServeOnceThenShutdown(t *testing.T) {
+	returned := make(chan struct{})
+	go func() {
+		ServeOnce(localOpts)
+		close(returned)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if Addr() != nil {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeOnce did not return after Shutdown")
+	}
+}
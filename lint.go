@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/op/go-logging.v1"
+)
+
+// A LintIssue is a single problem found by a LintRule.
+type LintIssue struct {
+	Message string `json:"message"`
+}
+
+// A LintRule checks the process for a known misconfiguration or problem.
+type LintRule interface {
+	// Name is a short, unique name for the rule.
+	Name() string
+	// Description explains what the rule checks for, and why it matters.
+	Description() string
+	// Check returns the problems found, or nothing if the rule passes.
+	Check() []LintIssue
+}
+
+// NewLintRule returns a LintRule that calls the given function to check.
+func NewLintRule(name, description string, check func() []LintIssue) LintRule {
+	return &lintRule{name: name, description: description, check: check}
+}
+
+type lintRule struct {
+	name, description string
+	check             func() []LintIssue
+}
+
+func (l *lintRule) Name() string        { return l.name }
+func (l *lintRule) Description() string { return l.description }
+func (l *lintRule) Check() []LintIssue  { return l.check() }
+
+var lintRules = struct {
+	sync.Mutex
+	rules []LintRule
+}{rules: []LintRule{
+	NewLintRule("gomaxprocs", "GOMAXPROCS should not exceed the CPU quota of the container, or the process will be throttled.", checkGOMAXPROCS),
+	NewLintRule("goroutines", fmt.Sprintf("More than %d goroutines usually indicates a leak.", goroutineThreshold), checkGoroutines),
+	NewLintRule("debug-logging", "Debug logging is verbose and expensive, and shouldn't be left on in production.", checkDebugLogging),
+}}
+
+// RegisterLintRule registers a rule to be checked by the lint pages. An error is returned if a rule with the same name already exists.
+func RegisterLintRule(rule LintRule) error {
+	lintRules.Lock()
+	defer lintRules.Unlock()
+	for _, r := range lintRules.rules {
+		if r.Name() == rule.Name() {
+			return fmt.Errorf("lint rule %s is already registered", rule.Name())
+		}
+	}
+	lintRules.rules = append(lintRules.rules, rule)
+	return nil
+}
+
+// goroutineThreshold is the number of goroutines above which the goroutines rule fails.
+const goroutineThreshold = 10000
+
+// cgroupRoot is where cgroup filesystems are mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+func checkGOMAXPROCS() []LintIssue {
+	quota, ok := cpuQuota()
+	if !ok {
+		return nil
+	}
+	if procs := runtime.GOMAXPROCS(0); float64(procs) > math.Ceil(quota) {
+		return []LintIssue{{Message: fmt.Sprintf("GOMAXPROCS is %d, but the CPU quota is %.2f CPUs", procs, quota)}}
+	}
+	return nil
+}
+
+// cpuQuota returns the CPU quota of the process' cgroup in CPUs, supporting both cgroup v2 & v1.
+func cpuQuota() (float64, bool) {
+	if b, err := ioutil.ReadFile(filepath.Join(cgroupRoot, "cpu.max")); err == nil {
+		fields := strings.Fields(string(b))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		return parseQuota(fields[0], fields[1])
+	}
+	quota, err1 := ioutil.ReadFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_quota_us"))
+	period, err2 := ioutil.ReadFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_period_us"))
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return parseQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+func parseQuota(quota, period string) (float64, bool) {
+	q, err1 := strconv.ParseFloat(quota, 64)
+	p, err2 := strconv.ParseFloat(period, 64)
+	if err1 != nil || err2 != nil || q <= 0 || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
+
+func checkGoroutines() []LintIssue {
+	if n := runtime.NumGoroutine(); n > goroutineThreshold {
+		return []LintIssue{{Message: fmt.Sprintf("There are %d goroutines running", n)}}
+	}
+	return nil
+}
+
+func checkDebugLogging() []LintIssue {
+	if loggerInfo == nil {
+		return nil
+	}
+	var issues []LintIssue
+	for module, level := range loggerInfo.ModuleLevels() {
+		if level == logging.DEBUG {
+			if module == "" {
+				module = "root"
+			}
+			issues = append(issues, LintIssue{Message: fmt.Sprintf("Module %s is logging at DEBUG", module)})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Message < issues[j].Message })
+	return issues
+}
+
+// exposureRule returns a rule that checks this server isn't listening on all interfaces without authentication.
+func (a *HTTPServer) exposureRule() LintRule {
+	return NewLintRule("admin-exposure", "The admin server should not listen on all interfaces without an Authenticator.", func() []LintIssue {
+		addr, ok := a.Addr().(*net.TCPAddr)
+		if !ok || !addr.IP.IsUnspecified() || a.getAuthenticator() != nil {
+			return nil
+		}
+		return []LintIssue{{Message: fmt.Sprintf("The admin server is listening on %s without authentication", addr)}}
+	})
+}
+
+type lintResult struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Passed      bool        `json:"passed"`
+	Issues      []LintIssue `json:"issues"`
+}
+
+// lint runs all lint rules, returning their results sorted by name.
+func (a *HTTPServer) lint() []lintResult {
+	lintRules.Lock()
+	rules := append([]LintRule{a.exposureRule()}, lintRules.rules...)
+	lintRules.Unlock()
+
+	results := make([]lintResult, 0, len(rules))
+	for _, rule := range rules {
+		issues := rule.Check()
+		if issues == nil {
+			issues = []LintIssue{}
+		}
+		results = append(results, lintResult{
+			Name:        rule.Name(),
+			Description: rule.Description(),
+			Passed:      len(issues) == 0,
+			Issues:      issues,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+var lintTemplate = template.Must(template.New("lint").Parse(`
+<table class="table">
+	<thead>
+		<tr><th>rule</th><th>description</th><th>result</th></tr>
+	</thead>
+	<tbody>
+{{range .}}
+		<tr class="{{if .Passed}}table-success{{else}}table-warning{{end}}">
+			<td>{{.Name}}</td>
+			<td>{{.Description}}</td>
+			<td>{{if .Passed}}passed{{else}}<ul class="list-unstyled">{{range .Issues}}<li>{{.Message}}</li>{{end}}</ul>{{end}}</td>
+		</tr>
+{{end}}
+	</tbody>
+</table>
+`))
+
+var failedLintTemplate = template.Must(template.New("failedLint").Parse(`
+{{if .}}
+<div class="alert alert-warning" role="alert">
+	<a href="/admin/lint"><strong>{{len .}} lint rule{{if (gt (len .) 1)}}s{{end}} failed:</strong></a>
+	<ul>
+{{range .}}{{$rule := .Name}}{{range .Issues}}
+		<li>{{$rule}}: {{.Message}}</li>
+{{end}}{{end}}
+	</ul>
+</div>
+{{end}}
+`))
+
+// lintHandler renders the results of all lint rules.
+func (a *HTTPServer) lintHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := lintTemplate.Execute(w, a.lint()); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// lintJSONHandler returns the results of all lint rules as JSON.
+func (a *HTTPServer) lintJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.lint())
+	w.Write(b)
+}
+
+// failedLintHandler renders a summary fragment of only the failed lint rules; it's empty if all pass.
+func (a *HTTPServer) failedLintHandler(w http.ResponseWriter, r *http.Request) {
+	var failed []lintResult
+	for _, result := range a.lint() {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := failedLintTemplate.Execute(w, failed); err != nil {
+		log.Errorf("%s", err)
+	}
+}
@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/op/go-logging.v1"
+)
+
+func TestGOMAXPROCSRule(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cgroup")
+	defer os.RemoveAll(dir)
+	oldRoot := cgroupRoot
+	cgroupRoot = dir
+	defer func() { cgroupRoot = oldRoot }()
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	if issues := checkGOMAXPROCS(); len(issues) != 0 {
+		t.Errorf("no cgroup: unexpected issues %v", issues)
+	}
+	writeFile(t, dir, "cpu.max", []byte("max 100000\n"))
+	if issues := checkGOMAXPROCS(); len(issues) != 0 {
+		t.Errorf("unlimited quota: unexpected issues %v", issues)
+	}
+	writeFile(t, dir, "cpu.max", []byte("150000 100000\n"))
+	if issues := checkGOMAXPROCS(); len(issues) != 1 || !strings.Contains(issues[0].Message, "1.50 CPUs") {
+		t.Errorf("cgroup v2: unexpected issues %v", issues)
+	}
+	os.Remove(filepath.Join(dir, "cpu.max"))
+	os.Mkdir(filepath.Join(dir, "cpu"), 0755)
+	writeFile(t, dir, "cpu/cpu.cfs_quota_us", []byte("400000\n"))
+	writeFile(t, dir, "cpu/cpu.cfs_period_us", []byte("100000\n"))
+	if issues := checkGOMAXPROCS(); len(issues) != 0 {
+		t.Errorf("cgroup v1 with enough quota: unexpected issues %v", issues)
+	}
+}
+
+type debugLoggerInfo struct{ fakeLoggerInfo }
+
+func (debugLoggerInfo) ModuleLevels() map[string]logging.Level {
+	return map[string]logging.Level{"": logging.INFO, "db": logging.DEBUG}
+}
+
+func TestDebugLoggingRule(t *testing.T) {
+	loggerInfo = debugLoggerInfo{}
+	defer func() { loggerInfo = nil }()
+	if issues := checkDebugLogging(); len(issues) != 1 || issues[0].Message != "Module db is logging at DEBUG" {
+		t.Errorf("unexpected issues %v", issues)
+	}
+}
+
+func TestExposureRule(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), Opts{Host: "0.0.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if issues := s.exposureRule().Check(); len(issues) != 1 {
+		t.Errorf("unexpected issues %v", issues)
+	}
+	s.authenticator = StaticTokenAuthenticator{}
+	if issues := s.exposureRule().Check(); len(issues) != 0 {
+		t.Errorf("unexpected issues with an authenticator %v", issues)
+	}
+}
+
+func TestLintPages(t *testing.T) {
+	if err := RegisterLintRule(NewLintRule("always-fails", "Fails", func() []LintIssue {
+		return []LintIssue{{Message: "something is wrong"}}
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterLintRule(NewLintRule("always-fails", "Fails", nil)); err == nil {
+		t.Error("expected an error registering a duplicate rule")
+	}
+	s := &HTTPServer{}
+
+	var results []lintResult
+	if err := json.Unmarshal(get(t, s, "/admin/lint.json", false).Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range results {
+		if r.Name == "always-fails" {
+			found = !r.Passed && len(r.Issues) == 1
+		} else if r.Name == "admin-exposure" && !r.Passed {
+			t.Error("admin-exposure failed while the server isn't running")
+		}
+	}
+	if !found {
+		t.Errorf("always-fails not reported correctly: %+v", results)
+	}
+
+	failed := get(t, s, "/admin/failedlint", false).Body.String()
+	if !strings.Contains(failed, "always-fails: something is wrong") || strings.Contains(failed, "admin-exposure") {
+		t.Errorf("unexpected failed lint fragment: %s", failed)
+	}
+	if page := get(t, s, "/admin/lint", true).Body.String(); !strings.Contains(page, "admin-exposure") || !strings.Contains(page, "something is wrong") {
+		t.Error("lint page doesn't list all rules")
+	}
+}
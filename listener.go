@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// listen creates the listener described by the given options.
+// An injected Listener takes precedence over a Unix socket, which takes precedence over host & port.
+// If a TLS certificate is configured, the listener is wrapped to serve TLS.
+func listen(ctx context.Context, opts Opts) (net.Listener, error) {
+	var tlsConfig *tls.Config
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		reloader, err := newCertReloader(opts.TLSCert, opts.TLSKey, opts.TLSClientCA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = reloader.config()
+	} else if opts.TLSClientCA != "" {
+		return nil, fmt.Errorf("a client CA requires a TLS certificate & key to be set")
+	}
+
+	listener := opts.Listener
+	if listener == nil {
+		var err error
+		if listener, err = bind(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+	if tlsConfig != nil {
+		return tls.NewListener(listener, tlsConfig), nil
+	}
+	return listener, nil
+}
+
+// bind listens on either the configured Unix socket or host & port.
+func bind(ctx context.Context, opts Opts) (net.Listener, error) {
+	lc := &net.ListenConfig{}
+	if opts.Socket != "" {
+		// Remove a stale socket left behind by a previous process; anything else at that path is left alone.
+		if info, err := os.Stat(opts.Socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(opts.Socket)
+		}
+		listener, err := lc.Listen(ctx, "unix", opts.Socket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on socket %s: %s", opts.Socket, err)
+		}
+		return listener, nil
+	}
+	addr := fmt.Sprintf("%s:%d", opts.Host, opts.Port)
+	listener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %s", addr, err)
+	}
+	return listener, nil
+}
+
+// A certReloader serves a TLS certificate & client CA pool from disk, reloading them when the files change.
+type certReloader struct {
+	certFile, keyFile, caFile string
+
+	mutex     sync.Mutex
+	modTimes  [3]time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func newCertReloader(certFile, keyFile, caFile string) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key must be set")
+	}
+	c := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reload reloads the certificate & CA pool if any of the files have been modified since they were last loaded.
+func (c *certReloader) reload() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var modTimes [3]time.Time
+	for i, filename := range []string{c.certFile, c.keyFile, c.caFile} {
+		if filename == "" {
+			continue
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	if c.cert != nil && modTimes == c.modTimes {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %s", err)
+	}
+	var pool *x509.CertPool
+	if c.caFile != "" {
+		b, err := ioutil.ReadFile(c.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %s", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return fmt.Errorf("no certificates found in client CA %s", c.caFile)
+		}
+	}
+	if c.cert != nil {
+		log.Infof("Reloaded admin TLS certificate from %s", c.certFile)
+	}
+	c.cert = &cert
+	c.clientCAs = pool
+	c.modTimes = modTimes
+	return nil
+}
+
+// config returns a TLS config that picks up changes to the certificate files on each new connection.
+func (c *certReloader) config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			if err := c.reload(); err != nil {
+				log.Warningf("Failed to reload admin TLS certificate, continuing with the previous one: %s", err)
+			}
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*c.cert},
+			}
+			if c.clientCAs != nil {
+				config.ClientCAs = c.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
@@ -0,0 +1,210 @@
+package admin
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate for localhost, signed by parent (or self-signed if parent is nil).
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func writeFile(t *testing.T, dir, name string, contents []byte) string {
+	t.Helper()
+	filename := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(filename, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func tlsClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "admin-tls")
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", ca)
+	client := newTestCert(t, "client", ca)
+
+	opts := localOpts
+	opts.TLSCert = writeFile(t, dir, "server.crt", server.certPEM)
+	opts.TLSKey = writeFile(t, dir, "server.key", server.keyPEM)
+	opts.TLSClientCA = writeFile(t, dir, "ca.crt", ca.certPEM)
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	url := "https://" + s.Addr().String() + "/admin/ping"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	if _, err := tlsClient(roots).Get(url); err == nil {
+		t.Error("expected a request without a client certificate to fail")
+	}
+	resp, err := tlsClient(roots, client.tlsCertificate(t)).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("unexpected response %d", resp.StatusCode)
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "admin-tls")
+	defer os.RemoveAll(dir)
+	first := newTestCert(t, "first", nil)
+	second := newTestCert(t, "second", nil)
+
+	opts := localOpts
+	opts.TLSCert = writeFile(t, dir, "server.crt", first.certPEM)
+	opts.TLSKey = writeFile(t, dir, "server.key", first.keyPEM)
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	servedCN := func() string {
+		conn, err := tls.Dial("tcp", s.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	if cn := servedCN(); cn != "first" {
+		t.Fatalf("served %s", cn)
+	}
+	writeFile(t, dir, "server.crt", second.certPEM)
+	writeFile(t, dir, "server.key", second.keyPEM)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(opts.TLSCert, later, later)
+	os.Chtimes(opts.TLSKey, later, later)
+	if cn := servedCN(); cn != "second" {
+		t.Fatalf("served %s after reload", cn)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "admin-socket")
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "admin.sock")
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), Opts{Socket: socket}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if s.Addr().Network() != "unix" {
+		t.Fatalf("listening on %s", s.Addr())
+	}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://admin/admin/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response %d", resp.StatusCode)
+	}
+}
+
+func TestInjectedListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), Opts{Listener: l, Port: 1}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if s.Addr().String() != l.Addr().String() {
+		t.Fatalf("listening on %s, not %s", s.Addr(), l.Addr())
+	}
+	resp, err := http.Get(baseURL(t, s) + "/admin/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestClientCARequiresCertificate(t *testing.T) {
+	s := &HTTPServer{}
+	if err := s.Start(context.Background(), Opts{Host: "127.0.0.1", TLSClientCA: "ca.crt"}); err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("expected an error")
+	}
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"fmt"
 	"html/template"
 	"net/http"
 
@@ -26,57 +27,44 @@ type Logger interface{
 }
 
 var noLoggersTemplate = template.Must(template.New("noLoggers").Parse(`
-<html>
-	<head>
-		<link rel="stylesheet" href="https://stackpath.bootstrapcdn.com/bootstrap/4.1.0/css/bootstrap.min.css" integrity="sha384-9gVQ4dYFwwWSjIDZnLEWnxCjeSWFphJiwGPXr1jddIhOegiu1FwO5qRGvFXOdJZ4" crossorigin="anonymous">
-		<script src="https://stackpath.bootstrapcdn.com/bootstrap/4.1.0/js/bootstrap.min.js" integrity="sha384-uefMccjFJAIv6A+rW+L4AHf99KvxDjWSu1z9VI8SKNVmz4sk7buKt/6v9KI65qnm" crossorigin="anonymous"></script>
-	</head>
-	<body>
-		<div class="alert alert-danger" role="alert">Logging has not been initialized. Call SetLogger().</div>
-	</body>
-</html>
+<div class="alert alert-danger" role="alert">Logging has not been initialized. Call SetLogger().</div>
 `))
 
 var loggersTemplate = template.Must(template.New("loggers").Parse(`
-<html>
-	<head>
-		<link rel="stylesheet" href="https://stackpath.bootstrapcdn.com/bootstrap/4.1.0/css/bootstrap.min.css" integrity="sha384-9gVQ4dYFwwWSjIDZnLEWnxCjeSWFphJiwGPXr1jddIhOegiu1FwO5qRGvFXOdJZ4" crossorigin="anonymous">
-		<script src="https://stackpath.bootstrapcdn.com/bootstrap/4.1.0/js/bootstrap.min.js" integrity="sha384-uefMccjFJAIv6A+rW+L4AHf99KvxDjWSu1z9VI8SKNVmz4sk7buKt/6v9KI65qnm" crossorigin="anonymous"></script>
-	</head>
-	<body>
-		<table class="table">
-			<thead>
-				<tr><th>module</th><th>level</th></tr>
-			</thead>
-			<tbody>
+<table class="table">
+	<thead>
+		<tr><th>module</th><th>level</th></tr>
+	</thead>
+	<tbody>
 {{range $module, $moduleLevel := $.ModuleLevels}}
-				<tr><td>{{if (eq $module "")}}root{{else}}{{$module}}{{end}}</td>
-					<td>
-						<form action="/admin/logging" method="POST" style="display: inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="DEBUG" /><input type="submit" class="btn btn{{if (ne $moduleLevel 6)}}-outline{{end}}-secondary" value="ALL"/></form>
+		<tr><td>{{if (eq $module "")}}root{{else}}{{$module}}{{end}}</td>
+			<td>
+				<form action="/admin/logging" method="POST" class="d-inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="DEBUG" />{{$.CSRFField}}<input type="submit" class="btn btn{{if (ne $moduleLevel 6)}}-outline{{end}}-secondary" value="ALL"/></form>
 {{range $level := $.AllLevels}}
-						<form action="/admin/logging" method="POST" style="display: inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="{{ $level.String }}" /><input type="submit" class="btn btn{{if (ne $moduleLevel $level)}}-outline{{end}}-{{index $.Colours $level}}" value="{{$level.String}}"/></form>
+				<form action="/admin/logging" method="POST" class="d-inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="{{ $level.String }}" />{{$.CSRFField}}<input type="submit" class="btn btn{{if (ne $moduleLevel $level)}}-outline{{end}}-{{index $.Colours $level}}" value="{{$level.String}}"/></form>
 {{end}}
-						<form action="/admin/logging" method="POST" style="display: inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="-1" /><input type="submit" class="btn btn{{if (ne $moduleLevel -1)}}-outline{{end}}-dark" value="OFF"/></form>
-					</td>
-				</tr>
+				<form action="/admin/logging" method="POST" class="d-inline"><input type="hidden" name="module" value="{{$module}}" /><input type="hidden" name="level" value="-1" />{{$.CSRFField}}<input type="submit" class="btn btn{{if (ne $moduleLevel -1)}}-outline{{end}}-dark" value="OFF"/></form>
+			</td>
+		</tr>
 {{end}}
-			</tbody>
+	</tbody>
 </table>
-	</body>
-</html>
 `))
 
 // LoggingHandler returns the current state of all loggers that we know about.
 func LoggingHandler(writer http.ResponseWriter, request *http.Request) {
+	writeContentType(writer, "text/html;charset=UTF-8")
 	if loggerInfo == nil {
 		noLoggersTemplate.Execute(writer, nil)
 		return
 	}
 	if err := loggersTemplate.Execute(writer, struct {
+		CSRFField    template.HTML
 		AllLevels    []logging.Level
 		Colours      map[logging.Level]string
 		ModuleLevels map[string]logging.Level
 	}{
+		CSRFField: CSRFField(request),
 		AllLevels: []logging.Level{
 			logging.DEBUG,
 			logging.INFO,
@@ -109,6 +97,7 @@ func UpdateLoggingHandler(writer http.ResponseWriter, request *http.Request) {
 	} else {
 		log.Debugf("Setting level for %s to %s", module, level)
 		loggerInfo.SetLevel(level, module)
+		AnnotateAudit(request, fmt.Sprintf("set level of %s to %s", module, level))
 	}
 	http.Redirect(writer, request, "/admin/logging", http.StatusSeeOther)
 }
@@ -3,31 +3,58 @@ package admin
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"math"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_model/go"
 )
 
-// Gatherer is the thing we gather metrics from.
-var Gatherer = prometheus.DefaultGatherer
-
-func renderMetrics(w http.ResponseWriter, keys sort.StringSlice) {
+// renderMetrics renders the list of metric families, with a chooser of the gatherer they're from and any problems gathering them.
+func renderMetrics(w http.ResponseWriter, keys sort.StringSlice, gatherer string, err error) {
 	content := `<link type="text/css" href="/admin/files/css/metric-query.css" rel="stylesheet"/>
         <script type="application/javascript" src="/admin/files/js/metric-query.js"></script>
         <script type="application/javascript" src="/admin/files/js/chart-renderer.js"></script>
-        <div id="metrics-grid" class="row" data-refresh-uri="/admin/metrics">
+        <script type="application/javascript" src="/admin/files/js/heatmap.js"></script>
+        <script type="application/javascript" src="/admin/files/js/histogram-renderer.js"></script>
+        <script type="application/javascript" src="/admin/files/js/label-picker.js"></script>
+        <div id="metrics-grid" class="row" data-refresh-uri="/admin/metrics" data-history-uri="/admin/metrics/history" data-labels-uri="/admin/metrics/labels" data-gatherer="` + html.EscapeString(gatherer) + `">`
+	if err != nil {
+		content += `<div class="col-md-12"><div class="alert alert-warning">Some metrics could not be gathered:<ul class="mb-0">`
+		errs, ok := err.(prometheus.MultiError)
+		if !ok {
+			errs = prometheus.MultiError{err}
+		}
+		for _, e := range errs {
+			content += "<li>" + html.EscapeString(e.Error()) + "</li>"
+		}
+		content += `</ul></div></div>`
+	}
+	content += `
           <div class="col-md-4 snuggle-right">
+            <select id="gatherer" class="form-control form-control-sm mb-2" title="Gatherer to show metrics from">
+              <option value="">All gatherers</option>`
+	for _, g := range namedGatherers() {
+		selected := ""
+		if g.Name == gatherer {
+			selected = " selected"
+		}
+		content += fmt.Sprintf(`<option value="%s"%s>%s</option>`, html.EscapeString(g.Name), selected, html.EscapeString(g.Name))
+	}
+	content += `</select>
             <ul id="metrics" class="list-unstyled">`
 	sort.Sort(keys)
 	for _, key := range keys {
-		content += fmt.Sprintf(`<li id="%s">%s</li>`+"\n", strings.Replace(key, "/", "-", -1), key)
+		content += fmt.Sprintf(`<li data-metric="%s">%s</li>`+"\n", html.EscapeString(key), html.EscapeString(key))
 	}
 	content += `</ul>
           </div>
           <div class="col-md-8 snuggle-left">
+            <div id="label-picker"></div>
             <div id="chart-div"></div>
           </div>
         </div>`
@@ -36,47 +63,299 @@ func renderMetrics(w http.ResponseWriter, keys sort.StringSlice) {
 }
 
 type statEntry struct {
-	Name  string   `json:"name"`
-	Value *float64 `json:"value"`
+	Name   string            `json:"name"`
+	Family string            `json:"family"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  *float64          `json:"value"`
+	// The following are only set for histograms & summaries, whose Value is the sum of observations.
+	Count       *uint64             `json:"count,omitempty"`
+	Sum         *float64            `json:"sum,omitempty"`
+	Buckets     []bucketEntry       `json:"buckets,omitempty"`
+	Quantiles   []quantileEntry     `json:"quantiles,omitempty"`
+	Percentiles map[string]*float64 `json:"percentiles,omitempty"`
+}
+
+// A bucketEntry is a histogram bucket. The upper bound is a string since it may be +Inf, which JSON can't represent.
+type bucketEntry struct {
+	UpperBound      string `json:"le"`
+	CumulativeCount uint64 `json:"count"`
+}
+
+type quantileEntry struct {
+	Quantile float64  `json:"quantile"`
+	Value    *float64 `json:"value"`
+}
+
+// percentiles are estimated from the buckets of each histogram.
+var percentiles = []struct {
+	name     string
+	quantile float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+}
+
+// finite returns a pointer to f, or nil if it's NaN or infinite (which can't be represented in JSON).
+func finite(f float64) *float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return &f
+}
+
+func histogramEntry(entry statEntry, h *io_prometheus_client.Histogram) statEntry {
+	entry.Value = h.SampleSum
+	entry.Count = h.SampleCount
+	entry.Sum = h.SampleSum
+	entry.Buckets = make([]bucketEntry, len(h.Bucket))
+	for i, b := range h.Bucket {
+		entry.Buckets[i] = bucketEntry{
+			UpperBound:      strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64),
+			CumulativeCount: b.GetCumulativeCount(),
+		}
+	}
+	entry.Percentiles = make(map[string]*float64, len(percentiles))
+	for _, p := range percentiles {
+		entry.Percentiles[p.name] = finite(bucketQuantile(p.quantile, h))
+	}
+	return entry
+}
+
+func summaryEntry(entry statEntry, s *io_prometheus_client.Summary) statEntry {
+	entry.Value = s.SampleSum
+	entry.Count = s.SampleCount
+	entry.Sum = s.SampleSum
+	entry.Quantiles = make([]quantileEntry, len(s.Quantile))
+	for i, q := range s.Quantile {
+		entry.Quantiles[i] = quantileEntry{Quantile: q.GetQuantile(), Value: finite(q.GetValue())}
+	}
+	return entry
 }
 
-func query(mfs []*io_prometheus_client.MetricFamily, ms map[string]struct{}) []statEntry {
-	ret := []statEntry{}
+// bucketQuantile estimates the q-quantile of a histogram by linear interpolation within the bucket
+// it falls in, in the same way as Prometheus' histogram_quantile. It returns NaN if there are no observations.
+func bucketQuantile(q float64, h *io_prometheus_client.Histogram) float64 {
+	buckets := append([]*io_prometheus_client.Bucket{}, h.Bucket...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].GetUpperBound() < buckets[j].GetUpperBound() })
+	if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].GetUpperBound(), 1) {
+		inf := math.Inf(1)
+		buckets = append(buckets, &io_prometheus_client.Bucket{UpperBound: &inf, CumulativeCount: h.SampleCount})
+	}
+	total := float64(buckets[len(buckets)-1].GetCumulativeCount())
+	if total == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	rank := q * total
+	b := sort.Search(len(buckets), func(i int) bool { return float64(buckets[i].GetCumulativeCount()) >= rank })
+	if b == len(buckets)-1 {
+		// The quantile is in the +Inf bucket, so the best we can do is the highest finite bound.
+		if b == 0 {
+			return math.NaN()
+		}
+		return buckets[b-1].GetUpperBound()
+	} else if b == 0 && buckets[0].GetUpperBound() <= 0 {
+		return buckets[0].GetUpperBound()
+	}
+	start, end, count := 0.0, buckets[b].GetUpperBound(), float64(buckets[b].GetCumulativeCount())
+	if b > 0 {
+		start = buckets[b-1].GetUpperBound()
+		count -= float64(buckets[b-1].GetCumulativeCount())
+		rank -= float64(buckets[b-1].GetCumulativeCount())
+	}
+	if count == 0 {
+		return end
+	}
+	return start + (end-start)*(rank/count)
+}
+
+// A series is a single metric of a family.
+type series struct {
+	family string
+	typ    io_prometheus_client.MetricType
+	labels map[string]string
+	metric *io_prometheus_client.Metric
+}
+
+// value returns the value of a series, which for histograms & summaries is the sum of observations.
+func (s series) value() float64 {
+	switch s.typ {
+	case io_prometheus_client.MetricType_COUNTER:
+		return s.metric.GetCounter().GetValue()
+	case io_prometheus_client.MetricType_GAUGE:
+		return s.metric.GetGauge().GetValue()
+	case io_prometheus_client.MetricType_SUMMARY:
+		return s.metric.GetSummary().GetSampleSum()
+	case io_prometheus_client.MetricType_HISTOGRAM:
+		return s.metric.GetHistogram().GetSampleSum()
+	}
+	return s.metric.GetUntyped().GetValue()
+}
+
+// selectSeries returns the series matched by any of the given selectors.
+func selectSeries(mfs []*io_prometheus_client.MetricFamily, sels []metricSelector) []series {
+	ret := []series{}
 	for _, mf := range mfs {
-		if _, present := ms[mf.GetName()]; present {
-			metrics := mf.GetMetric()
-			for _, m := range metrics {
-				seriesName := mf.GetName()
-				if len(m.Label) != 0 {
-					labelValues := make([]string, 0)
-					for _, v := range m.Label {
-						labelValues = append(labelValues, v.GetName()+"="+v.GetValue())
-					}
-					seriesName += "{" + strings.Join(labelValues, ",") + "}"
-				}
-				switch mf.GetType() {
-				case io_prometheus_client.MetricType_COUNTER:
-					ret = append(ret, statEntry{Name: seriesName, Value: m.Counter.Value})
-				case io_prometheus_client.MetricType_GAUGE:
-					ret = append(ret, statEntry{Name: seriesName, Value: m.Gauge.Value})
-				case io_prometheus_client.MetricType_SUMMARY:
-					ret = append(ret, statEntry{Name: seriesName, Value: m.Summary.SampleSum})
-				case io_prometheus_client.MetricType_HISTOGRAM:
-					ret = append(ret, statEntry{Name: seriesName, Value: m.Histogram.SampleSum})
-				}
+		for _, m := range mf.GetMetric() {
+			if labels := labelMap(m); anySelectorMatches(sels, mf.GetName(), labels) {
+				ret = append(ret, series{family: mf.GetName(), typ: mf.GetType(), labels: labels, metric: m})
 			}
 		}
 	}
 	return ret
 }
 
-// MetricQueryHandler either renders the list of all metrics and a graph, or returns the queried metrics' current values.
+func query(ss []series) []statEntry {
+	ret := make([]statEntry, 0, len(ss))
+	for _, s := range ss {
+		entry := statEntry{Name: s.family + formatLabels(s.labels), Family: s.family, Labels: s.labels}
+		switch s.typ {
+		case io_prometheus_client.MetricType_SUMMARY:
+			entry = summaryEntry(entry, s.metric.Summary)
+		case io_prometheus_client.MetricType_HISTOGRAM:
+			entry = histogramEntry(entry, s.metric.Histogram)
+		default:
+			value := s.value()
+			entry.Value = &value
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// aggregations combine the values of a group of series. Summing histograms & summaries also sums their
+// counts and (where the buckets of all the histograms match) their buckets.
+var aggregations = map[string]func(values []float64) float64{
+	"sum": func(values []float64) float64 {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	},
+	"avg": func(values []float64) float64 {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	},
+	"min": func(values []float64) float64 {
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	},
+	"max": func(values []float64) float64 {
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+}
+
+// aggregate groups series by family and the given labels, and combines each group with the given aggregation.
+func aggregate(ss []series, op string, by []string) []statEntry {
+	type group struct {
+		labels map[string]string
+		series []series
+	}
+	groups := map[string]*group{}
+	for _, s := range ss {
+		labels := map[string]string{}
+		for _, name := range by {
+			if value, present := s.labels[name]; present {
+				labels[name] = value
+			}
+		}
+		key := s.family + formatLabels(labels)
+		if g, present := groups[key]; present {
+			g.series = append(g.series, s)
+		} else {
+			groups[key] = &group{labels: labels, series: []series{s}}
+		}
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ret := make([]statEntry, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		entry := statEntry{Name: key, Family: g.series[0].family, Labels: g.labels}
+		switch typ := g.series[0].typ; {
+		case op == "sum" && typ == io_prometheus_client.MetricType_HISTOGRAM:
+			entry = histogramEntry(entry, sumHistograms(g.series))
+		case op == "sum" && typ == io_prometheus_client.MetricType_SUMMARY:
+			count, sum := uint64(0), 0.0
+			for _, s := range g.series {
+				count += s.metric.GetSummary().GetSampleCount()
+				sum += s.metric.GetSummary().GetSampleSum()
+			}
+			entry.Value, entry.Count, entry.Sum = &sum, &count, &sum
+		default:
+			values := make([]float64, len(g.series))
+			for i, s := range g.series {
+				values[i] = s.value()
+			}
+			entry.Value = finite(aggregations[op](values))
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// sumHistograms returns the sum of the given histograms. Their buckets are only summed if they all have the same bounds.
+func sumHistograms(ss []series) *io_prometheus_client.Histogram {
+	count, sum := uint64(0), 0.0
+	var buckets []*io_prometheus_client.Bucket
+	for i, s := range ss {
+		h := s.metric.GetHistogram()
+		count += h.GetSampleCount()
+		sum += h.GetSampleSum()
+		if i == 0 {
+			for _, b := range h.Bucket {
+				cumulativeCount := b.GetCumulativeCount()
+				buckets = append(buckets, &io_prometheus_client.Bucket{UpperBound: b.UpperBound, CumulativeCount: &cumulativeCount})
+			}
+			continue
+		}
+		if len(h.Bucket) != len(buckets) {
+			buckets = nil
+		}
+		for j, b := range buckets {
+			if b.GetUpperBound() != h.Bucket[j].GetUpperBound() {
+				buckets = nil
+				break
+			}
+			*b.CumulativeCount += h.Bucket[j].GetCumulativeCount()
+		}
+	}
+	return &io_prometheus_client.Histogram{SampleCount: &count, SampleSum: &sum, Bucket: buckets}
+}
+
+// MetricQueryHandler either renders the list of all metrics and a graph, or returns the current values of the
+// series matched by the selectors given as m parameters. The agg parameter aggregates them by family and by
+// any labels given as by parameters, with one of sum, avg, min, max or count. Both are restricted to the
+// gatherers named by any g parameters.
 func MetricQueryHandler(w http.ResponseWriter, r *http.Request) {
-	mfs, err := Gatherer.Gather()
+	names, err := gathererNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mfs, err := gather(names...)
 	ms, present := r.URL.Query()["m"]
 	if !present {
 		keys := []string{}
-		if err != nil {
+		if err != nil && len(mfs) == 0 {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -85,14 +364,70 @@ func MetricQueryHandler(w http.ResponseWriter, r *http.Request) {
 			keys = append(keys, *mf.Name)
 		}
 		writeContentType(w, "text/html;charset=UTF-8")
-		renderMetrics(w, keys)
+		renderMetrics(w, keys, r.URL.Query().Get("g"), err)
 	} else {
-		writeContentType(w, "application/json;charset=UTF-8")
-		mMap := make(map[string]struct{})
-		for _, m := range ms {
-			mMap[m] = struct{}{}
+		sels, err := parseSelectors(ms)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		op := r.URL.Query().Get("agg")
+		by := []string{}
+		for _, b := range r.URL.Query()["by"] {
+			by = append(by, strings.Split(b, ",")...)
+		}
+		if _, present := aggregations[op]; op != "" && !present {
+			http.Error(w, "Unknown agg "+op+", must be one of sum, avg, min, max or count", http.StatusBadRequest)
+			return
+		} else if op == "" && len(by) > 0 {
+			http.Error(w, "by requires agg to be set", http.StatusBadRequest)
+			return
+		}
+		ss := selectSeries(mfs, sels)
+		entries := query(ss)
+		if op != "" {
+			entries = aggregate(ss, op, by)
 		}
-		b, _ := json.Marshal(query(mfs, mMap))
+		writeContentType(w, "application/json;charset=UTF-8")
+		b, _ := json.Marshal(entries)
 		w.Write(b)
 	}
 }
+
+// MetricLabelsHandler returns the names and values of the labels of the series matched by the selectors given as
+// m parameters, from the gatherers named by any g parameters, as a JSON object of label name to sorted values.
+func MetricLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	sels, err := parseSelectors(r.URL.Query()["m"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names, err := gathererNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	mfs, err := gather(names...)
+	if err != nil {
+		log.Warningf("Failed to gather some metrics: %s", err)
+	}
+	values := map[string]map[string]struct{}{}
+	for _, s := range selectSeries(mfs, sels) {
+		for name, value := range s.labels {
+			if values[name] == nil {
+				values[name] = map[string]struct{}{}
+			}
+			values[name][value] = struct{}{}
+		}
+	}
+	labels := make(map[string][]string, len(values))
+	for name, vs := range values {
+		for v := range vs {
+			labels[name] = append(labels[name], v)
+		}
+		sort.Strings(labels[name])
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(labels)
+	w.Write(b)
+}
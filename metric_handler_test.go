@@ -0,0 +1,189 @@
+package admin
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_model/go"
+)
+
+// useGatherer replaces Gatherer with the given registry for the duration of a test.
+func useGatherer(t *testing.T, reg *prometheus.Registry) {
+	oldGatherer := Gatherer
+	Gatherer = reg
+	t.Cleanup(func() { Gatherer = oldGatherer })
+}
+
+func queryMetrics(t *testing.T, query string) []statEntry {
+	t.Helper()
+	w := get(t, &HTTPServer{}, "/admin/metrics?"+query, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	var entries []statEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON %q: %s", w.Body.String(), err)
+	}
+	return entries
+}
+
+func TestHistogramDetails(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{0.1, 1, 10}})
+	reg.MustRegister(latency)
+	useGatherer(t, reg)
+	for i := 0; i < 100; i++ {
+		latency.Observe(0.5)
+	}
+	for i := 0; i < 10; i++ {
+		latency.Observe(20)
+	}
+
+	entries := queryMetrics(t, "m=latency_seconds")
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	e := entries[0]
+	if *e.Count != 110 || *e.Sum != 250 || *e.Value != 250 {
+		t.Errorf("unexpected count %d, sum %f, value %f", *e.Count, *e.Sum, *e.Value)
+	}
+	if len(e.Buckets) != 3 || e.Buckets[1] != (bucketEntry{UpperBound: "1", CumulativeCount: 100}) {
+		t.Errorf("unexpected buckets %+v", e.Buckets)
+	}
+	// The 55th observation is interpolated to be 55% of the way through the (0.1, 1] bucket.
+	if p50 := *e.Percentiles["p50"]; math.Abs(p50-0.595) > 1e-9 {
+		t.Errorf("unexpected p50 %f", p50)
+	}
+	// The 99th percentile is in the +Inf bucket, so it's reported as the highest finite bound.
+	if p99 := *e.Percentiles["p99"]; p99 != 10 {
+		t.Errorf("unexpected p99 %f", p99)
+	}
+}
+
+func TestSummaryDetails(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	latency := prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: "latency_seconds", Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01}}, []string{"path"})
+	reg.MustRegister(latency)
+	useGatherer(t, reg)
+	for i := 1; i <= 10; i++ {
+		latency.WithLabelValues("/a").Observe(float64(i))
+	}
+	latency.WithLabelValues("/b")
+
+	entries := queryMetrics(t, "m=latency_seconds")
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if a := entries[0]; *a.Count != 10 || len(a.Quantiles) != 2 || a.Quantiles[0].Quantile != 0.5 || *a.Quantiles[0].Value != 5 {
+		t.Errorf("unexpected entry %+v", a)
+	}
+	// Quantiles of an empty summary are NaN, which must not break the JSON encoding.
+	if b := entries[1]; *b.Count != 0 || len(b.Quantiles) != 2 || b.Quantiles[0].Value != nil {
+		t.Errorf("unexpected entry %+v", b)
+	}
+}
+
+func TestBucketQuantile(t *testing.T) {
+	bucket := func(le float64, count uint64) *io_prometheus_client.Bucket {
+		return &io_prometheus_client.Bucket{UpperBound: &le, CumulativeCount: &count}
+	}
+	histogram := func(count uint64, buckets ...*io_prometheus_client.Bucket) *io_prometheus_client.Histogram {
+		return &io_prometheus_client.Histogram{SampleCount: &count, Bucket: buckets}
+	}
+	for name, tc := range map[string]struct {
+		q        float64
+		h        *io_prometheus_client.Histogram
+		expected float64
+	}{
+		"first bucket":    {0.5, histogram(10, bucket(1, 10), bucket(2, 10)), 0.5},
+		"interpolated":    {0.75, histogram(4, bucket(1, 2), bucket(3, 4)), 2},
+		"unsorted":        {0.75, histogram(4, bucket(3, 4), bucket(1, 2)), 2},
+		"explicit +Inf":   {0.9, histogram(10, bucket(1, 5), bucket(math.Inf(1), 10)), 1},
+		"negative bounds": {0.1, histogram(10, bucket(-1, 5), bucket(1, 10)), -1},
+		"empty":           {0.5, histogram(0, bucket(1, 0)), math.NaN()},
+		"no buckets":      {0.5, histogram(3), math.NaN()},
+	} {
+		if actual := bucketQuantile(tc.q, tc.h); actual != tc.expected && !(math.IsNaN(actual) && math.IsNaN(tc.expected)) {
+			t.Errorf("%s: expected %f, got %f", name, tc.expected, actual)
+		}
+	}
+}
+
+func TestMetricsPageIncludesHistogramRenderer(t *testing.T) {
+	w := get(t, &HTTPServer{}, "/admin/metrics", true)
+	for _, script := range []string{"heatmap.js", "histogram-renderer.js"} {
+		if !strings.Contains(w.Body.String(), "/admin/files/js/"+script) {
+			t.Errorf("metrics page doesn't include %s", script)
+		}
+	}
+}
+
+func TestMetricSelectorsAndAggregation(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code", "method"})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{1, 10}}, []string{"method"})
+	reg.MustRegister(requests, latency)
+	useGatherer(t, reg)
+	requests.WithLabelValues("200", "GET").Add(5)
+	requests.WithLabelValues("500", "GET").Add(2)
+	requests.WithLabelValues("503", "POST").Add(1)
+	latency.WithLabelValues("GET").Observe(0.5)
+	latency.WithLabelValues("POST").Observe(5)
+
+	entries := queryMetrics(t, `m=requests_total{code=~"5.."}`)
+	if len(entries) != 2 || entries[0].Name != "requests_total{code=500,method=GET}" || entries[0].Family != "requests_total" ||
+		entries[0].Labels["code"] != "500" || *entries[1].Value != 1 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	if entries := queryMetrics(t, `m={__name__=~"req.*_total"}&m=requests_total{method="POST"}`); len(entries) != 3 {
+		t.Errorf("series matched by multiple selectors should only be returned once: %+v", entries)
+	}
+
+	entries = queryMetrics(t, "m=requests_total&agg=sum&by=method")
+	if len(entries) != 2 || entries[0].Name != "requests_total{method=GET}" || *entries[0].Value != 7 || *entries[1].Value != 1 {
+		t.Errorf("unexpected sums %+v", entries)
+	}
+	for agg, expected := range map[string]float64{"sum": 8, "avg": 8.0 / 3, "min": 1, "max": 5, "count": 3} {
+		entries := queryMetrics(t, "m=requests_total&agg="+agg)
+		if len(entries) != 1 || entries[0].Name != "requests_total" || entries[0].Labels != nil || *entries[0].Value != expected {
+			t.Errorf("%s: unexpected entries %+v", agg, entries)
+		}
+	}
+
+	// Summed histograms keep their buckets.
+	entries = queryMetrics(t, "m=latency_seconds&agg=sum")
+	if len(entries) != 1 || *entries[0].Count != 2 || *entries[0].Sum != 5.5 || len(entries[0].Buckets) != 2 ||
+		entries[0].Buckets[0].CumulativeCount != 1 || entries[0].Buckets[1].CumulativeCount != 2 || *entries[0].Percentiles["p50"] != 1 {
+		t.Errorf("unexpected histogram %+v", entries)
+	}
+}
+
+func TestMetricQueryErrors(t *testing.T) {
+	for _, query := range []string{"m=requests_total{", "m=requests_total&agg=median", "m=requests_total&by=code"} {
+		if w := get(t, &HTTPServer{}, "/admin/metrics?"+query, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestMetricLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code", "method"})
+	reg.MustRegister(requests)
+	useGatherer(t, reg)
+	requests.WithLabelValues("500", "GET")
+	requests.WithLabelValues("200", "GET")
+	requests.WithLabelValues("200", "POST")
+
+	w := get(t, &HTTPServer{}, `/admin/metrics/labels?m=requests_total{method="GET"}`, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); body != `{"code":["200","500"],"method":["GET"]}` {
+		t.Errorf("unexpected labels %s", body)
+	}
+}
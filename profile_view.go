@@ -0,0 +1,606 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// profileViews are the ways a profile can be viewed.
+var profileViews = []string{"flame", "top", "peek", "graph"}
+
+// defaultProfileViewNodes is how many functions are shown in the top, peek & graph views if not otherwise given.
+var defaultProfileViewNodes = map[string]int{"top": 50, "peek": 10, "graph": 30}
+
+// minFlameFraction is the fraction of the total below which flame graph frames are omitted; they'd be too narrow to see.
+const minFlameFraction = 0.001
+
+// A profileViewError is an error viewing a profile, with the status it's reported with.
+type profileViewError struct {
+	status int
+	err    error
+}
+
+func (e *profileViewError) Error() string {
+	return e.err.Error()
+}
+
+// A flameNode is a frame in a flame graph; its value is the total of the samples whose stacks pass through it.
+type flameNode struct {
+	Name     string       `json:"name"`
+	Value    int64        `json:"value"`
+	Children []*flameNode `json:"children,omitempty"`
+	children map[string]*flameNode
+}
+
+// add adds a sample's value along the given stack, which is ordered root first.
+func (n *flameNode) add(stack []string, value int64) {
+	n.Value += value
+	if len(stack) == 0 {
+		return
+	}
+	if n.children == nil {
+		n.children = map[string]*flameNode{}
+	}
+	child := n.children[stack[0]]
+	if child == nil {
+		child = &flameNode{Name: stack[0]}
+		n.children[stack[0]] = child
+	}
+	child.add(stack[1:], value)
+}
+
+// finish sorts the node's children by name, omitting any whose value is below min.
+func (n *flameNode) finish(min int64) {
+	for _, child := range n.children {
+		if child.Value >= min {
+			child.finish(min)
+			n.Children = append(n.Children, child)
+		}
+	}
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+	n.children = nil
+}
+
+// A profileFunction is the total of a function's samples: flat where it's the leaf, cum where it's anywhere in the stack.
+type profileFunction struct {
+	Name string `json:"name"`
+	Flat int64  `json:"flat"`
+	Cum  int64  `json:"cum"`
+}
+
+// A profileCall is the total of the samples in which one function called another.
+type profileCall struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// A profilePeek is a function with its callers & callees.
+type profilePeek struct {
+	profileFunction
+	Callers []profileCall `json:"callers"`
+	Callees []profileCall `json:"callees"`
+}
+
+// A callGraphEdge is a call from one function to another in a callGraph.
+type callGraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value int64  `json:"value"`
+}
+
+// A callGraph is the graph of calls between the functions with the most cumulative samples.
+type callGraph struct {
+	Nodes []profileFunction `json:"nodes"`
+	Edges []callGraphEdge   `json:"edges"`
+}
+
+// A profileSample is a sample's value & stack, ordered leaf first.
+type profileSample struct {
+	stack []string
+	value int64
+}
+
+// A profileView is a profile rendered in one of the profileViews.
+type profileView struct {
+	Source      string            `json:"source"`
+	Kind        string            `json:"kind"`
+	Time        time.Time         `json:"time"`
+	View        string            `json:"view"`
+	SampleTypes []string          `json:"sample_types"`
+	SampleType  string            `json:"sample_type"`
+	Unit        string            `json:"unit"`
+	Total       int64             `json:"total"`
+	Focus       string            `json:"focus,omitempty"`
+	Ignore      string            `json:"ignore,omitempty"`
+	Peek        string            `json:"peek,omitempty"`
+	Flame       *flameNode        `json:"flame,omitempty"`
+	Top         []profileFunction `json:"top,omitempty"`
+	Peeks       []profilePeek     `json:"peeks,omitempty"`
+	Graph       *callGraph        `json:"graph,omitempty"`
+	query       url.Values
+}
+
+// Link returns the URL of this page with the given parameters changed, given as name, value pairs.
+func (v *profileView) Link(params ...string) string {
+	q := url.Values{}
+	for k, vs := range v.query {
+		q[k] = vs
+	}
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] == "" {
+			q.Del(params[i])
+		} else {
+			q.Set(params[i], params[i+1])
+		}
+	}
+	return "/admin/profiles/view?" + q.Encode()
+}
+
+// JSONLink returns the URL of this view's JSON.
+func (v *profileView) JSONLink() string {
+	return "/admin/profiles/view.json?" + v.query.Encode()
+}
+
+// Format formats a sample value in the view's unit.
+func (v *profileView) Format(value int64) string {
+	return formatSampleValue(value, v.Unit)
+}
+
+// Percent formats a sample value as a percentage of the view's total.
+func (v *profileView) Percent(value int64) string {
+	if v.Total == 0 {
+		return "0%"
+	}
+	return strconv.FormatFloat(100*float64(value)/float64(v.Total), 'f', 1, 64) + "%"
+}
+
+// formatSampleValue formats a sample value according to its unit.
+func formatSampleValue(value int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(value).String()
+	case "bytes":
+		if value < 0 {
+			return "-" + formatBytes(uint64(-value))
+		}
+		return formatBytes(uint64(value))
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+// viewedProfile returns the profile given by the id parameter, a stored profile, or the capture parameter, one
+// retained by the continuous profiler. It returns nil if neither is given.
+func (a *HTTPServer) viewedProfile(q url.Values) (*profileView, []byte, error) {
+	if id := q.Get("id"); id != "" {
+		p, data, err := a.getProfiles().get(id)
+		if err != nil {
+			return nil, nil, &profileViewError{http.StatusNotFound, err}
+		}
+		return &profileView{Source: "profile " + id, Kind: p.Kind, Time: p.Time}, data, nil
+	} else if seq := q.Get("capture"); seq != "" {
+		var c *continuousCapture
+		if p := a.getProfiler(); p != nil {
+			c = p.get(seq)
+		}
+		if c == nil || c.Error != "" {
+			return nil, nil, &profileViewError{http.StatusNotFound, fmt.Errorf("no such capture %q; only the most recent are retained", seq)}
+		}
+		return &profileView{Source: "continuous capture " + seq, Kind: c.Kind, Time: c.Time}, c.data, nil
+	}
+	return nil, nil, nil
+}
+
+// compileProfileRegexp compiles one of the regular expressions given as parameters; it's nil if the parameter isn't set.
+func compileProfileRegexp(q url.Values, name string) (*regexp.Regexp, error) {
+	s := q.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, &profileViewError{http.StatusBadRequest, fmt.Errorf("invalid %s: %s", name, err)}
+	}
+	return re, nil
+}
+
+// newProfileView renders the profile given by the request's parameters in the view given by the view parameter.
+// The sample parameter selects the type of sample; focus & ignore are regular expressions restricting the samples
+// to those with or without a matching function in their stack. n limits the number of functions shown, & peek
+// selects the functions shown in the peek view. It returns nil if no profile is given.
+func (a *HTTPServer) newProfileView(q url.Values) (*profileView, error) {
+	v, data, err := a.viewedProfile(q)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	v.query = q
+	v.View = q.Get("view")
+	if v.View == "" {
+		v.View = "flame"
+	} else if defaultProfileViewNodes[v.View] == 0 && v.View != "flame" {
+		return nil, &profileViewError{http.StatusBadRequest, fmt.Errorf("unknown view %q", v.View)}
+	}
+	n := defaultProfileViewNodes[v.View]
+	if s := q.Get("n"); s != "" {
+		if n, err = strconv.Atoi(s); err != nil || n < 1 {
+			return nil, &profileViewError{http.StatusBadRequest, fmt.Errorf("n must be a positive integer")}
+		}
+	}
+	focus, err := compileProfileRegexp(q, "focus")
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := compileProfileRegexp(q, "ignore")
+	if err != nil {
+		return nil, err
+	}
+	peek, err := compileProfileRegexp(q, "peek")
+	if err != nil {
+		return nil, err
+	}
+	v.Focus, v.Ignore, v.Peek = q.Get("focus"), q.Get("ignore"), q.Get("peek")
+
+	p, err := profile.ParseData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %s", err)
+	}
+	index := len(p.SampleType) - 1
+	v.SampleType = q.Get("sample")
+	if v.SampleType == "" {
+		v.SampleType = p.DefaultSampleType
+	}
+	for i, st := range p.SampleType {
+		v.SampleTypes = append(v.SampleTypes, st.Type)
+		if st.Type == v.SampleType {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("profile has no samples")
+	} else if v.SampleType != "" && p.SampleType[index].Type != v.SampleType {
+		return nil, &profileViewError{http.StatusBadRequest, fmt.Errorf("unknown sample type %q", v.SampleType)}
+	}
+	v.SampleType, v.Unit = p.SampleType[index].Type, p.SampleType[index].Unit
+	p.FilterSamplesByName(focus, ignore, nil, nil)
+
+	samples := make([]profileSample, 0, len(p.Sample))
+	for _, s := range p.Sample {
+		if value := s.Value[index]; value != 0 {
+			samples = append(samples, profileSample{stack: sampleStack(s), value: value})
+			v.Total += value
+		}
+	}
+	switch v.View {
+	case "flame":
+		v.Flame = flameGraph(samples, int64(float64(v.Total)*minFlameFraction))
+	case "top":
+		v.Top = profileFunctions(samples)
+		if len(v.Top) > n {
+			v.Top = v.Top[:n]
+		}
+	case "peek":
+		v.Peeks = peekProfile(samples, peek, n)
+	case "graph":
+		v.Graph = newCallGraph(samples, n)
+	}
+	return v, nil
+}
+
+// sampleStack returns the names of the functions in a sample's stack, leaf first. Inlined functions are included;
+// addresses are used for locations that haven't been symbolized.
+func sampleStack(s *profile.Sample) []string {
+	var stack []string
+	for _, loc := range s.Location {
+		if len(loc.Line) == 0 {
+			stack = append(stack, fmt.Sprintf("0x%x", loc.Address))
+		}
+		for _, line := range loc.Line {
+			if line.Function != nil {
+				stack = append(stack, line.Function.Name)
+			}
+		}
+	}
+	return stack
+}
+
+// flameGraph returns the root of a flame graph of the samples, omitting frames whose value is below min.
+func flameGraph(samples []profileSample, min int64) *flameNode {
+	root := &flameNode{Name: "root"}
+	for _, s := range samples {
+		stack := make([]string, len(s.stack))
+		for i, name := range s.stack {
+			stack[len(stack)-1-i] = name
+		}
+		root.add(stack, s.value)
+	}
+	root.finish(min)
+	return root
+}
+
+// profileFunctions returns the flat & cumulative totals of each function in the samples, ordered by flat total.
+// Functions that appear more than once in a stack, i.e. recursive ones, are only counted once in its cumulative total.
+func profileFunctions(samples []profileSample) []profileFunction {
+	functions := map[string]*profileFunction{}
+	for _, s := range samples {
+		seen := map[string]bool{}
+		for i, name := range s.stack {
+			f := functions[name]
+			if f == nil {
+				f = &profileFunction{Name: name}
+				functions[name] = f
+			}
+			if i == 0 {
+				f.Flat += s.value
+			}
+			if !seen[name] {
+				f.Cum += s.value
+				seen[name] = true
+			}
+		}
+	}
+	ret := make([]profileFunction, 0, len(functions))
+	for _, f := range functions {
+		ret = append(ret, *f)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Flat != ret[j].Flat {
+			return ret[i].Flat > ret[j].Flat
+		} else if ret[i].Cum != ret[j].Cum {
+			return ret[i].Cum > ret[j].Cum
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// byCum orders functions by their cumulative total, largest first.
+func byCum(functions []profileFunction) {
+	sort.SliceStable(functions, func(i, j int) bool { return functions[i].Cum > functions[j].Cum })
+}
+
+// sortedCalls returns the calls in the map ordered by value, largest first.
+func sortedCalls(calls map[string]int64) []profileCall {
+	ret := make([]profileCall, 0, len(calls))
+	for name, value := range calls {
+		ret = append(ret, profileCall{Name: name, Value: value})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Value != ret[j].Value {
+			return ret[i].Value > ret[j].Value
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// peekProfile returns the callers & callees of up to n functions matching the given regular expression, or of the
+// n with the largest cumulative totals if it's nil.
+func peekProfile(samples []profileSample, re *regexp.Regexp, n int) []profilePeek {
+	functions := profileFunctions(samples)
+	byCum(functions)
+	var peeks []profilePeek
+	index := map[string]int{}
+	for _, f := range functions {
+		if len(peeks) < n && (re == nil || re.MatchString(f.Name)) {
+			index[f.Name] = len(peeks)
+			peeks = append(peeks, profilePeek{profileFunction: f})
+		}
+	}
+	callers := make([]map[string]int64, len(peeks))
+	callees := make([]map[string]int64, len(peeks))
+	for i := range peeks {
+		callers[i], callees[i] = map[string]int64{}, map[string]int64{}
+	}
+	for _, s := range samples {
+		seen := map[[3]string]bool{}
+		for i, name := range s.stack {
+			p, present := index[name]
+			if !present {
+				continue
+			}
+			if i+1 < len(s.stack) && !seen[[3]string{"caller", name, s.stack[i+1]}] {
+				callers[p][s.stack[i+1]] += s.value
+				seen[[3]string{"caller", name, s.stack[i+1]}] = true
+			}
+			if i > 0 && !seen[[3]string{"callee", name, s.stack[i-1]}] {
+				callees[p][s.stack[i-1]] += s.value
+				seen[[3]string{"callee", name, s.stack[i-1]}] = true
+			}
+		}
+	}
+	for i := range peeks {
+		peeks[i].Callers, peeks[i].Callees = sortedCalls(callers[i]), sortedCalls(callees[i])
+	}
+	return peeks
+}
+
+// newCallGraph returns the graph of calls between the n functions with the largest cumulative totals. Calls via
+// functions that aren't shown are attributed to the nearest functions that are.
+func newCallGraph(samples []profileSample, n int) *callGraph {
+	g := &callGraph{Nodes: profileFunctions(samples)}
+	byCum(g.Nodes)
+	if len(g.Nodes) > n {
+		g.Nodes = g.Nodes[:n]
+	}
+	shown := map[string]bool{}
+	for _, f := range g.Nodes {
+		shown[f.Name] = true
+	}
+	edges := map[[2]string]int64{}
+	for _, s := range samples {
+		var stack []string
+		for _, name := range s.stack {
+			if shown[name] && (len(stack) == 0 || stack[len(stack)-1] != name) {
+				stack = append(stack, name)
+			}
+		}
+		seen := map[[2]string]bool{}
+		for i := 1; i < len(stack); i++ {
+			edge := [2]string{stack[i], stack[i-1]}
+			if !seen[edge] {
+				edges[edge] += s.value
+				seen[edge] = true
+			}
+		}
+	}
+	for edge, value := range edges {
+		g.Edges = append(g.Edges, callGraphEdge{From: edge[0], To: edge[1], Value: value})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Value != g.Edges[j].Value {
+			return g.Edges[i].Value > g.Edges[j].Value
+		} else if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+var profileViewTemplate = template.Must(template.New("profile-view").Funcs(template.FuncMap{
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"views": func() []string { return profileViews },
+	"quote": regexp.QuoteMeta,
+}).Parse(`
+<link type="text/css" href="/admin/files/css/profile-view.css" rel="stylesheet"/>
+{{if not .View}}
+{{if .Profiles}}
+<p>Choose a profile to view, or <a href="/admin/profiles">capture one</a>.</p>
+<ul>
+	{{range .Profiles}}<li><a href="/admin/profiles/view?id={{.ID}}">{{.ID}}: {{.Kind}} at {{time .Time}}</a> ({{.Trigger}})</li>{{end}}
+</ul>
+{{else}}
+<p>No profiles have been captured. <a href="/admin/profiles">Capture one</a> to view it here.</p>
+{{end}}
+{{else}}
+<p>{{.Kind}} profile captured at {{time .Time}} ({{.Source}}): {{.Format .Total}} of {{.SampleType}} in total{{if or .Focus .Ignore}} matching the filters{{end}}.</p>
+<form method="GET" action="/admin/profiles/view" class="form-inline mb-2">
+	{{range $k, $v := .Hidden}}<input type="hidden" name="{{$k}}" value="{{$v}}"/>{{end}}
+	<select name="sample" class="form-control form-control-sm mr-2 mb-1" title="Sample type">
+		{{range .SampleTypes}}<option value="{{.}}"{{if eq . $.SampleType}} selected{{end}}>{{.}}</option>{{end}}
+	</select>
+	<input type="text" name="focus" value="{{.Focus}}" class="form-control form-control-sm mr-2 mb-1" placeholder="Focus regexp"/>
+	<input type="text" name="ignore" value="{{.Ignore}}" class="form-control form-control-sm mr-2 mb-1" placeholder="Ignore regexp"/>
+	{{if eq .View "peek"}}<input type="text" name="peek" value="{{.Peek}}" class="form-control form-control-sm mr-2 mb-1" placeholder="Peek regexp"/>{{end}}
+	<input type="submit" class="btn btn-sm btn-primary mb-1" value="Apply"/>
+</form>
+<ul class="nav nav-tabs mb-3">
+	{{range views}}<li class="nav-item"><a class="nav-link{{if eq . $.View}} active{{end}}" href="{{$.Link "view" .}}">{{.}}</a></li>{{end}}
+	<li class="nav-item ml-auto"><a class="nav-link" href="{{.Download}}">download</a></li>
+</ul>
+{{if eq .View "flame"}}
+<script type="application/javascript" src="/admin/files/js/flame-graph.js"></script>
+<p class="text-muted small">Click a frame to zoom in, or one above it to zoom back out.</p>
+<div id="flame-graph" data-uri="{{.JSONLink}}"></div>
+{{else if eq .View "top"}}
+<table class="table table-sm profile-table">
+	<thead><tr><th>flat</th><th>flat%</th><th>cum</th><th>cum%</th><th>function</th></tr></thead>
+	<tbody>
+	{{range .Top}}
+		<tr>
+			<td>{{$.Format .Flat}}</td><td>{{$.Percent .Flat}}</td><td>{{$.Format .Cum}}</td><td>{{$.Percent .Cum}}</td>
+			<td><a href="{{$.Link "view" "peek" "peek" (printf "^%s$" (quote .Name))}}">{{.Name}}</a></td>
+		</tr>
+	{{else}}
+		<tr><td colspan="5">No samples match.</td></tr>
+	{{end}}
+	</tbody>
+</table>
+{{else if eq .View "peek"}}
+{{range .Peeks}}
+<table class="table table-sm profile-table mb-4">
+	<thead><tr><th>value</th><th>%</th><th>function</th></tr></thead>
+	<tbody>
+	{{range .Callers}}<tr class="text-muted"><td>{{$.Format .Value}}</td><td>{{$.Percent .Value}}</td><td><a href="{{$.Link "peek" (printf "^%s$" (quote .Name))}}">{{.Name}}</a></td></tr>{{end}}
+	<tr class="table-active"><td>{{$.Format .Cum}}</td><td>{{$.Percent .Cum}}</td><td><strong>{{.Name}}</strong> (flat {{$.Format .Flat}})</td></tr>
+	{{range .Callees}}<tr class="text-muted"><td>{{$.Format .Value}}</td><td>{{$.Percent .Value}}</td><td class="pl-4"><a href="{{$.Link "peek" (printf "^%s$" (quote .Name))}}">{{.Name}}</a></td></tr>{{end}}
+	</tbody>
+</table>
+{{else}}
+<p>No functions match.</p>
+{{end}}
+{{else if eq .View "graph"}}
+<script type="application/javascript" src="/admin/files/js/line-chart.js"></script>
+<script type="application/javascript" src="/admin/files/js/flame-graph.js"></script>
+<script type="application/javascript" src="/admin/files/js/call-graph.js"></script>
+<p class="text-muted small">The {{len .Graph.Nodes}} functions with the most cumulative samples, called from above.</p>
+<div id="call-graph" data-uri="{{.JSONLink}}"></div>
+{{end}}
+{{end}}
+`))
+
+// profileViewPage is what's rendered on the profile view page; without a profile it lists those that can be viewed.
+type profileViewPage struct {
+	*profileView
+	Profiles []*storedProfile
+}
+
+// Hidden returns the parameters that are kept when the filter form is submitted.
+func (p profileViewPage) Hidden() map[string]string {
+	hidden := map[string]string{}
+	for _, name := range []string{"id", "capture", "view", "n"} {
+		if v := p.query.Get(name); v != "" {
+			hidden[name] = v
+		}
+	}
+	return hidden
+}
+
+// Download returns the URL the viewed profile can be downloaded from.
+func (p profileViewPage) Download() string {
+	if id := p.query.Get("id"); id != "" {
+		return "/admin/profiles/" + url.PathEscape(id)
+	}
+	return "/admin/profiling/" + url.PathEscape(p.query.Get("capture"))
+}
+
+// writeProfileViewError reports an error rendering a profile view.
+func writeProfileViewError(w http.ResponseWriter, err error) {
+	if e, ok := err.(*profileViewError); ok {
+		http.Error(w, e.Error(), e.status)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// profileViewHandler renders a stored or continuously captured profile as a flame graph, top or peek table, or call
+// graph. Without one it lists the stored profiles.
+func (a *HTTPServer) profileViewHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := a.newProfileView(r.URL.Query())
+	if err != nil {
+		writeProfileViewError(w, err)
+		return
+	}
+	page := profileViewPage{profileView: v}
+	if v == nil {
+		page.profileView = &profileView{}
+		page.Profiles = a.getProfiles().list()
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := profileViewTemplate.Execute(w, page); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// profileViewJSONHandler returns a stored or continuously captured profile rendered in the given view.
+func (a *HTTPServer) profileViewJSONHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := a.newProfileView(r.URL.Query())
+	if err == nil && v == nil {
+		err = &profileViewError{http.StatusBadRequest, fmt.Errorf("one of id or capture must be given")}
+	}
+	if err != nil {
+		writeProfileViewError(w, err)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(v)
+	w.Write(b)
+}
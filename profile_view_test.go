@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// testProfile returns a CPU profile of main.main calling main.a, which calls main.b & itself, & main.c, which
+// calls main.b.
+func testProfile() *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     1,
+	}
+	locations := map[string]*profile.Location{}
+	for i, name := range []string{"main.main", "main.a", "main.b", "main.c"} {
+		f := &profile.Function{ID: uint64(i + 1), Name: name, SystemName: name, Filename: "main.go"}
+		loc := &profile.Location{ID: uint64(i + 1), Line: []profile.Line{{Function: f, Line: int64(i)}}}
+		p.Function = append(p.Function, f)
+		p.Location = append(p.Location, loc)
+		locations[name] = loc
+	}
+	for _, s := range []struct {
+		value int64
+		stack []string
+	}{
+		{10, []string{"main.b", "main.a", "main.main"}},
+		{5, []string{"main.a", "main.main"}},
+		{3, []string{"main.b", "main.c", "main.main"}},
+		{2, []string{"main.a", "main.a", "main.main"}},
+	} {
+		sample := &profile.Sample{Value: []int64{s.value, s.value * int64(time.Millisecond)}}
+		for _, name := range s.stack {
+			sample.Location = append(sample.Location, locations[name])
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	return p
+}
+
+func testProfileSamples() []profileSample {
+	var samples []profileSample
+	for _, s := range testProfile().Sample {
+		samples = append(samples, profileSample{stack: sampleStack(s), value: s.Value[0]})
+	}
+	return samples
+}
+
+func TestProfileFunctions(t *testing.T) {
+	expected := []profileFunction{{"main.b", 13, 13}, {"main.a", 7, 17}, {"main.main", 0, 20}, {"main.c", 0, 3}}
+	if fs := profileFunctions(testProfileSamples()); !reflect.DeepEqual(fs, expected) {
+		t.Errorf("unexpected functions %+v", fs)
+	}
+}
+
+func TestFlameGraph(t *testing.T) {
+	root := flameGraph(testProfileSamples(), 3)
+	if root.Value != 20 || len(root.Children) != 1 {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	main := root.Children[0]
+	if main.Name != "main.main" || main.Value != 20 || len(main.Children) != 2 || main.Children[0].Name != "main.a" || main.Children[1].Name != "main.c" {
+		t.Fatalf("unexpected main %+v", main)
+	}
+	// The recursive call to main.a is too small to be included.
+	if a := main.Children[0]; a.Value != 17 || len(a.Children) != 1 || a.Children[0].Name != "main.b" || a.Children[0].Value != 10 {
+		t.Errorf("unexpected main.a %+v", a)
+	}
+}
+
+func TestPeekProfile(t *testing.T) {
+	peeks := peekProfile(testProfileSamples(), regexp.MustCompile(`^main\.a$`), 10)
+	if len(peeks) != 1 {
+		t.Fatalf("unexpected peeks %+v", peeks)
+	}
+	if p := peeks[0]; p.Name != "main.a" || p.Cum != 17 ||
+		!reflect.DeepEqual(p.Callers, []profileCall{{"main.main", 17}, {"main.a", 2}}) ||
+		!reflect.DeepEqual(p.Callees, []profileCall{{"main.b", 10}, {"main.a", 2}}) {
+		t.Errorf("unexpected peek %+v", p)
+	}
+	if peeks := peekProfile(testProfileSamples(), nil, 2); len(peeks) != 2 || peeks[0].Name != "main.main" || peeks[1].Name != "main.a" {
+		t.Errorf("unexpected peeks %+v", peeks)
+	}
+}
+
+func TestCallGraph(t *testing.T) {
+	g := newCallGraph(testProfileSamples(), 3)
+	if len(g.Nodes) != 3 || g.Nodes[0].Name != "main.main" || g.Nodes[2].Name != "main.b" {
+		t.Errorf("unexpected nodes %+v", g.Nodes)
+	}
+	// The call to main.b via main.c is attributed to main.main, since main.c isn't shown.
+	expected := []callGraphEdge{{"main.main", "main.a", 17}, {"main.a", "main.b", 10}, {"main.main", "main.b", 3}}
+	if !reflect.DeepEqual(g.Edges, expected) {
+		t.Errorf("unexpected edges %+v", g.Edges)
+	}
+}
+
+func TestProfileView(t *testing.T) {
+	s := &HTTPServer{}
+	var buf bytes.Buffer
+	if err := testProfile().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.getProfiles().add(&storedProfile{Kind: "cpu", Trigger: "test"}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	var v profileView
+	if err := json.Unmarshal(get(t, s, "/admin/profiles/view.json?id=1&view=top&ignore=main%5C.c", false).Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	} else if v.SampleType != "cpu" || v.Unit != "nanoseconds" || v.Total != 17*int64(time.Millisecond) || len(v.Top) != 3 || v.Top[0].Name != "main.b" {
+		t.Errorf("unexpected view %+v", v)
+	}
+	v = profileView{}
+	if err := json.Unmarshal(get(t, s, "/admin/profiles/view.json?id=1&sample=samples&focus=main%5C.c", false).Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	} else if v.View != "flame" || v.Total != 3 || v.Flame == nil || v.Flame.Value != 3 || len(v.SampleTypes) != 2 {
+		t.Errorf("unexpected view %+v", v)
+	}
+
+	body := get(t, s, "/admin/profiles/view?id=1", true).Body.String()
+	if !strings.Contains(body, `data-uri="/admin/profiles/view.json?id=1"`) || !strings.Contains(body, "js/flame-graph.js") || !strings.Contains(body, "20ms of cpu") {
+		t.Errorf("unexpected page %s", body)
+	}
+	body = get(t, s, "/admin/profiles/view?id=1&view=top", true).Body.String()
+	if !strings.Contains(body, `href="/admin/profiles/view?id=1&amp;peek=%5Emain%5C.b%24&amp;view=peek"`) || !strings.Contains(body, "65.0%") {
+		t.Errorf("unexpected page %s", body)
+	}
+	body = get(t, s, "/admin/profiles/view?id=1&view=peek&peek=main%5C.c", true).Body.String()
+	if !strings.Contains(body, "<strong>main.c</strong>") || !strings.Contains(body, `href="/admin/profiles/`+"1"+`"`) {
+		t.Errorf("unexpected page %s", body)
+	}
+	if body := get(t, s, "/admin/profiles/view", true).Body.String(); !strings.Contains(body, `href="/admin/profiles/view?id=1"`) {
+		t.Errorf("unexpected page %s", body)
+	}
+
+	for path, code := range map[string]int{
+		"/admin/profiles/view.json":                     http.StatusBadRequest,
+		"/admin/profiles/view.json?id=2":                http.StatusNotFound,
+		"/admin/profiles/view.json?capture=1":           http.StatusNotFound,
+		"/admin/profiles/view.json?id=1&view=nonsense":  http.StatusBadRequest,
+		"/admin/profiles/view.json?id=1&focus=(":        http.StatusBadRequest,
+		"/admin/profiles/view.json?id=1&sample=alloc":   http.StatusBadRequest,
+		"/admin/profiles/view.json?id=1&view=top&n=0":   http.StatusBadRequest,
+		"/admin/profiles/view.json?id=1&view=graph&n=2": http.StatusOK,
+	} {
+		if w := get(t, s, path, false); w.Code != code {
+			t.Errorf("%s: unexpected %d, expected %d", path, w.Code, code)
+		}
+	}
+}
+
+func TestViewHeapProfile(t *testing.T) {
+	s := &HTTPServer{}
+	p, err := s.getProfiles().capture(context.Background(), "heap", 0, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v profileView
+	if err := json.Unmarshal(get(t, s, "/admin/profiles/view.json?view=graph&id="+strconv.Itoa(p.ID), false).Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	} else if v.SampleType != "inuse_space" || v.Unit != "bytes" || v.Graph == nil {
+		t.Errorf("unexpected view %+v", v)
+	}
+}
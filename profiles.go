@@ -0,0 +1,528 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// defaultProfileStoreSize is the maximum total size of stored profiles when Opts.ProfileStoreSize isn't set.
+const defaultProfileStoreSize = 64 << 20
+
+// defaultProfileCPUDuration is how long CPU profiles are captured for if not otherwise given.
+const defaultProfileCPUDuration = 10 * time.Second
+
+// maxProfileCPUDuration is the longest CPU profile that can be captured via /admin/profiles, since nothing else can
+// use the CPU profiler meanwhile.
+const maxProfileCPUDuration = time.Minute
+
+// profileThresholdInterval is how often the thresholds set via Opts are checked.
+var profileThresholdInterval = 10 * time.Second
+
+// profileKinds are the kinds of profile that can be captured. cpu profiles are sampled over a duration; the others
+// are snapshots of the runtime's profiles.
+var profileKinds = []string{"cpu", "heap", "allocs", "goroutine", "mutex", "block", "threadcreate"}
+
+// checkProfileKind returns an error if the given kind of profile can't be captured.
+func checkProfileKind(kind string) error {
+	if kind != "cpu" && pprof.Lookup(kind) == nil {
+		return fmt.Errorf("unknown profile kind %q, must be one of %s", kind, strings.Join(profileKinds, ", "))
+	}
+	return nil
+}
+
+// A storedProfile describes a profile held in a profileStore.
+type storedProfile struct {
+	ID      int               `json:"id"`
+	Kind    string            `json:"kind"`
+	Time    time.Time         `json:"time"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Trigger string            `json:"trigger"`
+	Size    int64             `json:"size"`
+}
+
+// filename is the name the profile is downloaded as.
+func (p *storedProfile) filename() string {
+	return fmt.Sprintf("%s-%d-%s.pb.gz", p.Kind, p.ID, p.Time.UTC().Format("20060102T150405Z"))
+}
+
+// A profileStore holds captured profiles, either in memory or on disk, discarding the oldest once their total size
+// exceeds its limit.
+type profileStore struct {
+	mutex    sync.Mutex
+	dir      string
+	maxBytes int64
+	nextID   int
+	profiles []*storedProfile
+	data     map[int][]byte
+	// limits are those on the CPU profiler, which are shared with everything else that uses it.
+	limits *debugLimiters
+}
+
+// newProfileStore returns a store holding up to maxBytes of profiles. If dir is set, profiles are stored in it,
+// and any already there are loaded; otherwise they're held in memory.
+func newProfileStore(dir string, maxBytes int64) (*profileStore, error) {
+	s := &profileStore{dir: dir, maxBytes: maxBytes, data: map[int][]byte{}}
+	if dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		p := &storedProfile{}
+		if err := json.Unmarshal(b, p); err != nil {
+			log.Warningf("Ignoring stored profile %s: %s", file, err)
+			continue
+		} else if _, err := os.Stat(s.path(p.ID)); err != nil {
+			log.Warningf("Ignoring stored profile %s: %s", file, err)
+			continue
+		}
+		s.profiles = append(s.profiles, p)
+		if p.ID > s.nextID {
+			s.nextID = p.ID
+		}
+	}
+	sort.Slice(s.profiles, func(i, j int) bool { return s.profiles[i].ID < s.profiles[j].ID })
+	s.evict()
+	return s, nil
+}
+
+func (s *profileStore) path(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id)+".pb.gz")
+}
+
+// add stores a profile, assigning it an ID.
+func (s *profileStore) add(p *storedProfile, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p.ID = s.nextID + 1
+	p.Size = int64(len(data))
+	if s.dir != "" {
+		meta, _ := json.Marshal(p)
+		if err := os.WriteFile(s.path(p.ID), data, 0644); err != nil {
+			return err
+		} else if err := os.WriteFile(filepath.Join(s.dir, strconv.Itoa(p.ID)+".json"), meta, 0644); err != nil {
+			os.Remove(s.path(p.ID))
+			return err
+		}
+	} else {
+		s.data[p.ID] = data
+	}
+	s.nextID = p.ID
+	s.profiles = append(s.profiles, p)
+	s.evict()
+	return nil
+}
+
+// evict discards the oldest profiles until they fit within the store's size, always keeping the newest.
+func (s *profileStore) evict() {
+	var total int64
+	for _, p := range s.profiles {
+		total += p.Size
+	}
+	for total > s.maxBytes && len(s.profiles) > 1 {
+		p := s.profiles[0]
+		s.profiles = s.profiles[1:]
+		total -= p.Size
+		delete(s.data, p.ID)
+		if s.dir != "" {
+			os.Remove(s.path(p.ID))
+			os.Remove(filepath.Join(s.dir, strconv.Itoa(p.ID)+".json"))
+		}
+	}
+}
+
+// list returns the stored profiles, newest first.
+func (s *profileStore) list() []*storedProfile {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ret := make([]*storedProfile, len(s.profiles))
+	for i, p := range s.profiles {
+		ret[len(ret)-1-i] = p
+	}
+	return ret
+}
+
+// get returns a stored profile & its data.
+func (s *profileStore) get(id string) (*storedProfile, []byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, p := range s.profiles {
+		if strconv.Itoa(p.ID) != id {
+			continue
+		} else if s.dir == "" {
+			return p, s.data[p.ID], nil
+		}
+		data, err := os.ReadFile(s.path(p.ID))
+		return p, data, err
+	}
+	return nil, nil, fmt.Errorf("unknown profile %q", id)
+}
+
+// captureProfile captures a profile of the given kind in the gzipped protobuf format. CPU profiles are captured for
+// the given duration, or until the context is done, subject to the limits on the CPU profiler.
+func captureProfile(ctx context.Context, limits *debugLimiters, kind string, duration time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	if kind == "cpu" {
+		err := limits.do(ctx, cpuProfilerResource, duration, func(ctx context.Context) error {
+			if err := pprof.StartCPUProfile(&buf); err != nil {
+				return err
+			}
+			select {
+			case <-time.After(duration):
+			case <-ctx.Done():
+			}
+			pprof.StopCPUProfile()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := checkProfileKind(kind); err != nil {
+		return nil, err
+	} else if err := pprof.Lookup(kind).WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// capture captures a profile of the given kind and stores it.
+func (s *profileStore) capture(ctx context.Context, kind string, duration time.Duration, trigger string, labels map[string]string) (*storedProfile, error) {
+	data, err := captureProfile(ctx, s.limits, kind, duration)
+	if err != nil {
+		return nil, err
+	}
+	p := &storedProfile{Kind: kind, Time: time.Now(), Labels: labels, Trigger: trigger}
+	if err := s.add(p, data); err != nil {
+		return nil, err
+	}
+	log.Infof("Captured %s profile %d (%s)", kind, p.ID, trigger)
+	return p, nil
+}
+
+// diff returns a profile of the difference between two stored profiles, i.e. target minus base.
+// Samples that are the same in both are omitted.
+func (s *profileStore) diff(baseID, targetID string) (*profile.Profile, error) {
+	parse := func(id string) (*profile.Profile, error) {
+		_, data, err := s.get(id)
+		if err != nil {
+			return nil, err
+		}
+		return profile.ParseData(data)
+	}
+	base, err := parse(baseID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := parse(targetID)
+	if err != nil {
+		return nil, err
+	}
+	base.Scale(-1)
+	diff, err := profile.Merge([]*profile.Profile{target, base})
+	if err != nil {
+		return nil, fmt.Errorf("profiles %s and %s can't be diffed: %s", baseID, targetID, err)
+	}
+	samples := diff.Sample[:0]
+	for _, sample := range diff.Sample {
+		for _, v := range sample.Value {
+			if v != 0 {
+				samples = append(samples, sample)
+				break
+			}
+		}
+	}
+	diff.Sample = samples
+	return diff, nil
+}
+
+// profileTriggers are the conditions on which profiles are captured automatically.
+type profileTriggers struct {
+	schedule           time.Duration
+	scheduleKinds      []string
+	cpuDuration        time.Duration
+	heapThreshold      int64
+	goroutineThreshold int
+}
+
+// run captures profiles according to the triggers until the context is done.
+func (s *profileStore) run(ctx context.Context, t profileTriggers) {
+	var scheduled, thresholds <-chan time.Time
+	if t.schedule > 0 && len(t.scheduleKinds) > 0 {
+		ticker := time.NewTicker(t.schedule)
+		defer ticker.Stop()
+		scheduled = ticker.C
+	}
+	if t.heapThreshold > 0 || t.goroutineThreshold > 0 {
+		ticker := time.NewTicker(profileThresholdInterval)
+		defer ticker.Stop()
+		thresholds = ticker.C
+	}
+	if scheduled == nil && thresholds == nil {
+		return
+	}
+	var heapExceeded, goroutinesExceeded bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-scheduled:
+			for _, kind := range t.scheduleKinds {
+				if _, err := s.capture(withDebugSource(ctx, "profile schedule"), kind, t.cpuDuration, "schedule", nil); err != nil {
+					log.Errorf("Failed to capture scheduled %s profile: %s", kind, err)
+				}
+			}
+		case <-thresholds:
+			heapExceeded = s.checkThreshold(ctx, heapExceeded, "heap", float64(heapObjectBytes()), float64(t.heapThreshold), formatBytes)
+			goroutinesExceeded = s.checkThreshold(ctx, goroutinesExceeded, "goroutine", float64(runtime.NumGoroutine()), float64(t.goroutineThreshold),
+				func(n uint64) string { return strconv.FormatUint(n, 10) + " goroutines" })
+		}
+	}
+}
+
+// checkThreshold captures a profile of the given kind when a value first exceeds its threshold, if it's set.
+// It returns whether the value exceeds the threshold, so that no more are captured until it drops below it again.
+func (s *profileStore) checkThreshold(ctx context.Context, exceeded bool, kind string, value, threshold float64, format func(uint64) string) bool {
+	if threshold <= 0 || value <= threshold {
+		return false
+	} else if !exceeded {
+		trigger := fmt.Sprintf("%s > %s", format(uint64(value)), format(uint64(threshold)))
+		if _, err := s.capture(ctx, kind, 0, trigger, nil); err != nil {
+			log.Errorf("Failed to capture %s profile: %s", kind, err)
+		}
+	}
+	return true
+}
+
+// heapObjectBytes returns the size of the heap's live & unswept objects, which is cheap to read.
+func heapObjectBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// newProfileStoreFromOpts returns the profile store, and the triggers to capture profiles on, set by the given Opts.
+func newProfileStoreFromOpts(opts Opts) (*profileStore, profileTriggers, error) {
+	size := int64(defaultProfileStoreSize)
+	if opts.ProfileStoreSize != "" {
+		var err error
+		if size, err = parseBytes(opts.ProfileStoreSize); err != nil {
+			return nil, profileTriggers{}, fmt.Errorf("invalid profile store size: %s", err)
+		}
+	}
+	t := profileTriggers{
+		schedule:           opts.ProfileSchedule,
+		scheduleKinds:      opts.ProfileScheduleKinds,
+		cpuDuration:        opts.ProfileCPUDuration,
+		goroutineThreshold: opts.ProfileGoroutineThreshold,
+	}
+	if t.cpuDuration <= 0 {
+		t.cpuDuration = defaultProfileCPUDuration
+	}
+	if opts.ProfileHeapThreshold != "" {
+		var err error
+		if t.heapThreshold, err = parseBytes(opts.ProfileHeapThreshold); err != nil {
+			return nil, t, fmt.Errorf("invalid profile heap threshold: %s", err)
+		}
+	}
+	for _, kind := range t.scheduleKinds {
+		if err := checkProfileKind(kind); err != nil {
+			return nil, t, err
+		}
+	}
+	store, err := newProfileStore(opts.ProfileDir, size)
+	if err != nil {
+		return nil, t, fmt.Errorf("failed to open profile store: %s", err)
+	}
+	return store, t, nil
+}
+
+// getProfiles returns the server's profile store, which holds profiles in memory if it's not been set via Start.
+func (a *HTTPServer) getProfiles() *profileStore {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.profiles == nil {
+		a.profiles, _ = newProfileStore("", defaultProfileStoreSize)
+		a.profiles.limits = a.debugLimitersLocked()
+	}
+	return a.profiles
+}
+
+// parseProfileLabels parses labels of the form a=b,c=d.
+func parseProfileLabels(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, label := range strings.Split(s, ",") {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid label %q, labels must be of the form name=value", label)
+		}
+		labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return labels, nil
+}
+
+type profilesView struct {
+	Kinds    []string
+	Profiles []*storedProfile
+	CSRF     template.HTML
+}
+
+var profilesTemplate = template.Must(template.New("profiles").Funcs(template.FuncMap{
+	"bytes": func(n int64) string { return formatBytes(uint64(n)) },
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+}).Parse(`
+<form method="POST" action="/admin/profiles" class="form-inline mb-3">{{.CSRF}}
+	<select name="kind" class="form-control form-control-sm mr-2 mb-1">
+		{{range .Kinds}}<option value="{{.}}">{{.}}</option>{{end}}
+	</select>
+	<input type="number" name="seconds" min="1" max="60" class="form-control form-control-sm mr-2 mb-1" placeholder="CPU seconds (10)"/>
+	<input type="text" name="labels" class="form-control form-control-sm mr-2 mb-1" placeholder="Labels, e.g. build=123,reason=slow"/>
+	<input type="submit" class="btn btn-sm btn-primary mb-1" value="Capture"/>
+</form>
+{{if .Profiles}}
+<form method="GET" action="/admin/profiles/diff" class="form-inline mb-3">
+	Diff
+	<select name="base" class="form-control form-control-sm mx-2 mb-1">
+		{{range .Profiles}}<option value="{{.ID}}">{{.ID}}: {{.Kind}} at {{time .Time}}</option>{{end}}
+	</select>
+	to
+	<select name="target" class="form-control form-control-sm mx-2 mb-1">
+		{{range .Profiles}}<option value="{{.ID}}">{{.ID}}: {{.Kind}} at {{time .Time}}</option>{{end}}
+	</select>
+	<input type="submit" class="btn btn-sm btn-secondary mb-1" value="Download diff"/>
+</form>
+<table class="table table-sm">
+	<thead><tr><th>id</th><th>kind</th><th>captured</th><th>trigger</th><th>labels</th><th>size</th><th></th></tr></thead>
+	<tbody>
+	{{range .Profiles}}
+		<tr>
+			<td>{{.ID}}</td>
+			<td>{{.Kind}}</td>
+			<td>{{time .Time}}</td>
+			<td>{{.Trigger}}</td>
+			<td>{{range $k, $v := .Labels}}<span class="badge badge-light">{{$k}}={{$v}}</span> {{end}}</td>
+			<td>{{bytes .Size}}</td>
+			<td><a href="/admin/profiles/view?id={{.ID}}">view</a> <a href="/admin/profiles/{{.ID}}">download</a></td>
+		</tr>
+	{{end}}
+	</tbody>
+</table>
+{{else}}
+<p>No profiles have been captured.</p>
+{{end}}
+`))
+
+// profilesHandler lists the stored profiles, with forms to capture & diff them.
+func (a *HTTPServer) profilesHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	view := profilesView{Kinds: profileKinds, Profiles: a.getProfiles().list(), CSRF: CSRFField(r)}
+	if err := profilesTemplate.Execute(w, view); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// profilesJSONHandler lists the stored profiles, newest first.
+func (a *HTTPServer) profilesJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.getProfiles().list())
+	w.Write(b)
+}
+
+// captureProfileHandler captures a profile of the kind given by the kind form value. CPU profiles are captured for
+// the number of seconds given, or 10. The labels form value attaches labels of the form a=b,c=d.
+func (a *HTTPServer) captureProfileHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	kind := r.Form.Get("kind")
+	max := a.getDebugLimiters().maxDuration(cpuProfilerResource, maxProfileCPUDuration)
+	duration := defaultProfileCPUDuration
+	if s := r.Form.Get("seconds"); s != "" {
+		seconds, err := strconv.Atoi(s)
+		if err != nil || seconds < 1 || float64(seconds) > max.Seconds() {
+			http.Error(w, fmt.Sprintf("seconds must be a positive integer, at most %g", max.Seconds()), http.StatusBadRequest)
+			return
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	labels, err := parseProfileLabels(r.Form.Get("labels"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := checkProfileKind(kind); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := a.getProfiles().capture(withDebugSource(r.Context(), r.RemoteAddr), kind, duration, "manual", labels)
+	if writeDebugLimitError(w, err) {
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	AnnotateAudit(r, fmt.Sprintf("captured %s profile %d", kind, p.ID))
+	if expectsHTML(r) {
+		http.Redirect(w, r, "/admin/profiles", http.StatusSeeOther)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(p)
+	w.Write(b)
+}
+
+// profileHandler downloads the profile whose ID follows /admin/profiles/.
+func (a *HTTPServer) profileHandler(w http.ResponseWriter, r *http.Request) {
+	p, data, err := a.getProfiles().get(strings.TrimPrefix(r.URL.Path, "/admin/profiles/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeContentType(w, "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, p.filename()))
+	w.Write(data)
+}
+
+// profileDiffHandler downloads a profile of the difference between the profiles given by the base & target
+// parameters, which can be viewed with go tool pprof.
+func (a *HTTPServer) profileDiffHandler(w http.ResponseWriter, r *http.Request) {
+	base, target := r.URL.Query().Get("base"), r.URL.Query().Get("target")
+	diff, err := a.getProfiles().diff(base, target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeContentType(w, "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="diff-%s-%s.pb.gz"`, base, target))
+	if err := diff.Write(w); err != nil {
+		log.Errorf("Failed to write profile diff: %s", err)
+	}
+}
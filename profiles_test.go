@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func postCapture(t *testing.T, s http.Handler, form url.Values) storedProfile {
+	t.Helper()
+	form.Set(csrfField, "x")
+	w := post(s, "/admin/profiles", form, "x", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("%v: unexpected %d: %s", form, w.Code, w.Body.String())
+	}
+	var p storedProfile
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProfiles(t *testing.T) {
+	s := &HTTPServer{}
+
+	heap := postCapture(t, s, url.Values{"kind": {"heap"}, "labels": {"build=123, reason=testing"}})
+	if heap.Kind != "heap" || heap.Trigger != "manual" || heap.Size == 0 || heap.Labels["reason"] != "testing" || heap.Labels["build"] != "123" {
+		t.Errorf("unexpected profile %+v", heap)
+	}
+	cpu := postCapture(t, s, url.Values{"kind": {"cpu"}, "seconds": {"1"}})
+
+	var ps []storedProfile
+	if err := json.Unmarshal(get(t, s, "/admin/profiles.json", false).Body.Bytes(), &ps); err != nil {
+		t.Fatal(err)
+	} else if len(ps) != 2 || ps[0].ID != cpu.ID || ps[1].ID != heap.ID {
+		t.Errorf("unexpected profiles %+v", ps)
+	}
+
+	w := get(t, s, "/admin/profiles/"+strconv.Itoa(heap.ID), false)
+	if p, err := profile.Parse(w.Body); err != nil {
+		t.Errorf("downloaded profile doesn't parse: %s", err)
+	} else if p.SampleType[0].Type != "alloc_objects" {
+		t.Errorf("unexpected profile %s", p.SampleType[0].Type)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="heap-`+strconv.Itoa(heap.ID)+"-") {
+		t.Errorf("unexpected Content-Disposition %s", cd)
+	}
+
+	body := get(t, s, "/admin/profiles", true).Body.String()
+	if !strings.Contains(body, `href="/admin/profiles/`+strconv.Itoa(cpu.ID)+`"`) || !strings.Contains(body, "reason=testing") {
+		t.Errorf("unexpected page %s", body)
+	}
+
+	for _, form := range []url.Values{
+		{"kind": {"nonsense"}},
+		{"kind": {"cpu"}, "seconds": {"0"}},
+		{"kind": {"cpu"}, "seconds": {"100000"}},
+		{"kind": {"heap"}, "labels": {"nolabel"}},
+	} {
+		form.Set(csrfField, "x")
+		if w := post(s, "/admin/profiles", form, "x", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: unexpected %d", form, w.Code)
+		}
+	}
+	for _, path := range []string{"/admin/profiles/999", "/admin/profiles/nonsense"} {
+		if w := get(t, s, path, false); w.Code != http.StatusNotFound {
+			t.Errorf("%s: unexpected %d", path, w.Code)
+		}
+	}
+}
+
+func TestProfileDiff(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	s := &HTTPServer{}
+
+	base := postCapture(t, s, url.Values{"kind": {"goroutine"}})
+	startGoroutineTestBlockers(t, 3, done)
+	target := postCapture(t, s, url.Values{"kind": {"goroutine"}})
+
+	w := get(t, s, "/admin/profiles/diff?base="+strconv.Itoa(base.ID)+"&target="+strconv.Itoa(target.ID), false)
+	diff, err := profile.Parse(w.Body)
+	if err != nil {
+		t.Fatalf("diff doesn't parse: %s", err)
+	}
+	var blockers int64
+	for _, sample := range diff.Sample {
+		for _, loc := range sample.Location {
+			if len(loc.Line) > 0 && strings.HasSuffix(loc.Line[0].Function.Name, ".goroutineTestBlocker") {
+				blockers += sample.Value[0]
+				break
+			}
+		}
+	}
+	if blockers != 3 {
+		t.Errorf("diff has %d more blocked goroutines, expected 3", blockers)
+	}
+
+	heap := postCapture(t, s, url.Values{"kind": {"heap"}})
+	for _, query := range []string{"base=" + strconv.Itoa(heap.ID) + "&target=" + strconv.Itoa(target.ID), "base=999&target=1"} {
+		if w := get(t, s, "/admin/profiles/diff?"+query, false); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: unexpected %d", query, w.Code)
+		}
+	}
+}
+
+func TestProfileStoreOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newProfileStore(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.add(&storedProfile{Kind: "heap", Trigger: "test"}, bytes.Repeat([]byte{'x'}, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ps := s.list(); len(ps) != 2 || ps[0].ID != 3 || ps[1].ID != 2 {
+		t.Errorf("unexpected profiles %+v", ps)
+	}
+	if _, _, err := s.get("1"); err == nil {
+		t.Error("evicted profile still present")
+	}
+
+	// Profiles are reloaded from the directory, and new ones numbered after them.
+	s, err = newProfileStore(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, data, err := s.get("3"); err != nil || p.Trigger != "test" || len(data) != 10 {
+		t.Errorf("unexpected reloaded profile %+v, %d bytes, %v", p, len(data), err)
+	}
+	s.add(&storedProfile{Kind: "heap"}, nil)
+	if ps := s.list(); ps[0].ID != 4 {
+		t.Errorf("unexpected profiles %+v", ps)
+	}
+}
+
+func TestProfileThresholds(t *testing.T) {
+	s, _ := newProfileStore("", defaultProfileStoreSize)
+	ctx := context.Background()
+	format := func(n uint64) string { return strconv.FormatUint(n, 10) }
+
+	if s.checkThreshold(ctx, false, "goroutine", 5, 10, format) || len(s.list()) != 0 {
+		t.Error("captured below the threshold")
+	}
+	if !s.checkThreshold(ctx, false, "goroutine", 15, 10, format) || len(s.list()) != 1 || s.list()[0].Trigger != "15 > 10" {
+		t.Errorf("didn't capture above the threshold: %+v", s.list())
+	}
+	if !s.checkThreshold(ctx, true, "goroutine", 20, 10, format) || len(s.list()) != 1 {
+		t.Error("captured again while still above the threshold")
+	}
+	if s.checkThreshold(ctx, true, "goroutine", 5, 10, format) || s.checkThreshold(ctx, false, "goroutine", 15, 0, format) {
+		t.Error("still above the threshold")
+	}
+}
+
+func TestProfileOpts(t *testing.T) {
+	for _, opts := range []Opts{
+		{ProfileStoreSize: "lots"},
+		{ProfileHeapThreshold: "-1"},
+		{ProfileScheduleKinds: []string{"heap", "nonsense"}},
+	} {
+		if _, _, err := newProfileStoreFromOpts(opts); err == nil {
+			t.Errorf("%+v accepted", opts)
+		}
+	}
+	s, triggers, err := newProfileStoreFromOpts(Opts{ProfileStoreSize: "1MiB", ProfileHeapThreshold: "1GiB", ProfileScheduleKinds: []string{"cpu"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if s.maxBytes != 1<<20 || triggers.heapThreshold != 1<<30 || triggers.cpuDuration != defaultProfileCPUDuration {
+		t.Errorf("unexpected store %+v, triggers %+v", s, triggers)
+	}
+}
@@ -0,0 +1,376 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_model/go"
+)
+
+// A RegistryEntry describes a server or client registered with the admin server, and the Prometheus
+// metrics that describe its traffic. Series are selected from each metric by matching all of Labels.
+type RegistryEntry struct {
+	// Name uniquely identifies the entry amongst those of the same kind. It may not contain a slash, end in .json
+	// or be index.txt, since those paths are used by the admin pages.
+	Name string
+	// Address is where the server listens, or where the client connects to.
+	Address string
+	// Protocol is a free-form description of the protocol spoken, e.g. "http" or "grpc".
+	Protocol string
+	// Labels selects this entry's series from the metrics below.
+	Labels map[string]string
+	// RequestsMetric is a counter of requests handled (or made).
+	RequestsMetric string
+	// FailuresMetric is a counter of requests that failed.
+	FailuresMetric string
+	// LatencyMetric is a histogram or summary of request latency in seconds.
+	LatencyMetric string
+}
+
+// A Registry holds the servers & clients in this process.
+type Registry struct {
+	mutex   sync.RWMutex
+	servers map[string]RegistryEntry
+	clients map[string]RegistryEntry
+}
+
+// DefaultRegistry is the registry used by the admin server unless Opts.Registry is set.
+var DefaultRegistry = &Registry{}
+
+// RegisterServer registers a server with the DefaultRegistry.
+func RegisterServer(entry RegistryEntry) error {
+	return DefaultRegistry.RegisterServer(entry)
+}
+
+// RegisterClient registers a client with the DefaultRegistry.
+func RegisterClient(entry RegistryEntry) error {
+	return DefaultRegistry.RegisterClient(entry)
+}
+
+// RegisterServer registers a server. An error is returned if a server with the same name is already registered.
+func (r *Registry) RegisterServer(entry RegistryEntry) error {
+	return r.register(&r.servers, "server", entry)
+}
+
+// RegisterClient registers a client. An error is returned if a client with the same name is already registered.
+func (r *Registry) RegisterClient(entry RegistryEntry) error {
+	return r.register(&r.clients, "client", entry)
+}
+
+// UnregisterServer removes a previously registered server.
+func (r *Registry) UnregisterServer(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.servers, name)
+}
+
+// UnregisterClient removes a previously registered client.
+func (r *Registry) UnregisterClient(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.clients, name)
+}
+
+func (r *Registry) register(entries *map[string]RegistryEntry, kind string, entry RegistryEntry) error {
+	if entry.Name == "" || strings.Contains(entry.Name, "/") || strings.HasSuffix(entry.Name, ".json") || entry.Name == "index.txt" {
+		return fmt.Errorf("invalid %s name %q", kind, entry.Name)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if *entries == nil {
+		*entries = map[string]RegistryEntry{}
+	}
+	if _, present := (*entries)[entry.Name]; present {
+		return fmt.Errorf("%s %s is already registered", kind, entry.Name)
+	}
+	(*entries)[entry.Name] = entry
+	return nil
+}
+
+// Servers returns all registered servers, sorted by name.
+func (r *Registry) Servers() []RegistryEntry {
+	return r.sorted(&r.servers)
+}
+
+// Clients returns all registered clients, sorted by name.
+func (r *Registry) Clients() []RegistryEntry {
+	return r.sorted(&r.clients)
+}
+
+// sorted returns the entries sorted by name. They're passed by reference, so that they're only read under the lock.
+func (r *Registry) sorted(entries *map[string]RegistryEntry) []RegistryEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ret := make([]RegistryEntry, 0, len(*entries))
+	for _, e := range *entries {
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// registryStats are the current statistics of a registry entry, computed from its metrics.
+type registryStats struct {
+	Name        string   `json:"name"`
+	Requests    float64  `json:"requests"`
+	Failures    float64  `json:"failures"`
+	SuccessRate *float64 `json:"success_rate"`
+	MeanLatency *float64 `json:"mean_latency_seconds"`
+}
+
+// SuccessClass returns the CSS class used to colour the success rate.
+func (s registryStats) SuccessClass() string {
+	if s.SuccessRate == nil {
+		return "sr-undefined"
+	} else if *s.SuccessRate >= 99.5 {
+		return "sr-good"
+	} else if *s.SuccessRate >= 95 {
+		return "sr-poor"
+	}
+	return "sr-bad"
+}
+
+// FormattedSuccessRate returns the success rate for display.
+func (s registryStats) FormattedSuccessRate() string {
+	if s.SuccessRate == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.4g%%", *s.SuccessRate)
+}
+
+func computeStats(mfs []*io_prometheus_client.MetricFamily, e RegistryEntry) registryStats {
+	stats := registryStats{Name: e.Name}
+	var latencySum, latencyCount float64
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			if !labelsMatch(m.GetLabel(), e.Labels) {
+				continue
+			}
+			switch mf.GetName() {
+			case e.RequestsMetric:
+				stats.Requests += sampleValue(mf.GetType(), m)
+			case e.FailuresMetric:
+				stats.Failures += sampleValue(mf.GetType(), m)
+			case e.LatencyMetric:
+				if h := m.GetHistogram(); h != nil {
+					latencySum += h.GetSampleSum()
+					latencyCount += float64(h.GetSampleCount())
+				} else if s := m.GetSummary(); s != nil {
+					latencySum += s.GetSampleSum()
+					latencyCount += float64(s.GetSampleCount())
+				}
+			}
+		}
+	}
+	if stats.Requests > 0 {
+		sr := (1.0 - stats.Failures/stats.Requests) * 100.0
+		stats.SuccessRate = &sr
+	}
+	if latencyCount > 0 {
+		mean := latencySum / latencyCount
+		stats.MeanLatency = &mean
+	}
+	return stats
+}
+
+// labelsMatch returns true if the given labels include all of the wanted ones.
+func labelsMatch(labels []*io_prometheus_client.LabelPair, want map[string]string) bool {
+	for name, value := range want {
+		found := false
+		for _, l := range labels {
+			if l.GetName() == name {
+				found = l.GetValue() == value
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sampleValue returns a single value for a metric; the count of observations for histograms & summaries.
+func sampleValue(t io_prometheus_client.MetricType, m *io_prometheus_client.Metric) float64 {
+	switch t {
+	case io_prometheus_client.MetricType_COUNTER:
+		return m.GetCounter().GetValue()
+	case io_prometheus_client.MetricType_GAUGE:
+		return m.GetGauge().GetValue()
+	case io_prometheus_client.MetricType_HISTOGRAM:
+		return float64(m.GetHistogram().GetSampleCount())
+	case io_prometheus_client.MetricType_SUMMARY:
+		return float64(m.GetSummary().GetSampleCount())
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+type registryEntryView struct {
+	RegistryEntry
+	ID         string
+	Href       string
+	RefreshURI string
+	Stats      registryStats
+}
+
+var registryTemplate = template.Must(template.New("registry").Parse(`
+<link type="text/css" href="/admin/files/css/server-registry.css" rel="stylesheet"/>
+<script type="application/javascript" src="/admin/files/js/chart-renderer.js"></script>
+<script type="application/javascript" src="/admin/files/js/server-registry.js"></script>
+{{if .Entries}}
+<div id="registry">
+	<ul class="nav nav-tabs" id="registry-tabs">
+{{range .Entries}}
+		<li class="nav-item"><a class="nav-link" data-toggle="tab" href="#{{.ID}}">{{.Name}}</a></li>
+{{end}}
+	</ul>
+	<div class="tab-content">
+{{range .Entries}}
+		<div class="tab-pane borders" id="{{.ID}}" data-refresh-uri="{{.RefreshURI}}">
+			<div class="row">
+				<div class="col-md-9"><div class="registry-graph"></div></div>
+				<div class="col-md-3">
+					<dl class="server-stats">
+						<dt>Address</dt><dd>{{.Address}}</dd>
+						<dt>Protocol</dt><dd>{{.Protocol}}</dd>
+						<dt>Requests</dt><dd data-key="requests">{{.Stats.Requests}}</dd>
+						<dt>Failures</dt><dd data-key="failures">{{.Stats.Failures}}</dd>
+						<dt>Success rate</dt><dd data-key="success_rate">{{.Stats.FormattedSuccessRate}}</dd>
+						<dt>Mean latency (s)</dt><dd data-key="mean_latency_seconds">{{with .Stats.MeanLatency}}{{.}}{{else}}N/A{{end}}</dd>
+					</dl>
+				</div>
+			</div>
+		</div>
+{{end}}
+	</div>
+</div>
+{{else}}
+<div class="alert alert-info" role="alert">No {{.Kind}}s have been registered.</div>
+{{end}}
+`))
+
+var registrySummaryTemplate = template.Must(template.New("registrySummary").Parse(`
+{{if .Entries}}
+<h5>{{.Title}}</h5>
+<div class="row">
+{{range .Entries}}
+	<div class="col-md-3">
+		<a href="{{.Href}}"><div class="client">
+			<h5 class="name">{{.Name}}</h5>
+			<p class="dest">{{.Protocol}} {{.Address}}</p>
+			<hr/>
+			<div class="row">
+				<div class="col-md-6 sr-header">success rate</div>
+				<div class="col-md-6 sr-text {{.Stats.SuccessClass}}">{{.Stats.FormattedSuccessRate}}</div>
+			</div>
+		</div></a>
+	</div>
+{{end}}
+</div>
+{{end}}
+`))
+
+// registryHandler serves the pages beneath basePath for either servers or clients:
+// the tabbed index at basePath, a summary fragment at index.txt, a page per entry and its statistics as JSON.
+func (a *HTTPServer) registryHandler(basePath, kind string, entries func() []RegistryEntry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, basePath)
+		all := entries()
+		mfs, err := gather()
+		if err != nil {
+			log.Warningf("Failed to gather metrics for %ss: %s", kind, err)
+		}
+		views := make([]registryEntryView, 0, len(all))
+		for i, e := range all {
+			views = append(views, registryEntryView{
+				RegistryEntry: e,
+				ID:            fmt.Sprintf("%s-%d", kind, i),
+				Href:          basePath + url.PathEscape(e.Name),
+				RefreshURI:    basePath + url.PathEscape(e.Name) + ".json",
+				Stats:         computeStats(mfs, e),
+			})
+		}
+
+		switch {
+		case name == "":
+			writeContentType(w, "text/html;charset=UTF-8")
+			if err := registryTemplate.Execute(w, struct {
+				Kind    string
+				Entries []registryEntryView
+			}{Kind: kind, Entries: views}); err != nil {
+				log.Errorf("%s", err)
+			}
+		case name == "index.txt":
+			writeContentType(w, "text/html;charset=UTF-8")
+			if err := registrySummaryTemplate.Execute(w, struct {
+				Title   string
+				Entries []registryEntryView
+			}{Title: strings.Title(kind) + "s", Entries: views}); err != nil {
+				log.Errorf("%s", err)
+			}
+		case strings.HasSuffix(name, ".json"):
+			for _, v := range views {
+				if v.Name == strings.TrimSuffix(name, ".json") {
+					writeContentType(w, "application/json;charset=UTF-8")
+					b, _ := json.Marshal(v.Stats)
+					w.Write(b)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		default:
+			for _, v := range views {
+				if v.Name == name {
+					writeContentType(w, "text/html;charset=UTF-8")
+					if err := registryTemplate.Execute(w, struct {
+						Kind    string
+						Entries []registryEntryView
+					}{Kind: kind, Entries: []registryEntryView{v}}); err != nil {
+						log.Errorf("%s", err)
+					}
+					return
+				}
+			}
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// registryEntries returns the sidebar groups for registered servers & clients.
+func (a *HTTPServer) registryEntries() []entry {
+	registry := a.getRegistry()
+	var entries []entry
+	for _, g := range []struct {
+		name, path string
+		entries    []RegistryEntry
+	}{
+		{"Servers", ServersPath, registry.Servers()},
+		{"Clients", ClientsPath, registry.Clients()},
+	} {
+		if len(g.entries) == 0 {
+			continue
+		}
+		links := make(entrySlice, 0, len(g.entries))
+		for _, e := range g.entries {
+			links = append(links, link{ID: e.Name, HRef: g.path + url.PathEscape(e.Name), Method: http.MethodGet})
+		}
+		entries = append(entries, group{Name: g.name, Links: links})
+	}
+	return entries
+}
+
+func (a *HTTPServer) getRegistry() *Registry {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.registry == nil {
+		return DefaultRegistry
+	}
+	return a.registry
+}
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func registryServer(t *testing.T) *HTTPServer {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rpc_requests_total"}, []string{"server"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rpc_failures_total"}, []string{"server"})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "rpc_latency_seconds"}, []string{"server"})
+	reg.MustRegister(requests, failures, latency)
+	requests.WithLabelValues("api").Add(200)
+	failures.WithLabelValues("api").Add(20)
+	requests.WithLabelValues("other").Add(5)
+	latency.WithLabelValues("api").Observe(0.5)
+	latency.WithLabelValues("api").Observe(1.5)
+
+	oldGatherer := Gatherer
+	Gatherer = reg
+	t.Cleanup(func() { Gatherer = oldGatherer })
+
+	s := &HTTPServer{registry: &Registry{}}
+	entry := RegistryEntry{
+		Name:           "api",
+		Address:        ":8080",
+		Protocol:       "http",
+		Labels:         map[string]string{"server": "api"},
+		RequestsMetric: "rpc_requests_total",
+		FailuresMetric: "rpc_failures_total",
+		LatencyMetric:  "rpc_latency_seconds",
+	}
+	if err := s.registry.RegisterServer(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.registry.RegisterServer(entry); err == nil {
+		t.Fatal("expected an error registering a duplicate server")
+	}
+	if err := s.registry.RegisterClient(RegistryEntry{Name: "downstream", Address: "db:5432", Protocol: "grpc"}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRegistryStats(t *testing.T) {
+	s := registryServer(t)
+	w := get(t, s, ServersPath+"api.json", false)
+	var stats registryStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 200 || stats.Failures != 20 || stats.SuccessRate == nil || *stats.SuccessRate != 90 ||
+		stats.MeanLatency == nil || *stats.MeanLatency != 1 {
+		t.Errorf("unexpected stats %s", w.Body.String())
+	}
+	if w := get(t, s, ServersPath+"missing.json", false); w.Code != http.StatusNotFound {
+		t.Errorf("unknown server returned %d", w.Code)
+	}
+}
+
+func TestRegistryPages(t *testing.T) {
+	s := registryServer(t)
+	summary := get(t, s, ServersPath+"index.txt", false).Body.String()
+	if !strings.Contains(summary, "api") || !strings.Contains(summary, "sr-bad") || !strings.Contains(summary, "90%") {
+		t.Errorf("unexpected server summary: %s", summary)
+	}
+	if summary := get(t, s, ClientsPath+"index.txt", false).Body.String(); !strings.Contains(summary, "downstream") || !strings.Contains(summary, "N/A") {
+		t.Errorf("unexpected client summary: %s", summary)
+	}
+	page := get(t, s, ServersPath+"api", true).Body.String()
+	for _, want := range []string{`id="registry-tabs"`, `data-refresh-uri="/admin/servers/api.json"`, "Servers", `href="/admin/clients/downstream"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("server page does not contain %q", want)
+		}
+	}
+	if w := get(t, s, ClientsPath+"missing", false); w.Code != http.StatusNotFound {
+		t.Errorf("unknown client returned %d", w.Code)
+	}
+}
+
+func TestRegistryRejectsInvalidNames(t *testing.T) {
+	r := &Registry{}
+	for _, name := range []string{"", "a/b", "db.json", "index.txt"} {
+		if err := r.RegisterClient(RegistryEntry{Name: name}); err == nil {
+			t.Errorf("expected an error registering %q", name)
+		}
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := &Registry{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			r.RegisterServer(RegistryEntry{Name: fmt.Sprintf("server-%d", i)})
+			r.RegisterClient(RegistryEntry{Name: fmt.Sprintf("client-%d", i)})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		r.Servers()
+		r.Clients()
+	}
+	<-done
+	if len(r.Servers()) != 100 || len(r.Clients()) != 100 {
+		t.Errorf("unexpected entries %d servers, %d clients", len(r.Servers()), len(r.Clients()))
+	}
+}
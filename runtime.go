@@ -0,0 +1,323 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// runtimeMutex serialises changes to runtime settings, so each change's before & after are consistent.
+var runtimeMutex sync.Mutex
+
+// blockProfileRate is the rate last set via runtime.SetBlockProfileRate, which the runtime has no way to read back.
+// It only reflects changes made through the admin server.
+var blockProfileRate int64
+
+// runtimeSettings are the tunable settings of the Go runtime.
+type runtimeSettings struct {
+	// GOGC is the GC target percentage, or -1 if GC is off.
+	GOGC int `json:"gogc"`
+	// MemoryLimit is the soft memory limit in bytes, math.MaxInt64 if there is none.
+	MemoryLimit          int64 `json:"memory_limit"`
+	GOMAXPROCS           int   `json:"gomaxprocs"`
+	NumCPU               int   `json:"num_cpu"`
+	BlockProfileRate     int   `json:"block_profile_rate"`
+	MutexProfileFraction int   `json:"mutex_profile_fraction"`
+}
+
+func currentRuntimeSettings() runtimeSettings {
+	// Read via runtime/metrics, since debug.SetGCPercent can only read GOGC by changing it.
+	samples := []metrics.Sample{{Name: "/gc/gogc:percent"}, {Name: "/gc/gomemlimit:bytes"}}
+	metrics.Read(samples)
+	return runtimeSettings{
+		// GOGC=off is reported as -1 converted to a uint64.
+		GOGC:                 int(int64(samples[0].Value.Uint64())),
+		MemoryLimit:          int64(samples[1].Value.Uint64()),
+		GOMAXPROCS:           runtime.GOMAXPROCS(0),
+		NumCPU:               runtime.NumCPU(),
+		BlockProfileRate:     int(atomic.LoadInt64(&blockProfileRate)),
+		MutexProfileFraction: runtime.SetMutexProfileFraction(-1),
+	}
+}
+
+// heapStats are the memory statistics shown before & after each change.
+type heapStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapSys      uint64 `json:"heap_sys"`
+	Sys          uint64 `json:"sys"`
+	NextGC       uint64 `json:"next_gc"`
+	NumGC        uint32 `json:"num_gc"`
+}
+
+func currentHeapStats() heapStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return heapStats{
+		HeapAlloc:    ms.HeapAlloc,
+		HeapInuse:    ms.HeapInuse,
+		HeapIdle:     ms.HeapIdle,
+		HeapReleased: ms.HeapReleased,
+		HeapSys:      ms.HeapSys,
+		Sys:          ms.Sys,
+		NextGC:       ms.NextGC,
+		NumGC:        ms.NumGC,
+	}
+}
+
+// A runtimeAction changes a runtime setting given the value submitted, returning a description of the change.
+type runtimeAction func(before runtimeSettings, value string) (string, error)
+
+var runtimeActions = map[string]runtimeAction{
+	"gogc": func(before runtimeSettings, value string) (string, error) {
+		gogc := -1
+		if value != "off" {
+			var err error
+			if gogc, err = strconv.Atoi(value); err != nil || gogc < 0 {
+				return "", fmt.Errorf("GOGC must be a non-negative integer or off")
+			}
+		}
+		debug.SetGCPercent(gogc)
+		return fmt.Sprintf("GOGC changed from %s to %s", formatGOGC(before.GOGC), formatGOGC(gogc)), nil
+	},
+	"memory_limit": func(before runtimeSettings, value string) (string, error) {
+		limit, err := parseBytes(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid memory limit: %s", err)
+		}
+		debug.SetMemoryLimit(limit)
+		return fmt.Sprintf("memory limit changed from %s to %s", formatMemoryLimit(before.MemoryLimit), formatMemoryLimit(limit)), nil
+	},
+	"gomaxprocs": func(before runtimeSettings, value string) (string, error) {
+		procs, err := strconv.Atoi(value)
+		if err != nil || procs < 1 {
+			return "", fmt.Errorf("GOMAXPROCS must be a positive integer")
+		}
+		runtime.GOMAXPROCS(procs)
+		return fmt.Sprintf("GOMAXPROCS changed from %d to %d", before.GOMAXPROCS, procs), nil
+	},
+	"block_profile_rate": func(before runtimeSettings, value string) (string, error) {
+		rate, err := strconv.Atoi(value)
+		if err != nil || rate < 0 {
+			return "", fmt.Errorf("block profile rate must be a non-negative integer")
+		}
+		runtime.SetBlockProfileRate(rate)
+		atomic.StoreInt64(&blockProfileRate, int64(rate))
+		return fmt.Sprintf("block profile rate changed from %d to %d", before.BlockProfileRate, rate), nil
+	},
+	"mutex_profile_fraction": func(before runtimeSettings, value string) (string, error) {
+		fraction, err := strconv.Atoi(value)
+		if err != nil || fraction < 0 {
+			return "", fmt.Errorf("mutex profile fraction must be a non-negative integer")
+		}
+		runtime.SetMutexProfileFraction(fraction)
+		return fmt.Sprintf("mutex profile fraction changed from %d to %d", before.MutexProfileFraction, fraction), nil
+	},
+	"gc": func(before runtimeSettings, value string) (string, error) {
+		runtime.GC()
+		return "forced garbage collection", nil
+	},
+	"free_os_memory": func(before runtimeSettings, value string) (string, error) {
+		debug.FreeOSMemory()
+		return "freed memory to the OS", nil
+	},
+}
+
+func formatGOGC(gogc int) string {
+	if gogc < 0 {
+		return "off"
+	}
+	return strconv.Itoa(gogc)
+}
+
+// byteUnits are the suffixes accepted by parseBytes, largest first so that e.g. MiB is matched before B.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseBytes parses a size, which is either a number of bytes with an optional suffix such as MiB, or off for no limit.
+func parseBytes(s string) (int64, error) {
+	original := s
+	if s == "off" {
+		return math.MaxInt64, nil
+	}
+	size := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, size = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 || n*float64(size) >= math.MaxInt64 {
+		return 0, fmt.Errorf("%q is not a number of bytes, optionally suffixed with KiB, MiB, GiB or TiB, or off", original)
+	}
+	return int64(n * float64(size)), nil
+}
+
+func formatMemoryLimit(limit int64) string {
+	if limit == math.MaxInt64 {
+		return "off"
+	}
+	return formatBytes(uint64(limit))
+}
+
+// formatBytes formats a number of bytes with the largest unit it's at least one of.
+func formatBytes(b uint64) string {
+	for _, unit := range byteUnits {
+		if b >= uint64(unit.size) && unit.size > 1 {
+			return strconv.FormatFloat(float64(b)/float64(unit.size), 'f', 1, 64) + " " + unit.suffix
+		}
+	}
+	return strconv.FormatUint(b, 10) + " B"
+}
+
+// A runtimeChange is the result of a runtime action.
+type runtimeChange struct {
+	Action     string          `json:"action"`
+	Detail     string          `json:"detail"`
+	Before     runtimeSettings `json:"before"`
+	After      runtimeSettings `json:"after"`
+	HeapBefore heapStats       `json:"heap_before"`
+	HeapAfter  heapStats       `json:"heap_after"`
+}
+
+type runtimeView struct {
+	Settings runtimeSettings `json:"settings"`
+	Heap     heapStats       `json:"heap"`
+	Change   *runtimeChange  `json:"-"`
+	Error    string          `json:"-"`
+	CSRF     template.HTML   `json:"-"`
+}
+
+var runtimeTemplate = template.Must(template.New("runtime").Funcs(template.FuncMap{
+	"gogc":        formatGOGC,
+	"memoryLimit": formatMemoryLimit,
+	"bytes":       formatBytes,
+	"args":        func(args ...interface{}) []interface{} { return args },
+}).Parse(`
+{{if .Error}}<div class="alert alert-danger">{{.Error}}</div>{{end}}
+{{with .Change}}
+<div class="card mb-3">
+	<div class="card-header">{{.Detail}}</div>
+	<table class="table table-sm mb-0">
+		<thead><tr><th></th><th>before</th><th>after</th></tr></thead>
+		<tbody>
+			<tr><td>heap allocated</td><td>{{bytes .HeapBefore.HeapAlloc}}</td><td>{{bytes .HeapAfter.HeapAlloc}}</td></tr>
+			<tr><td>heap in use</td><td>{{bytes .HeapBefore.HeapInuse}}</td><td>{{bytes .HeapAfter.HeapInuse}}</td></tr>
+			<tr><td>heap idle</td><td>{{bytes .HeapBefore.HeapIdle}}</td><td>{{bytes .HeapAfter.HeapIdle}}</td></tr>
+			<tr><td>heap released</td><td>{{bytes .HeapBefore.HeapReleased}}</td><td>{{bytes .HeapAfter.HeapReleased}}</td></tr>
+			<tr><td>heap obtained from the OS</td><td>{{bytes .HeapBefore.HeapSys}}</td><td>{{bytes .HeapAfter.HeapSys}}</td></tr>
+			<tr><td>total obtained from the OS</td><td>{{bytes .HeapBefore.Sys}}</td><td>{{bytes .HeapAfter.Sys}}</td></tr>
+			<tr><td>next GC target</td><td>{{bytes .HeapBefore.NextGC}}</td><td>{{bytes .HeapAfter.NextGC}}</td></tr>
+			<tr><td>completed GC cycles</td><td>{{.HeapBefore.NumGC}}</td><td>{{.HeapAfter.NumGC}}</td></tr>
+		</tbody>
+	</table>
+</div>
+{{end}}
+<table class="table">
+	<thead><tr><th>setting</th><th>current</th><th>change</th></tr></thead>
+	<tbody>
+		{{template "setting" (args "gogc" "GOGC" (gogc .Settings.GOGC) "e.g. 100, or off" $.CSRF)}}
+		{{template "setting" (args "memory_limit" "Soft memory limit" (memoryLimit .Settings.MemoryLimit) "e.g. 512MiB, or off" $.CSRF)}}
+		{{template "setting" (args "gomaxprocs" "GOMAXPROCS" (printf "%d (of %d CPUs)" .Settings.GOMAXPROCS .Settings.NumCPU) "e.g. 4" $.CSRF)}}
+		{{template "setting" (args "block_profile_rate" "Block profile rate (ns)" (printf "%d" .Settings.BlockProfileRate) "0 disables" $.CSRF)}}
+		{{template "setting" (args "mutex_profile_fraction" "Mutex profile fraction" (printf "%d" .Settings.MutexProfileFraction) "0 disables" $.CSRF)}}
+	</tbody>
+</table>
+<form action="/admin/runtime" method="POST" class="d-inline">{{.CSRF}}<input type="hidden" name="action" value="gc"/><input type="submit" class="btn btn-primary" value="Force garbage collection"/></form>
+<form action="/admin/runtime" method="POST" class="d-inline">{{.CSRF}}<input type="hidden" name="action" value="free_os_memory"/><input type="submit" class="btn btn-primary" value="Free memory to the OS"/></form>
+{{define "setting"}}
+<tr>
+	<td>{{index . 1}}</td>
+	<td>{{index . 2}}</td>
+	<td>
+		<form action="/admin/runtime" method="POST" class="form-inline">{{index . 4}}
+			<input type="hidden" name="action" value="{{index . 0}}"/>
+			<input type="text" name="value" class="form-control form-control-sm mr-2" placeholder="{{index . 3}}" required/>
+			<input type="submit" class="btn btn-sm btn-primary" value="Set"/>
+		</form>
+	</td>
+</tr>
+{{end}}
+`))
+
+// runtimeHandler renders the runtime settings, with forms to change them.
+func runtimeHandler(w http.ResponseWriter, r *http.Request) {
+	renderRuntime(w, r, nil, "")
+}
+
+// runtimeJSONHandler returns the runtime settings and current heap statistics.
+func runtimeJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(runtimeView{Settings: currentRuntimeSettings(), Heap: currentHeapStats()})
+	w.Write(b)
+}
+
+func renderRuntime(w http.ResponseWriter, r *http.Request, change *runtimeChange, errMsg string) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	if errMsg != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	view := runtimeView{Settings: currentRuntimeSettings(), Change: change, Error: errMsg, CSRF: CSRFField(r)}
+	if err := runtimeTemplate.Execute(w, view); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// updateRuntimeHandler applies the runtime action given by the action form value, with its value if it takes one.
+// It responds with the settings & heap statistics before and after, as JSON unless the client expects HTML.
+func updateRuntimeHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	name := r.Form.Get("action")
+	action, present := runtimeActions[name]
+	if !present {
+		respondRuntimeError(w, r, fmt.Sprintf("unknown action %q", name))
+		return
+	}
+	runtimeMutex.Lock()
+	change := runtimeChange{Action: name, Before: currentRuntimeSettings(), HeapBefore: currentHeapStats()}
+	detail, err := action(change.Before, strings.TrimSpace(r.Form.Get("value")))
+	change.After, change.HeapAfter = currentRuntimeSettings(), currentHeapStats()
+	runtimeMutex.Unlock()
+	if err != nil {
+		respondRuntimeError(w, r, err.Error())
+		return
+	}
+	change.Detail = detail
+	log.Infof("Runtime: %s", detail)
+	AnnotateAudit(r, detail)
+	if expectsHTML(r) {
+		renderRuntime(w, r, &change, "")
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(change)
+	w.Write(b)
+}
+
+func respondRuntimeError(w http.ResponseWriter, r *http.Request, msg string) {
+	if expectsHTML(r) {
+		renderRuntime(w, r, nil, msg)
+		return
+	}
+	http.Error(w, msg, http.StatusBadRequest)
+}
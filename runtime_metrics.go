@@ -0,0 +1,299 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSummaryMetrics are the runtime metrics shown in the summary header unless Opts.SummaryMetrics is set.
+var defaultSummaryMetrics = []string{
+	"/sched/goroutines:goroutines",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/sched/latencies:seconds",
+}
+
+var summaryMetrics struct {
+	sync.RWMutex
+	names []string
+}
+
+// setSummaryMetrics sets the runtime metrics shown in the summary header. They must all be supported by this runtime.
+// If there are none, the defaults are shown.
+func setSummaryMetrics(names []string) error {
+	for _, name := range names {
+		if _, present := runtimeMetricDescriptions()[name]; !present {
+			return fmt.Errorf("unknown runtime metric %s, see /admin/runtime/metrics for those available", name)
+		}
+	}
+	summaryMetrics.Lock()
+	defer summaryMetrics.Unlock()
+	summaryMetrics.names = names
+	return nil
+}
+
+// currentSummaryMetrics returns the runtime metrics shown in the summary header.
+func currentSummaryMetrics() []string {
+	summaryMetrics.RLock()
+	defer summaryMetrics.RUnlock()
+	if len(summaryMetrics.names) == 0 {
+		return defaultSummaryMetrics
+	}
+	return summaryMetrics.names
+}
+
+// runtimeMetricDescriptions returns the descriptions of all metrics supported by this runtime, by name.
+func runtimeMetricDescriptions() map[string]metrics.Description {
+	descs := map[string]metrics.Description{}
+	for _, d := range metrics.All() {
+		descs[d.Name] = d
+	}
+	return descs
+}
+
+// A runtimeMetric is the value of a runtime/metrics metric, formatted according to its unit.
+type runtimeMetric struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Unit        string `json:"unit"`
+	Kind        string `json:"kind"`
+	Cumulative  bool   `json:"cumulative"`
+	// Value is set for scalar metrics, Histogram for distributions.
+	Value     *float64          `json:"value,omitempty"`
+	Histogram *runtimeHistogram `json:"histogram,omitempty"`
+	Formatted string            `json:"formatted"`
+}
+
+// A runtimeHistogram is a distribution from runtime/metrics, with estimated quantiles.
+type runtimeHistogram struct {
+	Count   uint64                   `json:"count"`
+	P50     float64                  `json:"p50"`
+	P90     float64                  `json:"p90"`
+	P99     float64                  `json:"p99"`
+	Max     float64                  `json:"max"`
+	Buckets []runtimeHistogramBucket `json:"buckets"`
+}
+
+// A runtimeHistogramBucket is a non-empty bucket of a runtimeHistogram, covering [Lower, Upper).
+// Lower and Upper are omitted for unbounded buckets.
+type runtimeHistogramBucket struct {
+	Lower *float64 `json:"lower,omitempty"`
+	Upper *float64 `json:"upper,omitempty"`
+	Count uint64   `json:"count"`
+}
+
+var runtimeMetricKinds = map[metrics.ValueKind]string{
+	metrics.KindUint64:           "uint64",
+	metrics.KindFloat64:          "float64",
+	metrics.KindFloat64Histogram: "histogram",
+}
+
+// readRuntimeMetrics reads the named runtime metrics, or all of them if none are named, sorted by name.
+func readRuntimeMetrics(names []string) ([]runtimeMetric, error) {
+	descs := runtimeMetricDescriptions()
+	if len(names) == 0 {
+		for name := range descs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		if _, present := descs[name]; !present {
+			return nil, fmt.Errorf("unknown runtime metric %s", name)
+		}
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	ret := make([]runtimeMetric, 0, len(samples))
+	for _, s := range samples {
+		d := descs[s.Name]
+		m := runtimeMetric{
+			Name:        s.Name,
+			Description: d.Description,
+			Unit:        s.Name[strings.LastIndex(s.Name, ":")+1:],
+			Kind:        runtimeMetricKinds[s.Value.Kind()],
+			Cumulative:  d.Cumulative,
+		}
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			v := float64(s.Value.Uint64())
+			m.Value, m.Formatted = &v, formatRuntimeValue(v, m.Unit)
+		case metrics.KindFloat64:
+			v := s.Value.Float64()
+			m.Value, m.Formatted = &v, formatRuntimeValue(v, m.Unit)
+		case metrics.KindFloat64Histogram:
+			m.Histogram = newRuntimeHistogram(s.Value.Float64Histogram())
+			m.Formatted = formatRuntimeHistogram(m.Histogram, m.Unit)
+		default:
+			// The metric isn't supported by this runtime after all.
+			m.Kind, m.Formatted = "unsupported", "unsupported"
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+func newRuntimeHistogram(h *metrics.Float64Histogram) *runtimeHistogram {
+	rh := &runtimeHistogram{Buckets: []runtimeHistogramBucket{}}
+	for _, c := range h.Counts {
+		rh.Count += c
+	}
+	// bound returns a finite bound for the i'th bucket, preferring its upper bound.
+	bound := func(i int) float64 {
+		if upper := h.Buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		} else if lower := h.Buckets[i]; !math.IsInf(lower, -1) {
+			return lower
+		}
+		return 0
+	}
+	quantile := func(q float64) float64 {
+		rank := uint64(math.Ceil(q * float64(rh.Count)))
+		var seen uint64
+		for i, c := range h.Counts {
+			if seen += c; c > 0 && seen >= rank {
+				return bound(i)
+			}
+		}
+		return 0
+	}
+	if rh.Count > 0 {
+		rh.P50, rh.P90, rh.P99, rh.Max = quantile(0.5), quantile(0.9), quantile(0.99), quantile(1)
+	}
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		b := runtimeHistogramBucket{Count: c}
+		if lower := h.Buckets[i]; !math.IsInf(lower, -1) {
+			b.Lower = &lower
+		}
+		if upper := h.Buckets[i+1]; !math.IsInf(upper, 1) {
+			b.Upper = &upper
+		}
+		rh.Buckets = append(rh.Buckets, b)
+	}
+	return rh
+}
+
+// formatRuntimeValue formats a value in the given runtime/metrics unit.
+func formatRuntimeValue(v float64, unit string) string {
+	switch unit {
+	case "bytes":
+		if v < 0 {
+			return "-" + formatBytes(uint64(-v))
+		}
+		return formatBytes(uint64(v))
+	case "seconds", "cpu-seconds":
+		d := time.Duration(v * float64(time.Second))
+		if unit == "cpu-seconds" {
+			return d.String() + " CPU"
+		}
+		return d.String()
+	case "percent":
+		return strconv.FormatFloat(v, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64) + " " + unit
+}
+
+func formatRuntimeHistogram(h *runtimeHistogram, unit string) string {
+	if h.Count == 0 {
+		return "no observations"
+	}
+	return fmt.Sprintf("p50 %s · p90 %s · p99 %s · max %s", formatRuntimeValue(h.P50, unit), formatRuntimeValue(h.P90, unit),
+		formatRuntimeValue(h.P99, unit), formatRuntimeValue(h.Max, unit))
+}
+
+// runtimeMetricGroup is the metrics under one top-level prefix, e.g. /gc.
+type runtimeMetricGroup struct {
+	Prefix  string
+	Metrics []runtimeMetric
+}
+
+var runtimeMetricsTemplate = template.Must(template.New("runtime_metrics").Funcs(template.FuncMap{
+	"upper": func(b runtimeHistogramBucket, unit string) string {
+		if b.Upper == nil {
+			return "∞"
+		}
+		return formatRuntimeValue(*b.Upper, unit)
+	},
+	"lower": func(b runtimeHistogramBucket, unit string) string {
+		if b.Lower == nil {
+			return "-∞"
+		}
+		return formatRuntimeValue(*b.Lower, unit)
+	},
+}).Parse(`
+<p>All metrics exported by the Go runtime's <a href="https://pkg.go.dev/runtime/metrics">runtime/metrics</a> package.
+Distributions are summarised by quantiles estimated from their buckets; expand them to see the buckets.</p>
+{{range .}}
+<div class="card mb-3">
+	<div class="card-header">{{.Prefix}}</div>
+	<table class="table table-sm mb-0">
+		<tbody>
+		{{range .Metrics}}
+			<tr id="{{.Name}}">
+				<td><code title="{{.Description}}">{{.Name}}</code>{{if .Cumulative}} <span class="badge badge-secondary">cumulative</span>{{end}}
+					<div class="small text-muted">{{.Description}}</div></td>
+				<td class="text-nowrap">{{if .Histogram}}{{$unit := .Unit}}
+					<details>
+						<summary>{{.Formatted}}</summary>
+						<table class="table table-sm small mb-0">
+							<thead><tr><th>from</th><th>to</th><th>count</th></tr></thead>
+							<tbody>{{range .Histogram.Buckets}}<tr><td>{{lower . $unit}}</td><td>{{upper . $unit}}</td><td>{{.Count}}</td></tr>{{end}}</tbody>
+						</table>
+					</details>
+				{{else}}{{.Formatted}}{{end}}</td>
+			</tr>
+		{{end}}
+		</tbody>
+	</table>
+</div>
+{{end}}
+`))
+
+// runtimeMetricsHandler renders all runtime/metrics metrics, grouped by their top-level prefix.
+func runtimeMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	ms, err := readRuntimeMetrics(nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var groups []runtimeMetricGroup
+	for _, m := range ms {
+		prefix := m.Name
+		if i := strings.Index(m.Name[1:], "/"); i >= 0 {
+			prefix = m.Name[:i+1]
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Prefix != prefix {
+			groups = append(groups, runtimeMetricGroup{Prefix: prefix})
+		}
+		groups[len(groups)-1].Metrics = append(groups[len(groups)-1].Metrics, m)
+	}
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := runtimeMetricsTemplate.Execute(w, groups); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// runtimeMetricsJSONHandler returns the runtime/metrics metrics named by m parameters, or all of them.
+func runtimeMetricsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	ms, err := readRuntimeMetrics(r.URL.Query()["m"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(ms)
+	w.Write(b)
+}
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"runtime/metrics"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeMetrics(t *testing.T) {
+	s := &HTTPServer{}
+
+	var ms []runtimeMetric
+	if err := json.Unmarshal(get(t, s, "/admin/runtime/metrics.json", false).Body.Bytes(), &ms); err != nil {
+		t.Fatal(err)
+	} else if len(ms) != len(metrics.All()) {
+		t.Errorf("got %d metrics, expected %d", len(ms), len(metrics.All()))
+	}
+
+	ms = nil
+	w := get(t, s, "/admin/runtime/metrics.json?m=/sched/goroutines:goroutines&m=/sched/latencies:seconds", false)
+	if err := json.Unmarshal(w.Body.Bytes(), &ms); err != nil {
+		t.Fatal(err)
+	} else if len(ms) != 2 {
+		t.Fatalf("unexpected metrics %+v", ms)
+	}
+	if m := ms[0]; m.Kind != "uint64" || m.Unit != "goroutines" || m.Value == nil || *m.Value < 1 ||
+		!strings.HasSuffix(m.Formatted, " goroutines") || m.Description == "" {
+		t.Errorf("unexpected metric %+v", m)
+	}
+	if m := ms[1]; m.Kind != "histogram" || m.Unit != "seconds" || m.Value != nil || m.Histogram == nil || !m.Cumulative {
+		t.Errorf("unexpected metric %+v", m)
+	}
+
+	if w := get(t, s, "/admin/runtime/metrics.json?m=/no/such:metric", false); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown metric: unexpected %d", w.Code)
+	}
+
+	body := get(t, s, "/admin/runtime/metrics", true).Body.String()
+	for _, expected := range []string{`<div class="card-header">/gc</div>`, `id="/sched/goroutines:goroutines"`, "<details>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("page doesn't contain %s", expected)
+		}
+	}
+}
+
+func TestRuntimeHistogram(t *testing.T) {
+	h := newRuntimeHistogram(&metrics.Float64Histogram{
+		Buckets: []float64{math.Inf(-1), 0, 1, 2, math.Inf(1)},
+		Counts:  []uint64{0, 5, 4, 1},
+	})
+	if h.Count != 10 || h.P50 != 1 || h.P90 != 2 || h.P99 != 2 || h.Max != 2 {
+		t.Errorf("unexpected histogram %+v", h)
+	}
+	if len(h.Buckets) != 3 || *h.Buckets[0].Lower != 0 || *h.Buckets[0].Upper != 1 || h.Buckets[2].Upper != nil {
+		t.Errorf("unexpected buckets %+v", h.Buckets)
+	}
+	if s := formatRuntimeHistogram(h, "seconds"); s != "p50 1s · p90 2s · p99 2s · max 2s" {
+		t.Errorf("unexpected formatting %s", s)
+	}
+	if h := newRuntimeHistogram(&metrics.Float64Histogram{Buckets: []float64{0, 1}, Counts: []uint64{0}}); formatRuntimeHistogram(h, "bytes") != "no observations" {
+		t.Errorf("unexpected empty histogram %+v", h)
+	}
+}
+
+func TestFormatRuntimeValue(t *testing.T) {
+	for _, test := range []struct {
+		value    float64
+		unit     string
+		expected string
+	}{
+		{2048, "bytes", "2.0 KiB"},
+		{0.0015, "seconds", "1.5ms"},
+		{3, "cpu-seconds", "3s CPU"},
+		{100, "percent", "100%"},
+		{12, "goroutines", "12 goroutines"},
+		{1.5, "gc-cycles", "1.5 gc-cycles"},
+	} {
+		if s := formatRuntimeValue(test.value, test.unit); s != test.expected {
+			t.Errorf("formatRuntimeValue(%v, %s) = %s; expected %s", test.value, test.unit, s, test.expected)
+		}
+	}
+}
+
+func TestSummaryMetrics(t *testing.T) {
+	t.Cleanup(func() { setSummaryMetrics(nil) })
+	s := &HTTPServer{}
+
+	body := get(t, s, "/admin", true).Body.String()
+	for _, name := range defaultSummaryMetrics {
+		if !strings.Contains(body, `data-key="`+name+`"`) {
+			t.Errorf("summary doesn't show %s", name)
+		}
+	}
+
+	if err := setSummaryMetrics([]string{"/gc/heap/goal:bytes", "process_uptime"}); err == nil {
+		t.Error("unknown metric accepted")
+	}
+	if err := setSummaryMetrics([]string{"/gc/heap/goal:bytes"}); err != nil {
+		t.Fatal(err)
+	}
+	body = get(t, s, "/admin", true).Body.String()
+	if !strings.Contains(body, `data-key="/gc/heap/goal:bytes"`) || strings.Contains(body, `data-key="/sched/goroutines:goroutines"`) ||
+		!strings.Contains(body, `/gc/heap/goal:</a>`) {
+		t.Errorf("unexpected summary %s", body)
+	}
+}
+
+func TestStartResetsSummaryMetrics(t *testing.T) {
+	defer setSummaryMetrics(nil)
+	s := &HTTPServer{}
+	opts := localOpts
+	opts.SummaryMetrics = []string{"/gc/heap/goal:bytes"}
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown(context.Background())
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	if names := currentSummaryMetrics(); len(names) != len(defaultSummaryMetrics) || names[0] != defaultSummaryMetrics[0] {
+		t.Errorf("summary metrics not reset on restart: %v", names)
+	}
+}
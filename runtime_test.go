@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func postRuntime(t *testing.T, s http.Handler, action, value string) runtimeChange {
+	t.Helper()
+	w := post(s, "/admin/runtime", url.Values{"action": {action}, "value": {value}, csrfField: {"x"}}, "x", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s=%s: unexpected %d: %s", action, value, w.Code, w.Body.String())
+	}
+	var change runtimeChange
+	if err := json.Unmarshal(w.Body.Bytes(), &change); err != nil {
+		t.Fatalf("%s=%s: %s", action, value, err)
+	}
+	return change
+}
+
+// postHTML posts a form as a browser would, expecting an HTML response.
+func postHTML(s http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html")
+	req.AddCookie(&http.Cookie{Name: csrfCookie, Value: form.Get(csrfField)})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestRuntimeSettings(t *testing.T) {
+	gogc := debug.SetGCPercent(100)
+	limit := debug.SetMemoryLimit(-1)
+	procs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() {
+		debug.SetGCPercent(gogc)
+		debug.SetMemoryLimit(limit)
+		runtime.GOMAXPROCS(procs)
+	})
+	s := &HTTPServer{}
+
+	var view runtimeView
+	if err := json.Unmarshal(get(t, s, "/admin/runtime.json", false).Body.Bytes(), &view); err != nil {
+		t.Fatal(err)
+	} else if view.Settings.GOGC != 100 || view.Settings.GOMAXPROCS != procs || view.Heap.HeapSys == 0 {
+		t.Errorf("unexpected settings %+v", view)
+	}
+
+	change := postRuntime(t, s, "gogc", "200")
+	if change.Before.GOGC != 100 || change.After.GOGC != 200 || change.Detail != "GOGC changed from 100 to 200" {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if change := postRuntime(t, s, "gogc", "off"); change.After.GOGC != -1 {
+		t.Errorf("GOGC not turned off: %+v", change.After)
+	}
+	change = postRuntime(t, s, "memory_limit", "512MiB")
+	if change.After.MemoryLimit != 512<<20 || change.Detail != "memory limit changed from off to 512.0 MiB" {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if change := postRuntime(t, s, "memory_limit", "off"); change.After.MemoryLimit != math.MaxInt64 {
+		t.Errorf("memory limit not removed: %+v", change.After)
+	}
+	if change := postRuntime(t, s, "gomaxprocs", "1"); change.Before.GOMAXPROCS != procs || change.After.GOMAXPROCS != 1 {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if change := postRuntime(t, s, "gc", ""); change.HeapAfter.NumGC <= change.HeapBefore.NumGC {
+		t.Errorf("GC not run: %+v", change)
+	}
+
+	for _, form := range []url.Values{
+		{"action": {"gogc"}, "value": {"-5"}},
+		{"action": {"memory_limit"}, "value": {"lots"}},
+		{"action": {"gomaxprocs"}, "value": {"0"}},
+		{"action": {"block_profile_rate"}, "value": {""}},
+		{"action": {"reboot"}},
+	} {
+		form.Set(csrfField, "x")
+		if w := post(s, "/admin/runtime", form, "x", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: unexpected %d", form, w.Code)
+		}
+	}
+}
+
+func TestRuntimePage(t *testing.T) {
+	gogc := debug.SetGCPercent(100)
+	t.Cleanup(func() { debug.SetGCPercent(gogc) })
+	s := &HTTPServer{}
+
+	body := get(t, s, "/admin/runtime", true).Body.String()
+	for _, action := range []string{"gogc", "memory_limit", "gomaxprocs", "block_profile_rate", "mutex_profile_fraction", "gc", "free_os_memory"} {
+		if !strings.Contains(body, `name="action" value="`+action+`"`) {
+			t.Errorf("no form for %s", action)
+		}
+	}
+
+	req := url.Values{"action": {"gogc"}, "value": {"150"}, csrfField: {"x"}}
+	w := postHTML(s, "/admin/runtime", req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "GOGC changed from 100 to 150") ||
+		!strings.Contains(w.Body.String(), "heap allocated") {
+		t.Errorf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	req.Set("value", "many")
+	if w := postHTML(s, "/admin/runtime", req); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "alert-danger") {
+		t.Errorf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRuntimeAudit(t *testing.T) {
+	gogc := debug.SetGCPercent(100)
+	t.Cleanup(func() { debug.SetGCPercent(gogc) })
+	var sunk []AuditEvent
+	s := &HTTPServer{}
+	s.init()
+	s.audits = newAuditLog(2, []AuditSink{AuditSinkFunc(func(e AuditEvent) { sunk = append(sunk, e) })})
+
+	postRuntime(t, s, "gogc", "120")
+	if len(sunk) != 1 || sunk[0].Detail != "GOGC changed from 100 to 120" {
+		t.Errorf("unexpected audit events %+v", sunk)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"1024":     1024,
+		"100B":     100,
+		"2KiB":     2048,
+		"1.5 MiB":  3 << 19,
+		"4GiB":     4 << 30,
+		"1TiB":     1 << 40,
+		"off":      math.MaxInt64,
+		"0":        0,
+		"0.5KiB":   512,
+		"1024 KiB": 1 << 20,
+	} {
+		if n, err := parseBytes(s); err != nil || n != expected {
+			t.Errorf("parseBytes(%q) = %d, %v; expected %d", s, n, err, expected)
+		}
+	}
+	for _, s := range []string{"", "MiB", "-1", "1PiB", "lots", "9999999TiB"} {
+		if _, err := parseBytes(s); err == nil {
+			t.Errorf("parseBytes(%q) succeeded", s)
+		}
+	}
+	for b, expected := range map[uint64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1536:    "1.5 KiB",
+		5 << 30: "5.0 GiB",
+	} {
+		if s := formatBytes(b); s != expected {
+			t.Errorf("formatBytes(%d) = %q; expected %q", b, s, expected)
+		}
+	}
+}
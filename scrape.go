@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_model/go"
+)
+
+// scrapeErrorHandling maps the values of Opts.MetricsErrorHandling to how promhttp handles errors.
+var scrapeErrorHandling = map[string]promhttp.HandlerErrorHandling{
+	"":           promhttp.HTTPErrorOnError,
+	"http_error": promhttp.HTTPErrorOnError,
+	"continue":   promhttp.ContinueOnError,
+}
+
+// scrapeOptions are the options for /metrics set via Opts.
+type scrapeOptions struct {
+	errorHandling      promhttp.HandlerErrorHandling
+	disableCompression bool
+	disableOpenMetrics bool
+	// inFlight limits the number of concurrent scrapes, or is nil if they're unlimited.
+	inFlight chan struct{}
+}
+
+var scrapeConfig struct {
+	sync.RWMutex
+	opts scrapeOptions
+}
+
+// setScrapeOpts sets the options for /metrics from the given Opts. Options that aren't set are reset to their defaults,
+// so a restarted server doesn't keep those of its previous Start.
+func setScrapeOpts(opts Opts) error {
+	errorHandling, present := scrapeErrorHandling[opts.MetricsErrorHandling]
+	if !present {
+		return fmt.Errorf("unknown metrics error handling %s, must be http_error or continue", opts.MetricsErrorHandling)
+	}
+	so := scrapeOptions{
+		errorHandling:      errorHandling,
+		disableCompression: opts.MetricsDisableCompression,
+		disableOpenMetrics: opts.MetricsDisableOpenMetrics,
+	}
+	if opts.MetricsMaxConcurrentScrapes > 0 {
+		so.inFlight = make(chan struct{}, opts.MetricsMaxConcurrentScrapes)
+	}
+	scrapeConfig.Lock()
+	defer scrapeConfig.Unlock()
+	scrapeConfig.opts = so
+	return nil
+}
+
+var (
+	scrapesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "admin_metrics_scrapes_in_flight",
+		Help: "Number of scrapes of /metrics currently being served.",
+	})
+	scrapesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "admin_metrics_scrapes_total",
+		Help: "Number of scrapes of /metrics, by HTTP status code.",
+	}, []string{"code"})
+	scrapeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "admin_metrics_scrape_duration_seconds",
+		Help: "Time taken to serve scrapes of /metrics.",
+	}, nil)
+	scrapeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "admin_metrics_scrape_errors_total",
+		Help: "Number of errors gathering metrics for /metrics, by cause: gather for a gatherer failing, conflict for gatherers conflicting.",
+	}, []string{"cause"})
+)
+
+func init() {
+	adminRegistry.MustRegister(scrapesInFlight, scrapesTotal, scrapeDuration, scrapeErrors)
+	scrapeErrors.WithLabelValues("gather")
+	scrapeErrors.WithLabelValues("conflict")
+}
+
+// scrapeHandler serves /metrics, instrumented with the admin server's own metrics.
+var scrapeHandler = promhttp.InstrumentHandlerInFlight(scrapesInFlight,
+	promhttp.InstrumentHandlerCounter(scrapesTotal,
+		promhttp.InstrumentHandlerDuration(scrapeDuration, http.HandlerFunc(metricsHandler))))
+
+// scrapeLogger logs errors serving /metrics.
+type scrapeLogger struct{}
+
+func (scrapeLogger) Println(v ...interface{}) {
+	log.Errorf("Failed to serve /metrics: %s", fmt.Sprint(v...))
+}
+
+// metricsHandler serves metrics in the Prometheus or OpenMetrics exposition format, from the gatherers named by g
+// parameters or all of them. They can be restricted to the families named by name[] parameters and the series
+// matched by match[] selectors, e.g. for federation. Conflicts between gatherers are logged by gather rather than
+// failing the scrape.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	names, err := gathererNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := scrapeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	scrapeConfig.RLock()
+	opts := scrapeConfig.opts
+	scrapeConfig.RUnlock()
+	if opts.inFlight != nil {
+		select {
+		case opts.inFlight <- struct{}{}:
+			defer func() { <-opts.inFlight }()
+		default:
+			http.Error(w, fmt.Sprintf("Limit of concurrent scrapes reached (%d), try again later.", cap(opts.inFlight)), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	promhttp.HandlerFor(
+		prometheus.GathererFunc(func() ([]*io_prometheus_client.MetricFamily, error) {
+			mfs, err := gather(names...)
+			if errs, ok := err.(prometheus.MultiError); ok {
+				for _, err := range errs {
+					countScrapeError(err)
+				}
+			} else if err != nil {
+				countScrapeError(err)
+			}
+			if filter != nil {
+				mfs = filter(mfs)
+			}
+			return mfs, withoutConflicts(err)
+		}),
+		promhttp.HandlerOpts{
+			ErrorLog:           scrapeLogger{},
+			ErrorHandling:      opts.errorHandling,
+			DisableCompression: opts.disableCompression,
+			EnableOpenMetrics:  !opts.disableOpenMetrics,
+		},
+	).ServeHTTP(w, r)
+}
+
+func countScrapeError(err error) {
+	if _, ok := err.(gatherConflict); ok {
+		scrapeErrors.WithLabelValues("conflict").Inc()
+	} else {
+		scrapeErrors.WithLabelValues("gather").Inc()
+	}
+}
+
+// scrapeFilter returns a function restricting gathered metrics to the families named by the request's name[]
+// parameters and the series matched by its match[] selectors, or nil if it has neither.
+func scrapeFilter(r *http.Request) (func([]*io_prometheus_client.MetricFamily) []*io_prometheus_client.MetricFamily, error) {
+	names := r.URL.Query()["name[]"]
+	sels, err := parseSelectors(r.URL.Query()["match[]"])
+	if err != nil {
+		return nil, err
+	} else if len(names) == 0 && len(sels) == 0 {
+		return nil, nil
+	}
+	include := map[string]bool{}
+	for _, name := range names {
+		include[name] = true
+	}
+	return func(mfs []*io_prometheus_client.MetricFamily) []*io_prometheus_client.MetricFamily {
+		ret := []*io_prometheus_client.MetricFamily{}
+		for _, mf := range mfs {
+			if include[mf.GetName()] {
+				ret = append(ret, mf)
+				continue
+			}
+			filtered := &io_prometheus_client.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+			for _, m := range mf.Metric {
+				if anySelectorMatches(sels, mf.GetName(), labelMap(m)) {
+					filtered.Metric = append(filtered.Metric, m)
+				}
+			}
+			if len(filtered.Metric) > 0 {
+				ret = append(ret, filtered)
+			}
+		}
+		return ret
+	}, nil
+}
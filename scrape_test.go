@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_model/go"
+)
+
+// useScrapeOpts sets the options for /metrics for the duration of a test.
+func useScrapeOpts(t *testing.T, opts Opts) {
+	if err := setScrapeOpts(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setScrapeOpts(Opts{}) })
+}
+
+func scrape(t *testing.T, path string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	authedServer(nil).ServeHTTP(w, req)
+	return w
+}
+
+func TestScrapeOpenMetricsExemplars(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{1}})
+	reg.MustRegister(latency)
+	latency.(prometheus.ExemplarObserver).ObserveWithExemplar(0.5, prometheus.Labels{"trace_id": "abc123"})
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: reg})
+
+	w := scrape(t, "/metrics", map[string]string{"Accept": "application/openmetrics-text; version=0.0.1"})
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `latency_seconds_bucket{le="1.0"} 1 # {trace_id="abc123"} 0.5`) || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("exemplar missing from %s", body)
+	}
+	// Clients that don't ask for OpenMetrics get the text format, without exemplars.
+	if w := scrape(t, "/metrics", nil); strings.Contains(w.Body.String(), "trace_id") {
+		t.Errorf("unexpected exemplar in %s", w.Body.String())
+	}
+
+	useScrapeOpts(t, Opts{MetricsDisableOpenMetrics: true})
+	w = scrape(t, "/metrics", map[string]string{"Accept": "application/openmetrics-text; version=0.0.1"})
+	if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("OpenMetrics negotiated despite being disabled")
+	}
+}
+
+func TestScrapeCompression(t *testing.T) {
+	w := scrape(t, "/metrics", map[string]string{"Accept-Encoding": "gzip"})
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response not compressed")
+	}
+	r, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(r); !strings.Contains(string(b), "admin_metrics_scrapes_total") {
+		t.Errorf("unexpected body %s", b)
+	}
+
+	useScrapeOpts(t, Opts{MetricsDisableCompression: true})
+	if w := scrape(t, "/metrics", map[string]string{"Accept-Encoding": "gzip"}); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("response compressed despite compression being disabled")
+	}
+}
+
+func TestScrapeConcurrencyLimit(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	useGatherers(t, NamedGatherer{Name: "slow", Gatherer: prometheus.GathererFunc(func() ([]*io_prometheus_client.MetricFamily, error) {
+		entered <- struct{}{}
+		<-release
+		return nil, nil
+	})})
+	useScrapeOpts(t, Opts{MetricsMaxConcurrentScrapes: 1})
+
+	done := make(chan int)
+	go func() { done <- scrape(t, "/metrics", nil).Code }()
+	<-entered
+	if w := scrape(t, "/metrics?g=admin", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 beyond the limit, got %d", w.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("unexpected status %d", code)
+	}
+	if w := scrape(t, "/metrics?g=admin", nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the first scrape finished, got %d", w.Code)
+	}
+}
+
+func TestScrapeErrorHandling(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "working"}))
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: reg}, NamedGatherer{Name: "broken", Gatherer: prometheus.GathererFunc(func() ([]*io_prometheus_client.MetricFamily, error) {
+		return nil, fmt.Errorf("broken")
+	})})
+	errors := func() float64 {
+		mfs, _ := adminRegistry.Gather()
+		for _, mf := range mfs {
+			if mf.GetName() == "admin_metrics_scrape_errors_total" {
+				for _, m := range mf.Metric {
+					if m.Label[0].GetValue() == "gather" {
+						return m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	before := errors()
+	if w := scrape(t, "/metrics", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+	if after := errors(); after != before+1 {
+		t.Errorf("error not counted, %f => %f", before, after)
+	}
+	useScrapeOpts(t, Opts{MetricsErrorHandling: "continue"})
+	if w := scrape(t, "/metrics", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "working 0") {
+		t.Errorf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if err := setScrapeOpts(Opts{MetricsErrorHandling: "panic"}); err == nil {
+		t.Error("expected an error for an unknown error handling mode")
+	}
+}
+
+func TestScrapeFilter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	reg.MustRegister(requests, prometheus.NewGauge(prometheus.GaugeOpts{Name: "pool_size"}), prometheus.NewGauge(prometheus.GaugeOpts{Name: "other"}))
+	requests.WithLabelValues("200")
+	requests.WithLabelValues("500")
+	useGatherers(t, NamedGatherer{Name: "app", Gatherer: reg})
+
+	body := scrape(t, `/metrics?name[]=pool_size&match[]=requests_total{code="500"}`, nil).Body.String()
+	for s, expected := range map[string]bool{
+		"pool_size 0":                  true,
+		`requests_total{code="500"} 0`: true,
+		`requests_total{code="200"} 0`: false,
+		"other 0":                      false,
+		"admin_metrics_scrapes_total":  false,
+	} {
+		if strings.Contains(body, s) != expected {
+			t.Errorf("expected %s to be present: %t, in %s", s, expected, body)
+		}
+	}
+	if w := scrape(t, "/metrics?match[]=requests_total{", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid selector, got %d", w.Code)
+	}
+}
+
+func TestScrapeInstrumentation(t *testing.T) {
+	scrape(t, "/metrics", nil)
+	body := scrape(t, "/metrics?name[]=admin_metrics_scrapes_total&name[]=admin_metrics_scrapes_in_flight&name[]=admin_metrics_scrape_duration_seconds", nil).Body.String()
+	for _, s := range []string{`admin_metrics_scrapes_total{code="200"}`, "admin_metrics_scrapes_in_flight 1", "admin_metrics_scrape_duration_seconds_count"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("%s missing from %s", s, body)
+		}
+	}
+}
+
+func TestStartResetsScrapeOpts(t *testing.T) {
+	defer setScrapeOpts(Opts{})
+	s := &HTTPServer{}
+	opts := localOpts
+	opts.MetricsMaxConcurrentScrapes = 1
+	opts.MetricsDisableCompression = true
+	if err := s.Start(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	s.Shutdown(context.Background())
+	if err := s.Start(context.Background(), localOpts); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+	scrapeConfig.RLock()
+	so := scrapeConfig.opts
+	scrapeConfig.RUnlock()
+	if so.inFlight != nil || so.disableCompression {
+		t.Errorf("scrape options not reset on restart: %+v", so)
+	}
+}
@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/scanner"
+
+	"github.com/prometheus/client_model/go"
+)
+
+// nameLabel is the pseudo-label matching the name of a metric family, as in Prometheus.
+const nameLabel = "__name__"
+
+// A labelMatcher matches the value of a single label. Regexes are fully anchored, and a label that
+// isn't present has the empty string as its value, both as in Prometheus.
+type labelMatcher struct {
+	name, op, value string
+	re              *regexp.Regexp
+}
+
+func (m labelMatcher) matches(value string) bool {
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	default: // "!~"
+		return !m.re.MatchString(value)
+	}
+}
+
+// A metricSelector selects series, in the same syntax as a Prometheus instant vector selector, e.g.
+// http_requests_total{code=~"5..",method!="GET"} or {__name__=~"go_.*"}.
+type metricSelector []labelMatcher
+
+// parseSelector parses a selector. A bare family name is a valid selector, matching all its series.
+func parseSelector(s string) (metricSelector, error) {
+	sel, rest, err := parseSelectorPrefix(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid selector %q: unexpected %s", s, rest)
+	}
+	return sel, nil
+}
+
+// parseSelectorPrefix parses the selector at the start of s, returning it and the rest of s, with leading space
+// trimmed.
+func parseSelectorPrefix(s string) (metricSelector, string, error) {
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(s))
+	sc.Mode = scanner.ScanIdents | scanner.ScanStrings
+	sc.IsIdentRune = func(ch rune, i int) bool {
+		return ch == '_' || ch == ':' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (i > 0 && ch >= '0' && ch <= '9')
+	}
+	var errs []string
+	sc.Error = func(s *scanner.Scanner, msg string) { errs = append(errs, msg) }
+	fail := func(format string, args ...interface{}) (metricSelector, string, error) {
+		if len(errs) > 0 {
+			return nil, "", fmt.Errorf("invalid selector %q: %s", s, errs[0])
+		}
+		return nil, "", fmt.Errorf("invalid selector %q: "+format, append([]interface{}{s}, args...)...)
+	}
+
+	sel := metricSelector{}
+	tok := sc.Scan()
+	if tok == scanner.Ident {
+		sel = append(sel, labelMatcher{name: nameLabel, op: "=", value: sc.TokenText()})
+		tok = sc.Scan()
+	}
+	if tok == '{' {
+		for tok = sc.Scan(); tok != '}'; tok = sc.Scan() {
+			if tok != scanner.Ident {
+				return fail("expected a label name at column %d", sc.Position.Column)
+			}
+			m := labelMatcher{name: sc.TokenText()}
+			switch op := sc.Scan(); op {
+			case '=':
+				m.op = "="
+				if sc.Peek() == '~' {
+					sc.Next()
+					m.op = "=~"
+				}
+			case '!':
+				if next := sc.Next(); next == '=' || next == '~' {
+					m.op = "!" + string(next)
+				} else {
+					return fail("expected != or !~ at column %d", sc.Position.Column)
+				}
+			default:
+				return fail("expected one of =, !=, =~ or !~ at column %d", sc.Position.Column)
+			}
+			if sc.Scan() != scanner.String {
+				return fail("expected a quoted label value at column %d", sc.Position.Column)
+			}
+			value, err := strconv.Unquote(sc.TokenText())
+			if err != nil {
+				return fail("invalid label value %s", sc.TokenText())
+			}
+			m.value = value
+			if m.op == "=~" || m.op == "!~" {
+				if m.re, err = regexp.Compile("^(?:" + value + ")$"); err != nil {
+					return fail("%s", err)
+				}
+			}
+			sel = append(sel, m)
+			if tok = sc.Scan(); tok == '}' {
+				break
+			}
+			if tok != ',' {
+				return fail("expected , or } at column %d", sc.Position.Column)
+			}
+		}
+		tok = sc.Scan()
+	}
+	if len(sel) == 0 {
+		return fail("no matchers")
+	}
+	if tok == scanner.EOF {
+		return sel, "", nil
+	}
+	return sel, s[sc.Position.Offset:], nil
+}
+
+// matchesFamily returns true if the selector's name matchers match the given family name.
+func (sel metricSelector) matchesFamily(family string) bool {
+	for _, m := range sel {
+		if m.name == nameLabel && !m.matches(family) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches returns true if the selector matches a series of the given family with the given labels.
+func (sel metricSelector) matches(family string, labels map[string]string) bool {
+	for _, m := range sel {
+		if m.name != nameLabel && !m.matches(labels[m.name]) {
+			return false
+		}
+	}
+	return sel.matchesFamily(family)
+}
+
+// parseSelectors parses each of the given selectors.
+func parseSelectors(ss []string) ([]metricSelector, error) {
+	sels := make([]metricSelector, len(ss))
+	for i, s := range ss {
+		sel, err := parseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		sels[i] = sel
+	}
+	return sels, nil
+}
+
+// anySelectorMatches returns true if any of the given selectors match the series.
+func anySelectorMatches(sels []metricSelector, family string, labels map[string]string) bool {
+	for _, sel := range sels {
+		if sel.matches(family, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// labelMap returns the labels of a metric as a map.
+func labelMap(m *io_prometheus_client.Metric) map[string]string {
+	labels := make(map[string]string, len(m.Label))
+	for _, l := range m.Label {
+		labels[l.GetName()] = l.GetValue()
+	}
+	return labels
+}
+
+// formatLabels formats labels as {a=b,c=d}, sorted by name, or returns the empty string if there are none.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = name + "=" + labels[name]
+	}
+	return "{" + strings.Join(names, ",") + "}"
+}
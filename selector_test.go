@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	labels := map[string]string{"code": "503", "method": "GET"}
+	for selector, expected := range map[string]bool{
+		"requests_total":                            true,
+		"requests_total{}":                          true,
+		"other_total":                               false,
+		`requests_total{code="503"}`:                true,
+		`requests_total{code="200"}`:                false,
+		`requests_total{code!="200",}`:              true,
+		`requests_total{code=~"5.."}`:               true,
+		`requests_total{code=~"5"}`:                 false, // Regexes are anchored.
+		`requests_total{code!~"5..", method="GET"}`: false,
+		`requests_total{path=""}`:                   true, // Missing labels are empty.
+		`{__name__=~"requests_.*"}`:                 true,
+		`{__name__!~"requests_.*",code="503"}`:      false,
+		`{method="GET"}`:                            true,
+		`requests_total{method="G\x45T"}`:           true,
+	} {
+		sel, err := parseSelector(selector)
+		if err != nil {
+			t.Errorf("%s: %s", selector, err)
+		} else if actual := sel.matches("requests_total", labels); actual != expected {
+			t.Errorf("%s: expected %t, got %t", selector, expected, actual)
+		}
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, selector := range []string{
+		"",
+		"{}",
+		"requests_total{",
+		"requests_total{code}",
+		"requests_total{code=200}",
+		`requests_total{code=="200"}`,
+		`requests_total{code!"200"}`,
+		`requests_total{code="200"`,
+		`requests_total{code="200" method="GET"}`,
+		`requests_total{code=~"("}`,
+		`requests_total{code="200}`,
+		"requests_total other",
+		"0requests",
+	} {
+		if _, err := parseSelector(selector); err == nil {
+			t.Errorf("%s: expected an error", selector)
+		}
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if s := formatLabels(map[string]string{"b": "2", "a": "1"}); s != "{a=1,b=2}" {
+		t.Errorf("unexpected %s", s)
+	}
+	if s := formatLabels(nil); s != "" {
+		t.Errorf("unexpected %s", s)
+	}
+}
@@ -0,0 +1,390 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"golang.org/x/exp/trace"
+)
+
+// traceProfileKinds are the blocking profiles derived from a trace, as produced by go tool trace.
+var traceProfileKinds = []string{"net", "sync", "syscall", "sched"}
+
+// traceProfileDescriptions describe the traceProfileKinds.
+var traceProfileDescriptions = map[string]string{
+	"net":     "Time goroutines spent blocked on the network",
+	"sync":    "Time goroutines spent blocked on channels, selects & sync primitives",
+	"syscall": "Time goroutines spent in system calls",
+	"sched":   "Time goroutines spent runnable, waiting to be scheduled",
+}
+
+// maxTraceGoroutineGroups & maxTraceProfileStacks limit how much of a trace analysis is shown.
+const (
+	maxTraceGoroutineGroups = 50
+	maxTraceProfileStacks   = 20
+)
+
+// A traceGoroutineGroup is the goroutines in a trace that started in the same function, and where they spent their time.
+type traceGoroutineGroup struct {
+	Function  string        `json:"function"`
+	Count     int           `json:"count"`
+	Execution time.Duration `json:"execution"`
+	Scheduled time.Duration `json:"sched_wait"`
+	Blocked   time.Duration `json:"blocked"`
+	Syscall   time.Duration `json:"syscall"`
+}
+
+// Total returns the time the group's goroutines were alive in the trace.
+func (g *traceGoroutineGroup) Total() time.Duration {
+	return g.Execution + g.Scheduled + g.Blocked + g.Syscall
+}
+
+// A traceSpan is a range of time in a trace, relative to its start.
+type traceSpan struct {
+	Name     string        `json:"name"`
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// A tracePoint is a metric's value at a time in a trace, relative to its start.
+type tracePoint struct {
+	Time  time.Duration `json:"time"`
+	Value uint64        `json:"value"`
+}
+
+// A traceHistogramBucket counts the latencies in a traceHistogram up to its upper bound.
+type traceHistogramBucket struct {
+	Upper time.Duration `json:"upper"`
+	Count int           `json:"count"`
+}
+
+// A traceHistogram is a histogram of latencies in exponential buckets.
+type traceHistogram struct {
+	Count   int                    `json:"count"`
+	P50     time.Duration          `json:"p50"`
+	P90     time.Duration          `json:"p90"`
+	P99     time.Duration          `json:"p99"`
+	Max     time.Duration          `json:"max"`
+	Buckets []traceHistogramBucket `json:"buckets"`
+}
+
+// A traceStack is a stack in a profile derived from a trace, with the time spent in it.
+type traceStack struct {
+	Frames   []traceFrame  `json:"frames"`
+	Count    int           `json:"count"`
+	Duration time.Duration `json:"duration"`
+}
+
+// A traceFrame is a frame of a traceStack.
+type traceFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     uint64 `json:"line"`
+}
+
+// A traceAnalysis summarises an execution trace.
+type traceAnalysis struct {
+	Duration       time.Duration            `json:"duration"`
+	Events         int                      `json:"events"`
+	Goroutines     int                      `json:"goroutines"`
+	GoroutineTypes []*traceGoroutineGroup   `json:"goroutine_groups"`
+	GC             []traceSpan              `json:"gc"`
+	STW            []traceSpan              `json:"stw"`
+	STWTotal       time.Duration            `json:"stw_total"`
+	Heap           []tracePoint             `json:"heap"`
+	HeapGoal       []tracePoint             `json:"heap_goal"`
+	SchedLatency   traceHistogram           `json:"sched_latency"`
+	Profiles       map[string][]*traceStack `json:"profiles"`
+	ProfileTotals  map[string]time.Duration `json:"profile_totals"`
+	stacks         map[string]map[string]*traceStack
+}
+
+// traceGoroutine tracks the state of a goroutine while a trace is analysed.
+type traceGoroutine struct {
+	group  *traceGoroutineGroup
+	state  trace.GoState
+	since  trace.Time
+	reason string
+	stack  trace.Stack
+}
+
+// traceProfileKind returns the profile that time spent in a goroutine state is attributed to, if any.
+// Blocking reasons are classified as go tool trace does.
+func traceProfileKind(state trace.GoState, reason string) string {
+	switch state {
+	case trace.GoRunnable:
+		return "sched"
+	case trace.GoSyscall:
+		return "syscall"
+	case trace.GoWaiting:
+		if reason == "network" {
+			return "net"
+		} else if strings.Contains(reason, "chan") || strings.Contains(reason, "sync") || strings.Contains(reason, "select") {
+			return "sync"
+		}
+	}
+	return ""
+}
+
+// analyseTrace reads an execution trace & summarises it.
+func analyseTrace(r io.Reader) (*traceAnalysis, error) {
+	reader, err := trace.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	a := &traceAnalysis{
+		Profiles:      map[string][]*traceStack{},
+		ProfileTotals: map[string]time.Duration{},
+		stacks:        map[string]map[string]*traceStack{},
+	}
+	frames := map[trace.Stack][]traceFrame{}
+	stackFrames := func(s trace.Stack) []traceFrame {
+		if f, present := frames[s]; present {
+			return f
+		}
+		var f []traceFrame
+		for frame := range s.Frames() {
+			f = append(f, traceFrame{Function: frame.Func, File: frame.File, Line: frame.Line})
+		}
+		frames[s] = f
+		return f
+	}
+	groups := map[string]*traceGoroutineGroup{}
+	goroutines := map[trace.GoID]*traceGoroutine{}
+	ranges := map[string]trace.Time{}
+	var latencies []time.Duration
+	var start, end trace.Time
+
+	// account attributes the time a goroutine spent in its current state up to now.
+	account := func(g *traceGoroutine, now trace.Time, next trace.GoState) {
+		if g.since == 0 {
+			return
+		}
+		d := now.Sub(g.since)
+		switch g.state {
+		case trace.GoRunning:
+			g.group.Execution += d
+		case trace.GoRunnable:
+			g.group.Scheduled += d
+			if next == trace.GoRunning {
+				latencies = append(latencies, d)
+			}
+		case trace.GoWaiting:
+			g.group.Blocked += d
+		case trace.GoSyscall:
+			g.group.Syscall += d
+		}
+		if kind := traceProfileKind(g.state, g.reason); kind != "" {
+			a.addStack(kind, stackFrames(g.stack), d)
+		}
+	}
+
+	for {
+		ev, err := reader.ReadEvent()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		a.Events++
+		if start == 0 {
+			start = ev.Time()
+		}
+		end = ev.Time()
+		switch ev.Kind() {
+		case trace.EventStateTransition:
+			st := ev.StateTransition()
+			if st.Resource.Kind != trace.ResourceGoroutine {
+				continue
+			}
+			from, to := st.Goroutine()
+			id := st.Resource.Goroutine()
+			g := goroutines[id]
+			if g == nil {
+				g = &traceGoroutine{}
+				goroutines[id] = g
+			}
+			// Goroutines are grouped by the outermost frame of the first stack seen for them, which is the function
+			// they started in.
+			if g.group == nil {
+				if f := stackFrames(st.Stack); len(f) > 0 {
+					name := f[len(f)-1].Function
+					if g.group = groups[name]; g.group == nil {
+						g.group = &traceGoroutineGroup{Function: name}
+						groups[name] = g.group
+					}
+					g.group.Count++
+				} else {
+					// Nothing can be attributed to the goroutine until it's identified.
+					g.state, g.since = to, 0
+					continue
+				}
+			}
+			if from != trace.GoUndetermined && from != trace.GoNotExist {
+				account(g, ev.Time(), to)
+			}
+			g.state, g.since, g.reason = to, ev.Time(), st.Reason
+			// Goroutines that are unblocked keep the stack they blocked in, which is where they're scheduled from.
+			if st.Stack != trace.NoStack {
+				g.stack = st.Stack
+			}
+			if to == trace.GoNotExist {
+				g.since = 0
+			}
+		case trace.EventRangeBegin, trace.EventRangeActive:
+			r := ev.Range()
+			t := ev.Time()
+			if ev.Kind() == trace.EventRangeActive {
+				t = start
+			}
+			ranges[r.Name+r.Scope.String()] = t
+		case trace.EventRangeEnd:
+			r := ev.Range()
+			began, present := ranges[r.Name+r.Scope.String()]
+			if !present {
+				continue
+			}
+			delete(ranges, r.Name+r.Scope.String())
+			span := traceSpan{Name: r.Name, Start: began.Sub(start), Duration: ev.Time().Sub(began)}
+			if r.Name == "GC concurrent mark phase" {
+				a.GC = append(a.GC, span)
+			} else if strings.HasPrefix(r.Name, "stop-the-world") {
+				span.Name = strings.TrimSuffix(strings.TrimPrefix(r.Name, "stop-the-world ("), ")")
+				a.STW = append(a.STW, span)
+				a.STWTotal += span.Duration
+			}
+		case trace.EventMetric:
+			m := ev.Metric()
+			p := tracePoint{Time: ev.Time().Sub(start), Value: m.Value.Uint64()}
+			switch m.Name {
+			case "/memory/classes/heap/objects:bytes":
+				a.Heap = append(a.Heap, p)
+			case "/gc/heap/goal:bytes":
+				a.HeapGoal = append(a.HeapGoal, p)
+			}
+		}
+	}
+	if a.Events == 0 {
+		return nil, fmt.Errorf("trace contains no events")
+	}
+	a.Duration = end.Sub(start)
+	for _, g := range goroutines {
+		if g.group != nil {
+			account(g, end, trace.GoUndetermined)
+		}
+	}
+	a.Goroutines = len(goroutines)
+	for _, g := range groups {
+		a.GoroutineTypes = append(a.GoroutineTypes, g)
+	}
+	sort.Slice(a.GoroutineTypes, func(i, j int) bool {
+		if a.GoroutineTypes[i].Execution != a.GoroutineTypes[j].Execution {
+			return a.GoroutineTypes[i].Execution > a.GoroutineTypes[j].Execution
+		}
+		return a.GoroutineTypes[i].Function < a.GoroutineTypes[j].Function
+	})
+	a.SchedLatency = newTraceHistogram(latencies)
+	for kind, stacks := range a.stacks {
+		for _, s := range stacks {
+			a.Profiles[kind] = append(a.Profiles[kind], s)
+			a.ProfileTotals[kind] += s.Duration
+		}
+		sort.Slice(a.Profiles[kind], func(i, j int) bool { return a.Profiles[kind][i].Duration > a.Profiles[kind][j].Duration })
+	}
+	return a, nil
+}
+
+// addStack attributes time spent in the given stack to a profile.
+func (a *traceAnalysis) addStack(kind string, frames []traceFrame, d time.Duration) {
+	if len(frames) == 0 || d <= 0 {
+		return
+	}
+	var key strings.Builder
+	for _, f := range frames {
+		fmt.Fprintf(&key, "%s:%d;", f.Function, f.Line)
+	}
+	stacks := a.stacks[kind]
+	if stacks == nil {
+		stacks = map[string]*traceStack{}
+		a.stacks[kind] = stacks
+	}
+	s := stacks[key.String()]
+	if s == nil {
+		s = &traceStack{Frames: frames}
+		stacks[key.String()] = s
+	}
+	s.Count++
+	s.Duration += d
+}
+
+// newTraceHistogram returns a histogram of the given latencies, in buckets from 1µs increasing by factors of 4.
+func newTraceHistogram(latencies []time.Duration) traceHistogram {
+	h := traceHistogram{Count: len(latencies)}
+	if len(latencies) == 0 {
+		return h
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	quantile := func(q float64) time.Duration {
+		return latencies[int(math.Ceil(q*float64(len(latencies))))-1]
+	}
+	h.P50, h.P90, h.P99, h.Max = quantile(0.5), quantile(0.9), quantile(0.99), latencies[len(latencies)-1]
+	i := 0
+	for upper := time.Microsecond; ; upper *= 4 {
+		b := traceHistogramBucket{Upper: upper}
+		for ; i < len(latencies) && latencies[i] <= upper; i++ {
+			b.Count++
+		}
+		h.Buckets = append(h.Buckets, b)
+		if i == len(latencies) {
+			break
+		}
+	}
+	return h
+}
+
+// profile returns one of the traceProfileKinds as a pprof profile, whose values are the number of times goroutines
+// were in each stack & the total time they spent there.
+func (a *traceAnalysis) profile(kind string) *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "contentions", Unit: "count"}, {Type: "delay", Unit: "nanoseconds"}},
+		PeriodType: &profile.ValueType{Type: "contentions", Unit: "count"},
+		Period:     1,
+	}
+	functions := map[string]*profile.Function{}
+	locations := map[traceFrame]*profile.Location{}
+	for _, s := range a.Profiles[kind] {
+		sample := &profile.Sample{Value: []int64{int64(s.Count), int64(s.Duration)}}
+		for _, f := range s.Frames {
+			loc := locations[f]
+			if loc == nil {
+				fn := functions[f.Function+f.File]
+				if fn == nil {
+					fn = &profile.Function{ID: uint64(len(p.Function) + 1), Name: f.Function, SystemName: f.Function, Filename: f.File}
+					functions[f.Function+f.File] = fn
+					p.Function = append(p.Function, fn)
+				}
+				loc = &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn, Line: int64(f.Line)}}}
+				locations[f] = loc
+				p.Location = append(p.Location, loc)
+			}
+			sample.Location = append(sample.Location, loc)
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	return p
+}
+
+// profileData returns one of the traceProfileKinds as a gzipped pprof profile.
+func (a *traceAnalysis) profileData(kind string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := a.profile(kind).Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
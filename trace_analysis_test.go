@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"runtime"
+	"runtime/trace"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// traceWorkload blocks goroutines on a channel & the network and runs a GC, to give a trace something to show.
+func traceWorkload(t *testing.T) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(20 * time.Millisecond)
+		conn.Write([]byte("x"))
+	}()
+	ch := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() { <-ch }()
+	}
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Read(make([]byte, 1))
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	close(ch)
+}
+
+// testTrace captures a trace of traceWorkload.
+func testTrace(t *testing.T) []byte {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []byte)
+	go func() {
+		data, err := captureTrace(ctx, nil, time.Minute)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- data
+	}()
+	// Wait for the trace to start before running anything in it.
+	for i := 0; i < 1000 && !trace.IsEnabled(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	traceWorkload(t)
+	cancel()
+	return <-done
+}
+
+func TestAnalyseTrace(t *testing.T) {
+	a, err := analyseTrace(bytes.NewReader(testTrace(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Duration <= 0 || a.Events == 0 || a.Goroutines < 4 || len(a.GoroutineTypes) == 0 {
+		t.Errorf("unexpected analysis %+v", a)
+	}
+	if len(a.GC) == 0 || len(a.STW) == 0 || a.STWTotal <= 0 || len(a.Heap) == 0 {
+		t.Errorf("no GC in analysis: %+v %+v", a.GC, a.STW)
+	}
+	if a.SchedLatency.Count == 0 || len(a.SchedLatency.Buckets) == 0 || a.SchedLatency.Max < a.SchedLatency.P50 {
+		t.Errorf("unexpected scheduler latency %+v", a.SchedLatency)
+	}
+	for _, kind := range []string{"net", "sync"} {
+		if len(a.Profiles[kind]) == 0 || a.ProfileTotals[kind] <= 0 {
+			t.Errorf("empty %s profile", kind)
+		}
+		data, err := a.profileData(kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := profile.ParseData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Sample) != len(a.Profiles[kind]) || len(p.SampleType) != 2 {
+			t.Errorf("unexpected %s profile %s", kind, p)
+		}
+	}
+	if _, err := analyseTrace(bytes.NewReader([]byte("nonsense"))); err == nil {
+		t.Error("invalid trace analysed")
+	}
+}
+
+func TestTraceHistogram(t *testing.T) {
+	h := newTraceHistogram([]time.Duration{time.Microsecond, 2 * time.Microsecond, 3 * time.Microsecond, time.Millisecond})
+	if h.Count != 4 || h.Max != time.Millisecond || h.P50 != 2*time.Microsecond {
+		t.Errorf("unexpected histogram %+v", h)
+	}
+	total := 0
+	for _, b := range h.Buckets {
+		total += b.Count
+	}
+	if total != 4 {
+		t.Errorf("buckets count %d latencies", total)
+	}
+	if h := newTraceHistogram(nil); h.Count != 0 {
+		t.Errorf("unexpected histogram %+v", h)
+	}
+}
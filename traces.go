@@ -0,0 +1,454 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTraceDuration is how long traces are captured for if not otherwise given.
+const defaultTraceDuration = 5 * time.Second
+
+// maxTraceDuration is the longest trace that can be captured; traces are large & costly to analyse.
+const maxTraceDuration = time.Minute
+
+// defaultTraceRetain is the number of traces retained when Opts.TraceRetain isn't set.
+const defaultTraceRetain = 5
+
+// A capturedTrace is an execution trace retained in a traceStore.
+type capturedTrace struct {
+	ID       int           `json:"id"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Trigger  string        `json:"trigger"`
+	Size     int           `json:"size"`
+	// GoroutineSnapshot is the ID of the goroutine snapshot taken with the trace, if there is one.
+	GoroutineSnapshot int `json:"goroutine_snapshot,omitempty"`
+	data              []byte
+	goroutines        []byte
+	once              sync.Once
+	analysis          *traceAnalysis
+	err               error
+}
+
+// filename is the name the trace is downloaded as.
+func (t *capturedTrace) filename() string {
+	return fmt.Sprintf("trace-%d-%s.out", t.ID, t.Time.UTC().Format("20060102T150405Z"))
+}
+
+// analyse returns the analysis of the trace, which is only done the first time it's needed.
+func (t *capturedTrace) analyse() (*traceAnalysis, error) {
+	t.once.Do(func() {
+		t.analysis, t.err = analyseTrace(bytes.NewReader(t.data))
+	})
+	return t.analysis, t.err
+}
+
+// A traceStore retains the most recently captured traces in memory.
+type traceStore struct {
+	mutex  sync.Mutex
+	retain int
+	nextID int
+	traces []*capturedTrace
+}
+
+func newTraceStore(retain int) *traceStore {
+	if retain <= 0 {
+		retain = defaultTraceRetain
+	}
+	return &traceStore{retain: retain}
+}
+
+// add retains a trace, assigning it an ID & discarding the oldest if there are too many.
+func (s *traceStore) add(t *capturedTrace) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextID++
+	t.ID = s.nextID
+	t.Size = len(t.data)
+	s.traces = append(s.traces, t)
+	if len(s.traces) > s.retain {
+		s.traces = s.traces[len(s.traces)-s.retain:]
+	}
+}
+
+// list returns the retained traces, newest first.
+func (s *traceStore) list() []*capturedTrace {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ret := make([]*capturedTrace, len(s.traces))
+	for i, t := range s.traces {
+		ret[len(ret)-1-i] = t
+	}
+	return ret
+}
+
+// get returns the retained trace with the given ID, or nil if there isn't one.
+func (s *traceStore) get(id string) *capturedTrace {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, t := range s.traces {
+		if strconv.Itoa(t.ID) == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// getTraces returns the server's trace store, which retains the default number of traces if it's not been set via Start.
+func (a *HTTPServer) getTraces() *traceStore {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.traces == nil {
+		a.traces = newTraceStore(defaultTraceRetain)
+	}
+	return a.traces
+}
+
+// captureTrace captures an execution trace for the given duration, or until the context is done, subject to the
+// limits on the tracer. Only one trace can be captured at a time, including via /debug/pprof/trace.
+func captureTrace(ctx context.Context, limits *debugLimiters, duration time.Duration) ([]byte, error) {
+	var buf bytes.Buffer
+	err := limits.do(ctx, tracerResource, duration, func(ctx context.Context) error {
+		if err := trace.Start(&buf); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(duration):
+		case <-ctx.Done():
+		}
+		trace.Stop()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// A traceTimelineRect is a span drawn on a traceTimeline.
+type traceTimelineRect struct {
+	X, Width float64
+	Title    string
+}
+
+// A traceTimeline is the GC cycles, stop-the-world pauses & heap size over a trace, scaled to draw as an SVG.
+type traceTimeline struct {
+	GC, STW        []traceTimelineRect
+	Heap, HeapGoal string
+	MaxHeap        uint64
+}
+
+// traceTimelineWidth & traceTimelineHeapHeight are the size of the timeline drawn, before it's scaled to the page.
+const (
+	traceTimelineWidth      = 1000
+	traceTimelineHeapHeight = 80
+)
+
+func newTraceTimeline(a *traceAnalysis) traceTimeline {
+	var t traceTimeline
+	x := func(d time.Duration) float64 {
+		if a.Duration <= 0 {
+			return 0
+		}
+		return float64(traceTimelineWidth) * float64(d) / float64(a.Duration)
+	}
+	rects := func(spans []traceSpan) []traceTimelineRect {
+		ret := make([]traceTimelineRect, len(spans))
+		for i, s := range spans {
+			ret[i] = traceTimelineRect{X: x(s.Start), Width: x(s.Duration), Title: fmt.Sprintf("%s at %s for %s", s.Name, s.Start, s.Duration)}
+			// Pauses are often far too short to see at this scale.
+			if ret[i].Width < 1 {
+				ret[i].Width = 1
+			}
+		}
+		return ret
+	}
+	t.GC, t.STW = rects(a.GC), rects(a.STW)
+	for _, ps := range [][]tracePoint{a.Heap, a.HeapGoal} {
+		for _, p := range ps {
+			if p.Value > t.MaxHeap {
+				t.MaxHeap = p.Value
+			}
+		}
+	}
+	points := func(ps []tracePoint) string {
+		var b strings.Builder
+		for _, p := range ps {
+			y := float64(traceTimelineHeapHeight)
+			if t.MaxHeap > 0 {
+				y -= float64(traceTimelineHeapHeight) * float64(p.Value) / float64(t.MaxHeap)
+			}
+			fmt.Fprintf(&b, "%.1f,%.1f ", x(p.Time), y+30)
+		}
+		return b.String()
+	}
+	t.Heap, t.HeapGoal = points(a.Heap), points(a.HeapGoal)
+	return t
+}
+
+type tracesView struct {
+	Traces         []*capturedTrace
+	FlightRecorder bool
+	CSRF           template.HTML
+}
+
+var tracesTemplate = template.Must(template.New("traces").Funcs(template.FuncMap{
+	"bytes": func(n int) string { return formatBytes(uint64(n)) },
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+}).Parse(`
+{{if .FlightRecorder}}
+<p>The <a href="/admin/flightrecorder">flight recorder</a> is tracing the process, so traces are captured by snapshotting it.</p>
+{{else}}
+<form method="POST" action="/admin/traces" class="form-inline mb-3">{{.CSRF}}
+	<input type="number" name="seconds" min="1" step="any" class="form-control form-control-sm mr-2 mb-1" placeholder="Seconds (5)"/>
+	<input type="submit" class="btn btn-sm btn-primary mb-1" value="Capture trace"/>
+</form>
+{{end}}
+<table class="table table-sm">
+	<thead><tr><th>id</th><th>captured</th><th>duration</th><th>trigger</th><th>size</th><th></th></tr></thead>
+	<tbody>
+	{{range .Traces}}
+		<tr>
+			<td>{{.ID}}</td>
+			<td>{{time .Time}}</td>
+			<td>{{if .Duration}}{{.Duration}}{{end}}</td>
+			<td>{{.Trigger}}</td>
+			<td>{{bytes .Size}}</td>
+			<td>
+				<a href="/admin/traces/{{.ID}}">analyse</a> <a href="/admin/traces/{{.ID}}/download">download</a>
+				{{if .GoroutineSnapshot}}<a href="/admin/traces/{{.ID}}/goroutines">goroutines</a>{{end}}
+			</td>
+		</tr>
+	{{else}}
+		<tr><td colspan="6">No traces have been captured.</td></tr>
+	{{end}}
+	</tbody>
+</table>
+`))
+
+type traceView struct {
+	*traceAnalysis
+	Trace        *capturedTrace
+	Timeline     traceTimeline
+	Descriptions map[string]string
+	Kinds        []string
+}
+
+var traceTemplate = template.Must(template.New("trace").Funcs(template.FuncMap{
+	"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"bytes": formatBytes,
+	"bar": func(n, max int) int {
+		if max == 0 {
+			return 0
+		}
+		return 200 * n / max
+	},
+	"maxCount": func(buckets []traceHistogramBucket) int {
+		max := 0
+		for _, b := range buckets {
+			if b.Count > max {
+				max = b.Count
+			}
+		}
+		return max
+	},
+	"limitGroups": func(groups []*traceGoroutineGroup) []*traceGoroutineGroup {
+		if len(groups) > maxTraceGoroutineGroups {
+			return groups[:maxTraceGoroutineGroups]
+		}
+		return groups
+	},
+	"limitStacks": func(stacks []*traceStack) []*traceStack {
+		if len(stacks) > maxTraceProfileStacks {
+			return stacks[:maxTraceProfileStacks]
+		}
+		return stacks
+	},
+}).Parse(`
+<p>
+	Trace {{.Trace.ID}} captured at {{time .Trace.Time}} ({{.Trace.Trigger}}) over {{.Duration}}: {{.Events}} events from {{.Goroutines}} goroutines,
+	{{len .GC}} GC cycles & {{len .STW}} stop-the-world pauses totalling {{.STWTotal}}.
+	<a href="/admin/traces/{{.Trace.ID}}/download">Download</a> to open with <code>go tool trace</code>.
+	{{with .Trace.GoroutineSnapshot}}
+	The goroutines at the time were snapshotted: see the <a href="/admin/traces/{{$.Trace.ID}}/goroutines">full dump</a>
+	or <a href="/admin/goroutines?base={{.}}">how they've changed since</a>.
+	{{end}}
+</p>
+
+<h5>GC & stop-the-world timeline</h5>
+<svg viewBox="0 0 1000 115" width="100%" height="115" preserveAspectRatio="none" class="mb-1">
+	<text x="2" y="10" font-size="10" fill="#666">GC</text>
+	{{range .Timeline.GC}}<rect x="{{.X}}" y="0" width="{{.Width}}" height="12" fill="#ff9900"><title>{{.Title}}</title></rect>{{end}}
+	<text x="2" y="25" font-size="10" fill="#666">STW</text>
+	{{range .Timeline.STW}}<rect x="{{.X}}" y="15" width="{{.Width}}" height="12" fill="#dc3912"><title>{{.Title}}</title></rect>{{end}}
+	<polyline points="{{.Timeline.HeapGoal}}" fill="none" stroke="#999" stroke-dasharray="4 2"><title>heap goal</title></polyline>
+	<polyline points="{{.Timeline.Heap}}" fill="none" stroke="#3366cc"><title>heap objects</title></polyline>
+</svg>
+<p class="text-muted small">Heap objects (solid) & heap goal (dashed), up to {{bytes .Timeline.MaxHeap}}.</p>
+
+<h5>Scheduler latency</h5>
+<p>
+	{{.SchedLatency.Count}} times goroutines were scheduled after becoming runnable: p50 {{.SchedLatency.P50}}, p90 {{.SchedLatency.P90}},
+	p99 {{.SchedLatency.P99}}, max {{.SchedLatency.Max}}.
+</p>
+{{$max := maxCount .SchedLatency.Buckets}}
+<table class="table table-sm w-auto">
+	<tbody>
+	{{range .SchedLatency.Buckets}}
+		<tr><td>≤ {{.Upper}}</td><td>{{.Count}}</td><td><svg width="200" height="10"><rect width="{{bar .Count $max}}" height="10" fill="#3366cc"></rect></svg></td></tr>
+	{{end}}
+	</tbody>
+</table>
+
+<h5>Goroutines</h5>
+<table class="table table-sm">
+	<thead><tr><th>start function</th><th>count</th><th>execution</th><th>sched wait</th><th>blocked</th><th>syscall</th></tr></thead>
+	<tbody>
+	{{range limitGroups .GoroutineTypes}}
+		<tr><td><code>{{.Function}}</code></td><td>{{.Count}}</td><td>{{.Execution}}</td><td>{{.Scheduled}}</td><td>{{.Blocked}}</td><td>{{.Syscall}}</td></tr>
+	{{end}}
+	</tbody>
+</table>
+
+{{range $kind := .Kinds}}
+<h5>{{index $.Descriptions $kind}}</h5>
+{{with index $.Profiles $kind}}
+<p>{{index $.ProfileTotals $kind}} in total. <a href="/admin/traces/{{$.Trace.ID}}/{{$kind}}.pb.gz">Download</a> as a profile.</p>
+<table class="table table-sm">
+	<thead><tr><th>time</th><th>count</th><th>stack</th></tr></thead>
+	<tbody>
+	{{range limitStacks .}}
+		<tr>
+			<td>{{.Duration}}</td>
+			<td>{{.Count}}</td>
+			<td><details><summary><code>{{(index .Frames 0).Function}}</code></summary>{{range .Frames}}<code>{{.Function}}</code><br/><small class="text-muted">{{.File}}:{{.Line}}</small><br/>{{end}}</details></td>
+		</tr>
+	{{end}}
+	</tbody>
+</table>
+{{else}}
+<p>None.</p>
+{{end}}
+{{end}}
+`))
+
+// tracesHandler lists the retained traces, with a form to capture another.
+func (a *HTTPServer) tracesHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "text/html;charset=UTF-8")
+	if err := tracesTemplate.Execute(w, tracesView{Traces: a.getTraces().list(), FlightRecorder: a.flightRecorderStatus().Recording, CSRF: CSRFField(r)}); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// tracesJSONHandler lists the retained traces, newest first.
+func (a *HTTPServer) tracesJSONHandler(w http.ResponseWriter, r *http.Request) {
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(a.getTraces().list())
+	w.Write(b)
+}
+
+// captureTraceHandler captures an execution trace over the number of seconds given by the seconds form value, or 5.
+func (a *HTTPServer) captureTraceHandler(w http.ResponseWriter, r *http.Request) {
+	limits := a.getDebugLimiters()
+	max := limits.maxDuration(tracerResource, maxTraceDuration)
+	duration := defaultTraceDuration
+	if s := r.FormValue("seconds"); s != "" {
+		seconds, err := strconv.ParseFloat(s, 64)
+		if err != nil || seconds <= 0 || seconds > max.Seconds() {
+			http.Error(w, fmt.Sprintf("seconds must be a positive number, at most %g", max.Seconds()), http.StatusBadRequest)
+			return
+		}
+		duration = time.Duration(seconds * float64(time.Second))
+	}
+	t := &capturedTrace{Time: time.Now(), Trigger: "manual"}
+	data, err := captureTrace(withDebugSource(r.Context(), r.RemoteAddr), limits, duration)
+	if writeDebugLimitError(w, err) {
+		return
+	} else if err != nil && a.flightRecorderStatus().Recording {
+		http.Error(w, "The flight recorder is tracing the process; snapshot it via /admin/flightrecorder instead", http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to start trace: %s", err), http.StatusConflict)
+		return
+	}
+	t.Duration, t.data = time.Since(t.Time), data
+	a.getTraces().add(t)
+	AnnotateAudit(r, fmt.Sprintf("captured trace %d over %s", t.ID, duration))
+	if expectsHTML(r) {
+		http.Redirect(w, r, "/admin/traces/"+strconv.Itoa(t.ID), http.StatusSeeOther)
+		return
+	}
+	writeContentType(w, "application/json;charset=UTF-8")
+	b, _ := json.Marshal(t)
+	w.Write(b)
+}
+
+// traceHandler serves a retained trace, given by the ID following /admin/traces/. The ID alone renders its
+// analysis, <id>.json returns it as JSON, <id>/download downloads the trace itself, <id>/goroutines the goroutine
+// dump taken with it by the flight recorder and <id>/<kind>.pb.gz one of the blocking profiles derived from it.
+func (a *HTTPServer) traceHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/admin/traces/")
+	id, suffix := rest, ""
+	if i := strings.IndexAny(rest, "./"); i != -1 {
+		id, suffix = rest[:i], rest[i:]
+	}
+	t := a.getTraces().get(id)
+	if t == nil {
+		http.Error(w, fmt.Sprintf("No trace %q; only the most recent are retained", id), http.StatusNotFound)
+		return
+	}
+	if suffix == "/goroutines" && t.goroutines != nil {
+		writeContentType(w, "text/plain;charset=UTF-8")
+		w.Write(t.goroutines)
+		return
+	} else if suffix == "/download" {
+		writeContentType(w, "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, t.filename()))
+		w.Write(t.data)
+		return
+	}
+	analysis, err := t.analyse()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to analyse trace: %s", err), http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case suffix == "":
+		view := traceView{
+			traceAnalysis: analysis,
+			Trace:         t,
+			Timeline:      newTraceTimeline(analysis),
+			Descriptions:  traceProfileDescriptions,
+			Kinds:         traceProfileKinds,
+		}
+		writeContentType(w, "text/html;charset=UTF-8")
+		if err := traceTemplate.Execute(w, view); err != nil {
+			log.Errorf("%s", err)
+		}
+	case suffix == ".json":
+		writeContentType(w, "application/json;charset=UTF-8")
+		b, _ := json.Marshal(analysis)
+		w.Write(b)
+	case strings.HasPrefix(suffix, "/") && strings.HasSuffix(suffix, ".pb.gz") && traceProfileDescriptions[strings.TrimSuffix(suffix[1:], ".pb.gz")] != "":
+		kind := strings.TrimSuffix(suffix[1:], ".pb.gz")
+		data, err := analysis.profileData(kind)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeContentType(w, "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="trace-%d-%s.pb.gz"`, t.ID, kind))
+		w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceStore(t *testing.T) {
+	s := newTraceStore(2)
+	for i := 0; i < 3; i++ {
+		s.add(&capturedTrace{Time: time.Now(), data: []byte("trace")})
+	}
+	if traces := s.list(); len(traces) != 2 || traces[0].ID != 3 || traces[1].ID != 2 || traces[0].Size != 5 {
+		t.Errorf("unexpected traces %+v", traces)
+	}
+	if s.get("1") != nil || s.get("3") == nil {
+		t.Error("unexpected traces retained")
+	}
+}
+
+func TestCaptureTraceHandler(t *testing.T) {
+	s := &HTTPServer{}
+	w := postHTML(s, "/admin/traces", url.Values{"seconds": {"0.05"}, csrfField: {"x"}})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/traces/1" {
+		t.Fatalf("unexpected %d: %s", w.Code, w.Body.String())
+	}
+	for _, seconds := range []string{"0", "61", "nonsense"} {
+		if w := postHTML(s, "/admin/traces", url.Values{"seconds": {seconds}, csrfField: {"x"}}); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: unexpected %d", seconds, w.Code)
+		}
+	}
+
+	if body := get(t, s, "/admin/traces", true).Body.String(); !strings.Contains(body, `href="/admin/traces/1/download"`) {
+		t.Errorf("unexpected page %s", body)
+	}
+	var traces []*capturedTrace
+	if err := json.Unmarshal(get(t, s, "/admin/traces.json", false).Body.Bytes(), &traces); err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 || traces[0].Trigger != "manual" || traces[0].Size == 0 {
+		t.Errorf("unexpected traces %+v", traces)
+	}
+	if w := get(t, s, "/admin/traces/1/download", false); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "go 1.") {
+		t.Errorf("unexpected download %d", w.Code)
+	}
+	if body := get(t, s, "/admin/traces/1", true).Body.String(); !strings.Contains(body, "Scheduler latency") || !strings.Contains(body, "<svg") {
+		t.Errorf("unexpected page %s", body)
+	}
+	var analysis traceAnalysis
+	if err := json.Unmarshal(get(t, s, "/admin/traces/1.json", false).Body.Bytes(), &analysis); err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Events == 0 {
+		t.Errorf("unexpected analysis %+v", analysis)
+	}
+	if w := get(t, s, "/admin/traces/1/sync.pb.gz", false); w.Code != http.StatusOK {
+		t.Errorf("unexpected %d", w.Code)
+	}
+	for _, path := range []string{"/admin/traces/2", "/admin/traces/1/nonsense.pb.gz", "/admin/traces/1.txt"} {
+		if w := get(t, s, path, false); w.Code != http.StatusNotFound {
+			t.Errorf("%s: unexpected %d", path, w.Code)
+		}
+	}
+}
+
+func TestTraceTimeline(t *testing.T) {
+	a := &traceAnalysis{
+		Duration: time.Second,
+		GC:       []traceSpan{{Name: "GC", Start: 500 * time.Millisecond, Duration: 100 * time.Millisecond}},
+		STW:      []traceSpan{{Name: "stop-the-world (GC)", Start: 500 * time.Millisecond, Duration: time.Microsecond}},
+		Heap:     []tracePoint{{Time: 0, Value: 50}, {Time: time.Second, Value: 100}},
+	}
+	tl := newTraceTimeline(a)
+	if tl.GC[0].X != 500 || tl.GC[0].Width != 100 || tl.STW[0].Width != 1 || tl.MaxHeap != 100 {
+		t.Errorf("unexpected timeline %+v", tl)
+	}
+	if tl.Heap != "0.0,70.0 1000.0,30.0 " {
+		t.Errorf("unexpected heap %q", tl.Heap)
+	}
+}
+
+func TestCaptureTraceLimits(t *testing.T) {
+	s := &HTTPServer{}
+	s.debugLimits, _ = newDebugLimiters(Opts{DebugMaxDuration: 10 * time.Second, DebugCooldown: time.Minute})
+	if w := postHTML(s, "/admin/traces", url.Values{"seconds": {"11"}, csrfField: {"x"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("unexpected %d", w.Code)
+	}
+	if w := postHTML(s, "/admin/traces", url.Values{"seconds": {"0.01"}, csrfField: {"x"}}); w.Code != http.StatusSeeOther {
+		t.Fatalf("unexpected %d: %s", w.Code, w.Body.String())
+	}
+	// Traces can't be captured back to back during the cooldown.
+	w := postHTML(s, "/admin/traces", url.Values{"seconds": {"0.01"}, csrfField: {"x"}})
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || retry < 55 {
+		t.Errorf("unexpected %d, Retry-After %s", w.Code, w.Header().Get("Retry-After"))
+	}
+}